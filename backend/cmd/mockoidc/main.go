@@ -0,0 +1,247 @@
+// Command mockoidc is a minimal OpenID Connect issuer for local development. It signs every user in
+// without asking for a password, but otherwise behaves like a real provider: the authorization code
+// flow with PKCE (S256 only), the nonce, and RS256 ID tokens verified against its JWKS.
+//
+//	go run ./cmd/mockoidc
+//
+//	MOCK_OIDC_ADDR=:9999                    where to listen
+//	MOCK_OIDC_ISSUER=http://localhost:9999  issuer URL, as the API reaches it
+//	MOCK_OIDC_EMAIL=mock.user@example.com   default user; pass login_hint=<email> to sign in as someone else
+//	MOCK_OIDC_EMAIL_VERIFIED=true
+//
+// Point a provider at it with OIDC_PROVIDERS=local and OIDC_LOCAL_ISSUER=http://localhost:9999.
+// The client ID, secret and redirect URL are accepted as they are, but the token request must
+// repeat the client ID and redirect URL of the authorization request.
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/giakiet05/lkforum/internal/config"
+	"github.com/giakiet05/lkforum/internal/oidc"
+	"github.com/giakiet05/lkforum/internal/util"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// codeTTL is how long an authorization code can be exchanged
+const codeTTL = time.Minute
+
+type mockIssuer struct {
+	issuer        string
+	key           *rsa.PrivateKey
+	kid           string
+	email         string
+	emailVerified bool
+
+	mu    sync.Mutex
+	codes map[string]authorization
+}
+
+// authorization is what the token request has to match, remembered under the code
+type authorization struct {
+	clientID      string
+	redirectURI   string
+	codeChallenge string
+	nonce         string
+	email         string
+	expiresAt     time.Time
+}
+
+func main() {
+	addr := config.GetEnvWithDefault("MOCK_OIDC_ADDR", ":9999")
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		log.Fatalf("failed to generate signing key: %v", err)
+	}
+
+	m := &mockIssuer{
+		issuer:        strings.TrimSuffix(config.GetEnvWithDefault("MOCK_OIDC_ISSUER", "http://localhost:9999"), "/"),
+		key:           key,
+		kid:           randomToken(8),
+		email:         config.GetEnvWithDefault("MOCK_OIDC_EMAIL", "mock.user@example.com"),
+		emailVerified: config.GetEnvBoolWithDefault("MOCK_OIDC_EMAIL_VERIFIED", true),
+		codes:         make(map[string]authorization),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", m.discovery)
+	mux.HandleFunc("GET /authorize", m.authorize)
+	mux.HandleFunc("POST /token", m.token)
+	mux.HandleFunc("GET /jwks", m.jwks)
+
+	log.Printf("✅ Mock OIDC issuer %s listening on %s", m.issuer, addr)
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		log.Fatalf("mock OIDC issuer stopped: %v", err)
+	}
+}
+
+func (m *mockIssuer) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                m.issuer,
+		"authorization_endpoint":                m.issuer + "/authorize",
+		"token_endpoint":                        m.issuer + "/token",
+		"jwks_uri":                              m.issuer + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+// authorize approves the request right away and sends the browser back with a code
+func (m *mockIssuer) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	redirectURI, err := url.Parse(query.Get("redirect_uri"))
+	if err != nil || redirectURI.Scheme == "" {
+		writeError(w, "invalid_request", "redirect_uri is missing or not absolute")
+		return
+	}
+	switch {
+	case query.Get("response_type") != "code":
+		writeError(w, "unsupported_response_type", "only the authorization code flow is supported")
+		return
+	case query.Get("client_id") == "":
+		writeError(w, "invalid_request", "client_id is missing")
+		return
+	case query.Get("code_challenge") == "" || query.Get("code_challenge_method") != "S256":
+		writeError(w, "invalid_request", "PKCE with code_challenge_method S256 is required")
+		return
+	}
+
+	email := m.email
+	if hint := strings.TrimSpace(query.Get("login_hint")); hint != "" {
+		email = hint
+	}
+
+	code := randomToken(16)
+	m.mu.Lock()
+	m.codes[code] = authorization{
+		clientID:      query.Get("client_id"),
+		redirectURI:   redirectURI.String(),
+		codeChallenge: query.Get("code_challenge"),
+		nonce:         query.Get("nonce"),
+		email:         email,
+		expiresAt:     time.Now().Add(codeTTL),
+	}
+	m.mu.Unlock()
+
+	callback := redirectURI.Query()
+	callback.Set("code", code)
+	callback.Set("state", query.Get("state"))
+	redirectURI.RawQuery = callback.Encode()
+	http.Redirect(w, r, redirectURI.String(), http.StatusFound)
+}
+
+// token exchanges a code for an ID token once the client proves it started the flow
+func (m *mockIssuer) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeError(w, "invalid_request", "malformed form body")
+		return
+	}
+	if r.PostForm.Get("grant_type") != "authorization_code" {
+		writeError(w, "unsupported_grant_type", "only authorization_code is supported")
+		return
+	}
+
+	// A code is used once, whatever the outcome
+	m.mu.Lock()
+	auth, ok := m.codes[r.PostForm.Get("code")]
+	delete(m.codes, r.PostForm.Get("code"))
+	m.mu.Unlock()
+
+	switch {
+	case !ok || time.Now().After(auth.expiresAt):
+		writeError(w, "invalid_grant", "unknown, used or expired code")
+		return
+	case r.PostForm.Get("client_id") != auth.clientID || r.PostForm.Get("redirect_uri") != auth.redirectURI:
+		writeError(w, "invalid_grant", "client_id or redirect_uri does not match the authorization request")
+		return
+	case subtle.ConstantTimeCompare([]byte(oidc.CodeChallenge(r.PostForm.Get("code_verifier"))), []byte(auth.codeChallenge)) != 1:
+		writeError(w, "invalid_grant", "code_verifier does not match the code_challenge")
+		return
+	}
+
+	idToken, err := m.signIDToken(auth)
+	if err != nil {
+		log.Printf("⚠️ Failed to sign ID token: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": randomToken(16),
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     idToken,
+	})
+}
+
+func (m *mockIssuer) signIDToken(auth authorization) (string, error) {
+	now := time.Now()
+	username := strings.SplitN(auth.email, "@", 2)[0]
+	claims := jwt.MapClaims{
+		"iss":                m.issuer,
+		"sub":                "mock|" + strings.ToLower(auth.email),
+		"aud":                auth.clientID,
+		"iat":                now.Unix(),
+		"exp":                now.Add(5 * time.Minute).Unix(),
+		"email":              auth.email,
+		"email_verified":     m.emailVerified,
+		"name":               username,
+		"preferred_username": username,
+	}
+	if auth.nonce != "" {
+		claims["nonce"] = auth.nonce
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = m.kid
+	return token.SignedString(m.key)
+}
+
+func (m *mockIssuer) jwks(w http.ResponseWriter, r *http.Request) {
+	public := m.key.PublicKey
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": m.kid,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+		}},
+	})
+}
+
+// randomToken is util.RandomToken for codes and key IDs; crypto/rand does not fail on supported platforms
+func randomToken(n int) string {
+	token, err := util.RandomToken(n)
+	if err != nil {
+		log.Fatalf("failed to read random bytes: %v", err)
+	}
+	return token
+}
+
+func writeError(w http.ResponseWriter, code string, description string) {
+	writeJSON(w, http.StatusBadRequest, map[string]string{"error": code, "error_description": description})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("⚠️ Failed to write response: %v", err)
+	}
+}
@@ -2,6 +2,7 @@ package apperror
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 )
 
@@ -15,29 +16,35 @@ func (e AppError) Error() string {
 	return e.Message
 }
 
+// Is matches AppErrors by Code, so an error carrying a more specific Message still matches the error it was derived from
+func (e AppError) Is(target error) bool {
+	t, ok := target.(AppError)
+	return ok && t.Code == e.Code
+}
+
+// WithMessage returns a copy of the error with a more specific Message, keeping its Code and HTTP status
+func (e AppError) WithMessage(format string, args ...interface{}) AppError {
+	return AppError{Code: e.Code, Message: fmt.Sprintf(format, args...)}
+}
+
 // Code extracts the error Code from an error, returning the AppError Code if it's an AppError, otherwise returns INTERNAL_ERROR
 func Code(err error) string {
-	if isAppError(err) {
-		return err.(AppError).Code
+	var appError AppError
+	if errors.As(err, &appError) {
+		return appError.Code
 	}
 	return ErrInternal.Code
 }
 
 // Message extracts the error Message from an error, returning the AppError Message if it's an AppError, otherwise returns a generic internal error Message
 func Message(err error) string {
-	if isAppError(err) {
-		return err.(AppError).Message
+	var appError AppError
+	if errors.As(err, &appError) {
+		return appError.Message
 	}
 	return ErrInternal.Message
 }
 
-// isAppError checks if an error is an AppError (safe to expose to frontend)
-func isAppError(err error) bool {
-	var appError AppError
-	ok := errors.As(err, &appError)
-	return ok
-}
-
 // isErrorType checks if err matches any of the provided target errors
 func isErrorType(err error, targets ...error) bool {
 	for _, target := range targets {
@@ -52,20 +59,26 @@ func isErrorType(err error, targets ...error) bool {
 func StatusFromError(err error) int {
 	switch {
 	// 400 Bad Request
-	case isErrorType(err, ErrBadRequest, ErrInvalidID, ErrInvalidMembershipData):
+	case isErrorType(err, ErrBadRequest, ErrInvalidID, ErrInvalidMembershipData, ErrCannotMessageSelf, ErrNotMessageRequest, ErrInvalidReportReason, ErrInvalidReportTarget, ErrInvalidReportStatus, ErrCannotReportSelf, ErrInvalidBanType, ErrInvalidRole, ErrInvalidPermission, ErrNotModerator, ErrInvalidModPermission, ErrNoPendingTransfer, ErrInviteExpired, ErrInvalidAutoModRule, ErrInvalidContentFilter, ErrContentBlocked, ErrInvalidSetting, ErrNotAppealable, ErrInvalidAppealStatus, ErrInvalidVerificationToken, ErrInvalidResetToken, ErrTwoFactorNotEnabled, ErrTwoFactorNotPending, ErrInvalidOIDCState, ErrInvalidSignupToken, ErrOIDCEmailRequired, ErrCannotUnlinkLastLogin, ErrAccountNotLocked, ErrInvalidTokenScope, ErrAccessTokenLimit):
 		return http.StatusBadRequest
 	// 401 Unauthorized
-	case isErrorType(err, ErrInvalidCredentials, ErrInvalidToken, ErrInvalidClaims, ErrInvalidIssuer, ErrInvalidAudience, ErrTokenInvalidated):
+	case isErrorType(err, ErrInvalidCredentials, ErrInvalidToken, ErrInvalidClaims, ErrInvalidIssuer, ErrInvalidAudience, ErrTokenInvalidated, ErrRefreshTokenReused, ErrInvalidMFAToken, ErrInvalidTwoFactorCode, ErrOIDCLoginFailed):
 		return http.StatusUnauthorized
 	// 403 Forbidden
-	case isErrorType(err, ErrForbidden, ErrUserInactive, ErrUserNotMember):
+	case isErrorType(err, ErrForbidden, ErrUserInactive, ErrCannotSuspend, ErrUserNotMember, ErrNotConversationMember, ErrUserBlocked, ErrBannedFromCommunity, ErrMutedInCommunity, ErrCannotBanModerator, ErrModeratorOutranked, ErrAccountTooNew, ErrNotEnoughKarma, ErrCannotReviewOwnAction, ErrEmailNotVerified, ErrTwoFactorRequired, ErrInsufficientScope, ErrSessionLoginRequired):
 		return http.StatusForbidden
 	// 404 Not Found
-	case isErrorType(err, ErrUserNotFound, ErrCommunityNotFound, ErrMembershipNotFound):
+	case isErrorType(err, ErrUserNotFound, ErrCommunityNotFound, ErrMembershipNotFound, ErrConversationNotFound, ErrReportNotFound, ErrPostNotFound, ErrCommentNotFound, ErrBanNotFound, ErrInviteNotFound, ErrModmailThreadNotFound, ErrRemovalReasonNotFound, ErrAppealNotFound, ErrOIDCProviderNotFound, ErrIdentityNotFound, ErrSessionNotFound, ErrAccessTokenNotFound):
 		return http.StatusNotFound
 	// 409 Conflict
-	case isErrorType(err, ErrUsernameExists, ErrEmailExists, ErrCommunityNameExists, ErrAlreadyMember):
+	case isErrorType(err, ErrUsernameExists, ErrEmailExists, ErrCommunityNameExists, ErrAlreadyMember, ErrAlreadyReported, ErrReportAlreadyResolved, ErrAlreadyModerator, ErrAlreadyAppealed, ErrAppealAlreadyResolved, ErrEmailAlreadyVerified, ErrTwoFactorAlreadyEnabled, ErrOIDCEmailExists, ErrIdentityAlreadyLinked):
 		return http.StatusConflict
+	// 429 Too Many Requests
+	case isErrorType(err, ErrTooManyRequests, ErrSlowMode, ErrTooManyLoginAttempts, ErrAccountLocked):
+		return http.StatusTooManyRequests
+	// 503 Service Unavailable
+	case isErrorType(err, ErrTokenStatusUnavailable):
+		return http.StatusServiceUnavailable
 	// 500 Internal Server Error
 	case isErrorType(err, ErrInternal, ErrNoFieldsToUpdate, ErrMembershipCreateFailed, ErrMembershipDeleteFailed):
 		return http.StatusInternalServerError
@@ -76,30 +89,89 @@ func StatusFromError(err error) int {
 
 var (
 	// Auth-related
-	ErrInvalidCredentials = AppError{Code: "INVALID_CREDENTIALS", Message: "Invalid username or password"}
-	ErrInvalidToken       = AppError{Code: "INVALID_TOKEN", Message: "Invalid or expired token"}
-	ErrInvalidClaims      = AppError{Code: "INVALID_CLAIMS", Message: "Invalid token claims"}
-	ErrInvalidIssuer      = AppError{Code: "INVALID_ISSUER", Message: "Invalid token issuer"}
-	ErrInvalidAudience    = AppError{Code: "INVALID_AUDIENCE", Message: "Invalid token audience"}
-	ErrTokenInvalidated   = AppError{Code: "TOKEN_INVALIDATED", Message: "Token has been invalidated"}
-	ErrForbidden          = AppError{Code: "FORBIDDEN", Message: "You do not have permission to perform this action"}
-	ErrBadRequest         = AppError{Code: "BAD_REQUEST", Message: "Bad request"}
+	ErrInvalidCredentials     = AppError{Code: "INVALID_CREDENTIALS", Message: "Invalid username or password"}
+	ErrInvalidToken           = AppError{Code: "INVALID_TOKEN", Message: "Invalid or expired token"}
+	ErrInvalidClaims          = AppError{Code: "INVALID_CLAIMS", Message: "Invalid token claims"}
+	ErrInvalidIssuer          = AppError{Code: "INVALID_ISSUER", Message: "Invalid token issuer"}
+	ErrInvalidAudience        = AppError{Code: "INVALID_AUDIENCE", Message: "Invalid token audience"}
+	ErrTokenInvalidated       = AppError{Code: "TOKEN_INVALIDATED", Message: "Token has been invalidated"}
+	ErrRefreshTokenReused     = AppError{Code: "REFRESH_TOKEN_REUSED", Message: "This refresh token was already used, the session has been signed out"}
+	ErrTokenStatusUnavailable = AppError{Code: "TOKEN_STATUS_UNAVAILABLE", Message: "Sign-ins cannot be checked right now, please try again shortly"}
+
+	// Login throttling
+	ErrTooManyLoginAttempts = AppError{Code: "TOO_MANY_LOGIN_ATTEMPTS", Message: "Too many failed login attempts, please try again later"}
+	ErrAccountLocked        = AppError{Code: "ACCOUNT_LOCKED", Message: "This account is temporarily locked after too many failed login attempts"}
+	ErrAccountNotLocked     = AppError{Code: "ACCOUNT_NOT_LOCKED", Message: "This account is not locked"}
+	ErrForbidden            = AppError{Code: "FORBIDDEN", Message: "You do not have permission to perform this action"}
+	ErrBadRequest           = AppError{Code: "BAD_REQUEST", Message: "Bad request"}
 
 	// Generic
 	ErrInternal         = AppError{Code: "INTERNAL_ERROR", Message: "Internal server error"}
 	ErrNoFieldsToUpdate = AppError{Code: "NO_FIELDS_TO_UPDATE", Message: "No fields provided to update"}
 	ErrInvalidID        = AppError{Code: "INVALID_ID", Message: "Invalid ID format"}
+	ErrTooManyRequests  = AppError{Code: "TOO_MANY_REQUESTS", Message: "Too many requests, please slow down and try again later"}
 
 	// User-related
-	ErrUserNotFound   = AppError{Code: "USER_NOT_FOUND", Message: "User not found"}
-	ErrUsernameExists = AppError{Code: "USERNAME_EXISTS", Message: "Username already exists"}
-	ErrEmailExists    = AppError{Code: "EMAIL_EXISTS", Message: "Email already exists"}
-	ErrUserInactive   = AppError{Code: "USER_INACTIVE", Message: "User account is inactive"}
+	ErrUserNotFound      = AppError{Code: "USER_NOT_FOUND", Message: "User not found"}
+	ErrUsernameExists    = AppError{Code: "USERNAME_EXISTS", Message: "Username already exists"}
+	ErrEmailExists       = AppError{Code: "EMAIL_EXISTS", Message: "Email already exists"}
+	ErrCannotSuspend     = AppError{Code: "CANNOT_SUSPEND", Message: "Admins cannot be suspended"}
+	ErrInvalidRole       = AppError{Code: "INVALID_ROLE", Message: "Invalid role"}
+	ErrInvalidPermission = AppError{Code: "INVALID_PERMISSION", Message: "Invalid permission or role template"}
+	ErrUserInactive      = AppError{Code: "USER_INACTIVE", Message: "User account is suspended"}
+
+	// Email verification
+	ErrEmailNotVerified         = AppError{Code: "EMAIL_NOT_VERIFIED", Message: "Please verify your email address first"}
+	ErrEmailAlreadyVerified     = AppError{Code: "EMAIL_ALREADY_VERIFIED", Message: "Email address is already verified"}
+	ErrInvalidVerificationToken = AppError{Code: "INVALID_VERIFICATION_TOKEN", Message: "Verification link is invalid or has expired"}
+	ErrInvalidResetToken        = AppError{Code: "INVALID_RESET_TOKEN", Message: "Password reset link is invalid, expired or already used"}
+
+	// Two-factor authentication
+	ErrInvalidMFAToken         = AppError{Code: "INVALID_MFA_TOKEN", Message: "Login challenge is invalid or has expired, please log in again"}
+	ErrInvalidTwoFactorCode    = AppError{Code: "INVALID_2FA_CODE", Message: "Invalid authentication or recovery code"}
+	ErrTwoFactorNotEnabled     = AppError{Code: "2FA_NOT_ENABLED", Message: "Two-factor authentication is not enabled"}
+	ErrTwoFactorAlreadyEnabled = AppError{Code: "2FA_ALREADY_ENABLED", Message: "Two-factor authentication is already enabled"}
+	ErrTwoFactorNotPending     = AppError{Code: "2FA_NOT_PENDING", Message: "Start two-factor enrollment first"}
+	ErrTwoFactorRequired       = AppError{Code: "2FA_REQUIRED", Message: "Your admin role requires two-factor authentication"}
+
+	// Social login
+	ErrOIDCProviderNotFound  = AppError{Code: "OIDC_PROVIDER_NOT_FOUND", Message: "Login provider not found"}
+	ErrInvalidOIDCState      = AppError{Code: "INVALID_OIDC_STATE", Message: "Login request is invalid or has expired, please try again"}
+	ErrOIDCLoginFailed       = AppError{Code: "OIDC_LOGIN_FAILED", Message: "Could not sign in with the provider"}
+	ErrOIDCEmailRequired     = AppError{Code: "OIDC_EMAIL_REQUIRED", Message: "The provider did not share an email address"}
+	ErrOIDCEmailExists       = AppError{Code: "OIDC_EMAIL_EXISTS", Message: "An account with this email already exists. Log in and link the provider from your account settings"}
+	ErrInvalidSignupToken    = AppError{Code: "INVALID_SIGNUP_TOKEN", Message: "Sign up session is invalid or has expired, please sign in with the provider again"}
+	ErrIdentityNotFound      = AppError{Code: "IDENTITY_NOT_FOUND", Message: "Linked account not found"}
+	ErrIdentityAlreadyLinked = AppError{Code: "IDENTITY_ALREADY_LINKED", Message: "This provider account is already linked"}
+	ErrCannotUnlinkLastLogin = AppError{Code: "CANNOT_UNLINK_LAST_LOGIN", Message: "Set a password before unlinking your only login method"}
+
+	// Sessions
+	ErrSessionNotFound = AppError{Code: "SESSION_NOT_FOUND", Message: "Session not found"}
+
+	// Personal access tokens
+	ErrAccessTokenNotFound  = AppError{Code: "ACCESS_TOKEN_NOT_FOUND", Message: "Personal access token not found"}
+	ErrInvalidTokenScope    = AppError{Code: "INVALID_TOKEN_SCOPE", Message: "Choose at least one valid scope: read, post or moderate"}
+	ErrAccessTokenLimit     = AppError{Code: "ACCESS_TOKEN_LIMIT", Message: "You have reached the maximum number of personal access tokens"}
+	ErrInsufficientScope    = AppError{Code: "INSUFFICIENT_SCOPE", Message: "This access token does not have the scope needed for this request"}
+	ErrSessionLoginRequired = AppError{Code: "SESSION_LOGIN_REQUIRED", Message: "Personal access tokens cannot be used here, sign in instead"}
 
 	// Community-related
-	ErrCommunityNotFound   = AppError{Code: "COMMUNITY_NOT_FOUND", Message: "Community not found"}
-	ErrCommunityNameExists = AppError{Code: "COMMUNITY_NAME_EXISTS", Message: "Community name already exists"}
-	ErrUserNotMember       = AppError{Code: "USER_NOT_MEMBER", Message: "User is not a member of this community"}
+	ErrCommunityNotFound    = AppError{Code: "COMMUNITY_NOT_FOUND", Message: "Community not found"}
+	ErrCommunityNameExists  = AppError{Code: "COMMUNITY_NAME_EXISTS", Message: "Community name already exists"}
+	ErrUserNotMember        = AppError{Code: "USER_NOT_MEMBER", Message: "User is not a member of this community"}
+	ErrNotModerator         = AppError{Code: "NOT_MODERATOR", Message: "User is not a moderator of this community"}
+	ErrModeratorOutranked   = AppError{Code: "MODERATOR_OUTRANKED", Message: "You can only manage moderators below you"}
+	ErrInvalidModPermission = AppError{Code: "INVALID_MOD_PERMISSION", Message: "Invalid moderator permission"}
+	ErrNoPendingTransfer    = AppError{Code: "NO_PENDING_TRANSFER", Message: "There is no pending ownership transfer for you"}
+	ErrAlreadyModerator     = AppError{Code: "ALREADY_MODERATOR", Message: "User is already a moderator of this community"}
+	ErrInvalidSetting       = AppError{Code: "INVALID_COMMUNITY_SETTING", Message: "Community setting values cannot be negative"}
+	ErrSlowMode             = AppError{Code: "SLOW_MODE", Message: "This community is in slow mode, please wait before posting again"}
+	ErrAccountTooNew        = AppError{Code: "ACCOUNT_TOO_NEW", Message: "Your account is too new to post in this community"}
+	ErrNotEnoughKarma       = AppError{Code: "NOT_ENOUGH_KARMA", Message: "You do not have enough karma to post in this community"}
+
+	// Moderator invite-related
+	ErrInviteNotFound = AppError{Code: "INVITE_NOT_FOUND", Message: "Moderator invitation not found or no longer pending"}
+	ErrInviteExpired  = AppError{Code: "INVITE_EXPIRED", Message: "Moderator invitation has expired"}
 
 	// Membership-related
 	ErrMembershipNotFound     = AppError{Code: "MEMBERSHIP_NOT_FOUND", Message: "Membership not found"}
@@ -107,4 +179,50 @@ var (
 	ErrMembershipCreateFailed = AppError{Code: "MEMBERSHIP_CREATE_FAILED", Message: "Failed to create membership"}
 	ErrMembershipDeleteFailed = AppError{Code: "MEMBERSHIP_DELETE_FAILED", Message: "Failed to delete membership"}
 	ErrInvalidMembershipData  = AppError{Code: "INVALID_MEMBERSHIP_DATA", Message: "Invalid membership data"}
+
+	// Conversation-related
+	ErrConversationNotFound  = AppError{Code: "CONVERSATION_NOT_FOUND", Message: "Conversation not found"}
+	ErrNotConversationMember = AppError{Code: "NOT_CONVERSATION_MEMBER", Message: "User is not a member of this conversation"}
+	ErrCannotMessageSelf     = AppError{Code: "CANNOT_MESSAGE_SELF", Message: "You cannot send a message to yourself"}
+	ErrNotMessageRequest     = AppError{Code: "NOT_MESSAGE_REQUEST", Message: "Conversation is not a pending message request"}
+	ErrUserBlocked           = AppError{Code: "USER_BLOCKED", Message: "You cannot send messages to this user"}
+
+	// Modmail-related
+	ErrModmailThreadNotFound = AppError{Code: "MODMAIL_THREAD_NOT_FOUND", Message: "Modmail thread not found"}
+
+	// Content-related
+	ErrPostNotFound    = AppError{Code: "POST_NOT_FOUND", Message: "Post not found"}
+	ErrCommentNotFound = AppError{Code: "COMMENT_NOT_FOUND", Message: "Comment not found"}
+
+	// Report-related
+	ErrReportNotFound        = AppError{Code: "REPORT_NOT_FOUND", Message: "Report not found"}
+	ErrInvalidReportReason   = AppError{Code: "INVALID_REPORT_REASON", Message: "Invalid report reason"}
+	ErrInvalidReportTarget   = AppError{Code: "INVALID_REPORT_TARGET", Message: "Invalid report target"}
+	ErrInvalidReportStatus   = AppError{Code: "INVALID_REPORT_STATUS", Message: "Invalid report status"}
+	ErrCannotReportSelf      = AppError{Code: "CANNOT_REPORT_SELF", Message: "You cannot report yourself"}
+	ErrAlreadyReported       = AppError{Code: "ALREADY_REPORTED", Message: "You have already reported this content"}
+	ErrReportAlreadyResolved = AppError{Code: "REPORT_ALREADY_RESOLVED", Message: "Report has already been resolved"}
+
+	// Community ban-related
+	ErrBanNotFound         = AppError{Code: "BAN_NOT_FOUND", Message: "Ban not found"}
+	ErrInvalidBanType      = AppError{Code: "INVALID_BAN_TYPE", Message: "Invalid ban type"}
+	ErrBannedFromCommunity = AppError{Code: "BANNED_FROM_COMMUNITY", Message: "You are banned from this community"}
+	ErrMutedInCommunity    = AppError{Code: "MUTED_IN_COMMUNITY", Message: "You are muted in this community"}
+	ErrCannotBanModerator  = AppError{Code: "CANNOT_BAN_MODERATOR", Message: "Moderators cannot be banned or muted"}
+
+	// Removal reason and appeal-related
+	ErrRemovalReasonNotFound = AppError{Code: "REMOVAL_REASON_NOT_FOUND", Message: "Removal reason not found"}
+	ErrAppealNotFound        = AppError{Code: "APPEAL_NOT_FOUND", Message: "Appeal not found"}
+	ErrNotAppealable         = AppError{Code: "NOT_APPEALABLE", Message: "This action cannot be appealed"}
+	ErrAlreadyAppealed       = AppError{Code: "ALREADY_APPEALED", Message: "You have already appealed this action"}
+	ErrAppealAlreadyResolved = AppError{Code: "APPEAL_ALREADY_RESOLVED", Message: "Appeal has already been reviewed"}
+	ErrInvalidAppealStatus   = AppError{Code: "INVALID_APPEAL_STATUS", Message: "Appeals can only be granted or denied"}
+	ErrCannotReviewOwnAction = AppError{Code: "CANNOT_REVIEW_OWN_ACTION", Message: "Appeals must be reviewed by someone other than who took the action"}
+
+	// AutoMod-related
+	ErrInvalidAutoModRule = AppError{Code: "INVALID_AUTOMOD_RULE", Message: "Invalid AutoMod rule: check names, regexes, targets, triggers and actions"}
+
+	// Content filter-related
+	ErrInvalidContentFilter = AppError{Code: "INVALID_CONTENT_FILTER", Message: "Invalid content filter entry: check kind, pattern and action"}
+	ErrContentBlocked       = AppError{Code: "CONTENT_BLOCKED", Message: "Your submission contains words or links that are not allowed in this community"}
 )
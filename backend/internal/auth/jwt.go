@@ -2,9 +2,11 @@ package auth
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/giakiet05/lkforum/internal/apperror"
 	"github.com/giakiet05/lkforum/internal/config"
+	"github.com/giakiet05/lkforum/internal/model"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
@@ -14,8 +16,33 @@ import (
 
 // AuthUser đại diện cho user sau khi parse token
 type AuthUser struct {
-	ID   string
-	Role string
+	ID               string
+	SessionID        string
+	TokenID          string    // jti of the access token, used to deny it on logout
+	ExpiresAt        time.Time // when the access token expires
+	Role             string
+	Permissions      []model.Permission // admin permissions, empty for regular users
+	EmailVerified    bool
+	MFASetupRequired bool               // the admin role requires two-factor authentication the user has not enabled yet
+	Scopes           []model.TokenScope // set when authenticated with a personal access token, nil for a session
+}
+
+// IsPersonalAccessToken reports whether the request is authenticated with a personal access token
+func (u AuthUser) IsPersonalAccessToken() bool {
+	return u.Scopes != nil
+}
+
+// HasScope reports whether the credential may be used for the scope. Session tokens can do everything.
+func (u AuthUser) HasScope(scope model.TokenScope) bool {
+	if u.Scopes == nil {
+		return true
+	}
+	for _, s := range u.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
 }
 
 // Global token service instance
@@ -26,9 +53,11 @@ func SetTokenService(service *TokenService) {
 	TokenSvc = service
 }
 
+// Access tokens are signed with the rotating keys of Keys so other services can verify them from
+// the JWKS. Tokens that never leave this service are signed with secrets.
 var (
-	accessSecret  = []byte(os.Getenv("ACCESS_TOKEN_SECRET"))
 	refreshSecret = []byte(os.Getenv("REFRESH_TOKEN_SECRET"))
+	verifySecret  = []byte(os.Getenv("EMAIL_VERIFICATION_SECRET"))
 	issuer        = os.Getenv("JWT_ISS")
 	audience      = os.Getenv("JWT_AUD")
 )
@@ -36,77 +65,145 @@ var (
 // ====== CREATE ======
 
 // Tạo access token ngắn hạn
-func createAccessToken(userID, role string) (string, error) {
+func createAccessToken(userID, sessionID, role string, permissions []model.Permission, emailVerified bool, mfaSetupRequired bool) (string, error) {
+	if Keys == nil {
+		return "", errors.New("signing keys are not initialized")
+	}
+
 	expMinutes := config.GetEnvIntWithDefault("ACCESS_TOKEN_EXP_MIN", 15)
 	jti := uuid.New().String()
 
 	claims := jwt.MapClaims{
-		"sub":  userID,
-		"role": role,
-		"iss":  issuer,
-		"aud":  audience,
-		"iat":  time.Now().UTC().Unix(),
-		"exp":  time.Now().Add(time.Minute * time.Duration(expMinutes)).Unix(),
-		"jti":  jti,
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString(accessSecret)
-	if err != nil {
-		return "", err
+		"sub":       userID,
+		"sid":       sessionID,
+		"type":      "access",
+		"role":      role,
+		"perms":     permissions,
+		"ev":        emailVerified,
+		"mfa_setup": mfaSetupRequired,
+		"iss":       issuer,
+		"aud":       audience,
+		"iat":       time.Now().UTC().Unix(),
+		"exp":       time.Now().Add(time.Minute * time.Duration(expMinutes)).Unix(),
+		"jti":       jti,
 	}
 
-	return tokenString, nil
+	return Keys.Sign(claims)
 }
 
 // Tạo refresh token dài hạn
-func createRefreshToken(userID string) (string, error) {
+func createRefreshToken(userID, sessionID string, issuedAt time.Time) (string, string, error) {
 	expDays := config.GetEnvIntWithDefault("REFRESH_TOKEN_EXP_DAYS", 7)
 	jti := uuid.New().String()
 
 	claims := jwt.MapClaims{
 		"sub":  userID,
+		"sid":  sessionID,
 		"type": "refresh",
 		"iss":  issuer,
 		"aud":  audience,
-		"iat":  time.Now().UTC().Unix(),
-		"exp":  time.Now().Add(24 * time.Hour * time.Duration(expDays)).Unix(),
+		"iat":  issuedAt.UTC().Unix(),
+		"exp":  issuedAt.Add(24 * time.Hour * time.Duration(expDays)).Unix(),
 		"jti":  jti,
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	tokenString, err := token.SignedString(refreshSecret)
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
 
-	return tokenString, nil
+	return tokenString, jti, nil
 }
 
-func GenerateToken(id string, role string) (accessToken string, refreshToken string, err error) {
-	accessToken, err = createAccessToken(id, role)
+// TokenPair is what a login or refresh hands out. The session keeps RefreshTokenID to recognize
+// the refresh token it expects next.
+type TokenPair struct {
+	AccessToken    string
+	RefreshToken   string
+	RefreshTokenID string
+	IssuedAt       time.Time
+}
+
+// GenerateToken issues the tokens of a session
+func GenerateToken(user *model.User, sessionID string) (*TokenPair, error) {
+	id := user.ID.Hex()
+	issuedAt := time.Now()
+
+	// Until a required second factor is set up the admin keeps the role but none of its permissions
+	permissions := user.AdminPermissions()
+	mfaSetupRequired := user.TwoFactorRequired() && !user.TwoFactorEnabled()
+	if mfaSetupRequired {
+		permissions = nil
+	}
+
+	accessToken, err := createAccessToken(id, sessionID, string(user.Role), permissions, user.IsEmailVerified(), mfaSetupRequired)
 	if err != nil {
-		return "", "", err
+		return nil, err
 	}
 
-	refreshToken, err = createRefreshToken(id)
+	refreshToken, refreshTokenID, err := createRefreshToken(id, sessionID, issuedAt)
 	if err != nil {
-		return "", "", err
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:    accessToken,
+		RefreshToken:   refreshToken,
+		RefreshTokenID: refreshTokenID,
+		IssuedAt:       issuedAt,
+	}, nil
+}
+
+// CreateEmailVerificationToken signs the link sent to confirm an email address.
+// The address is part of the token, so changing it voids links sent to the old one.
+func CreateEmailVerificationToken(userID, email string) (string, error) {
+	expHours := config.GetEnvIntWithDefault("EMAIL_VERIFICATION_EXP_HOURS", 24)
+
+	claims := jwt.MapClaims{
+		"sub":   userID,
+		"email": email,
+		"type":  "email_verification",
+		"iss":   issuer,
+		"aud":   audience,
+		"iat":   time.Now().UTC().Unix(),
+		"exp":   time.Now().Add(time.Hour * time.Duration(expHours)).Unix(),
 	}
 
-	return accessToken, refreshToken, nil
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(verifySecret)
+}
+
+// CreateMFAToken issues the short-lived challenge returned by the first login step of a user with
+// two-factor authentication. It only proves the password was right and is exchanged for real
+// tokens once the second factor checks out.
+func CreateMFAToken(userID string) (string, error) {
+	expMinutes := config.GetEnvIntWithDefault("MFA_TOKEN_EXP_MIN", 5)
+
+	claims := jwt.MapClaims{
+		"sub":  userID,
+		"type": "mfa",
+		"iss":  issuer,
+		"aud":  audience,
+		"iat":  time.Now().UTC().Unix(),
+		"exp":  time.Now().Add(time.Minute * time.Duration(expMinutes)).Unix(),
+		"jti":  uuid.New().String(),
+	}
+
+	// Only this service reads the challenge, so it uses the refresh secret and never verifies as an access token
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(refreshSecret)
 }
 
 // ====== PARSE ======
 
 // Parse + validate access token
 func ParseAccessToken(tokenStr string) (AuthUser, error) {
-	token, err := jwt.Parse(tokenStr, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
-		}
-		return accessSecret, nil
-	})
+	if Keys == nil {
+		return AuthUser{}, apperror.ErrInvalidToken
+	}
+
+	token, err := jwt.Parse(tokenStr, Keys.Keyfunc, jwt.WithValidMethods(SigningAlgorithms))
 
 	if err != nil {
 		return AuthUser{}, apperror.ErrInvalidToken
@@ -129,22 +226,39 @@ func ParseAccessToken(tokenStr string) (AuthUser, error) {
 		return AuthUser{}, apperror.ErrInvalidAudience
 	}
 
+	// Only access tokens are signed with these keys, the type is checked all the same
+	if tokenType, ok := claims["type"].(string); ok && tokenType != "access" {
+		return AuthUser{}, apperror.ErrInvalidToken
+	}
+
 	userID, _ := claims["sub"].(string)
+	sessionID, _ := claims["sid"].(string)
 	role, _ := claims["role"].(string)
+	emailVerified, _ := claims["ev"].(bool)
+	mfaSetupRequired, _ := claims["mfa_setup"].(bool)
+	tokenID, _ := claims["jti"].(string)
+	var expiresAt time.Time
+	if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+		expiresAt = exp.Time
+	}
 
 	// Check if token has been invalidated (if token service is available)
-	if TokenSvc != nil {
-		ctx := context.Background()
-		if !TokenSvc.IsUserValid(ctx, userID) {
-			return AuthUser{}, apperror.ErrTokenInvalidated
-		}
+	if err := checkTokenStatus(claims, userID); err != nil {
+		return AuthUser{}, err
 	}
 
-	return AuthUser{ID: userID, Role: role}, nil
+	return AuthUser{ID: userID, SessionID: sessionID, TokenID: tokenID, ExpiresAt: expiresAt, Role: role, Permissions: parsePermissions(claims["perms"]), EmailVerified: emailVerified, MFASetupRequired: mfaSetupRequired}, nil
+}
+
+// RefreshClaims identify the session a refresh token belongs to
+type RefreshClaims struct {
+	UserID    string
+	SessionID string
+	TokenID   string
 }
 
 // Parse + validate refresh token
-func ParseRefreshToken(tokenStr string) (string, error) {
+func ParseRefreshToken(tokenStr string) (*RefreshClaims, error) {
 	token, err := jwt.Parse(tokenStr, func(t *jwt.Token) (interface{}, error) {
 		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
@@ -153,37 +267,158 @@ func ParseRefreshToken(tokenStr string) (string, error) {
 	})
 
 	if err != nil {
-		return "", apperror.ErrInvalidToken
+		return nil, apperror.ErrInvalidToken
 	}
 	if !token.Valid {
-		return "", apperror.ErrInvalidToken
+		return nil, apperror.ErrInvalidToken
 	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
-		return "", apperror.ErrInvalidClaims
+		return nil, apperror.ErrInvalidClaims
 	}
 
 	// Verify issuer and audience explicitly
 	if iss, ok := claims["iss"].(string); !ok || iss != issuer {
-		return "", apperror.ErrInvalidIssuer
+		return nil, apperror.ErrInvalidIssuer
 	}
 
 	if aud, ok := claims["aud"].(string); !ok || aud != audience {
-		return "", apperror.ErrInvalidAudience
+		return nil, apperror.ErrInvalidAudience
+	}
+
+	if tokenType, _ := claims["type"].(string); tokenType != "refresh" {
+		return nil, apperror.ErrInvalidToken
 	}
 
 	userID, _ := claims["sub"].(string)
+	sessionID, _ := claims["sid"].(string)
+	tokenID, _ := claims["jti"].(string)
+	if sessionID == "" || tokenID == "" {
+		return nil, apperror.ErrInvalidToken
+	}
 
 	// Check if token has been invalidated (if token service is available)
-	if TokenSvc != nil {
-		ctx := context.Background()
-		if !TokenSvc.IsUserValid(ctx, userID) {
-			return "", apperror.ErrTokenInvalidated
+	if err := checkTokenStatus(claims, userID); err != nil {
+		return nil, err
+	}
+
+	return &RefreshClaims{UserID: userID, SessionID: sessionID, TokenID: tokenID}, nil
+}
+
+// MFAClaims identify a login challenge and the user who passed the password step
+type MFAClaims struct {
+	UserID    string
+	TokenID   string
+	ExpiresAt time.Time
+}
+
+// ParseMFAToken validates a login challenge. A challenge that was used or failed too often is refused.
+func ParseMFAToken(tokenStr string) (*MFAClaims, error) {
+	token, err := jwt.Parse(tokenStr, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+		}
+		return refreshSecret, nil
+	})
+	if err != nil || !token.Valid {
+		return nil, apperror.ErrInvalidMFAToken
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, apperror.ErrInvalidMFAToken
+	}
+
+	if iss, ok := claims["iss"].(string); !ok || iss != issuer {
+		return nil, apperror.ErrInvalidMFAToken
+	}
+	if aud, ok := claims["aud"].(string); !ok || aud != audience {
+		return nil, apperror.ErrInvalidMFAToken
+	}
+	if tokenType, _ := claims["type"].(string); tokenType != "mfa" {
+		return nil, apperror.ErrInvalidMFAToken
+	}
+
+	userID, _ := claims["sub"].(string)
+	tokenID, _ := claims["jti"].(string)
+	expiresAt, err := claims.GetExpirationTime()
+	if tokenID == "" || err != nil || expiresAt == nil {
+		return nil, apperror.ErrInvalidMFAToken
+	}
+	if err := checkTokenStatus(claims, userID); err != nil {
+		return nil, err
+	}
+
+	return &MFAClaims{UserID: userID, TokenID: tokenID, ExpiresAt: expiresAt.Time}, nil
+}
+
+// ParseEmailVerificationToken validates a verification link and returns the user and address it confirms
+func ParseEmailVerificationToken(tokenStr string) (userID string, email string, err error) {
+	token, err := jwt.Parse(tokenStr, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+		}
+		return verifySecret, nil
+	})
+	if err != nil || !token.Valid {
+		return "", "", apperror.ErrInvalidVerificationToken
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return "", "", apperror.ErrInvalidVerificationToken
+	}
+
+	if iss, ok := claims["iss"].(string); !ok || iss != issuer {
+		return "", "", apperror.ErrInvalidVerificationToken
+	}
+	if aud, ok := claims["aud"].(string); !ok || aud != audience {
+		return "", "", apperror.ErrInvalidVerificationToken
+	}
+	if tokenType, _ := claims["type"].(string); tokenType != "email_verification" {
+		return "", "", apperror.ErrInvalidVerificationToken
+	}
+
+	userID, _ = claims["sub"].(string)
+	email, _ = claims["email"].(string)
+	if userID == "" || email == "" {
+		return "", "", apperror.ErrInvalidVerificationToken
+	}
+
+	return userID, email, nil
+}
+
+// parsePermissions reads the perms claim, which decodes as a list of strings
+func parsePermissions(claim interface{}) []model.Permission {
+	values, ok := claim.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	permissions := make([]model.Permission, 0, len(values))
+	for _, v := range values {
+		if p, ok := v.(string); ok {
+			permissions = append(permissions, model.Permission(p))
 		}
 	}
+	return permissions
+}
+
+// checkTokenStatus rejects denied tokens, tokens of revoked sessions, of suspended users and tokens revoked after they were issued
+func checkTokenStatus(claims jwt.MapClaims, userID string) error {
+	if TokenSvc == nil {
+		return nil
+	}
+
+	issuedAt, err := claims.GetIssuedAt()
+	if err != nil || issuedAt == nil {
+		return apperror.ErrInvalidClaims
+	}
 
-	return userID, nil
+	tokenID, _ := claims["jti"].(string)
+	sessionID, _ := claims["sid"].(string)
+	return TokenSvc.CheckToken(context.Background(), tokenID, sessionID, userID, issuedAt.Time)
 }
 
 func IsOwner(c *gin.Context, ownerID string) bool {
@@ -202,3 +437,22 @@ func IsAdmin(c *gin.Context) bool {
 	}
 	return authUser.(AuthUser).Role == "admin"
 }
+
+// HasPermission checks that the current user is an admin holding the given permission
+func HasPermission(c *gin.Context, permission model.Permission) bool {
+	authUser, exists := c.Get("authUser")
+	if !exists {
+		return false
+	}
+
+	user := authUser.(AuthUser)
+	if user.Role != "admin" {
+		return false
+	}
+	for _, p := range user.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,503 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/giakiet05/lkforum/internal/config"
+	"github.com/giakiet05/lkforum/internal/model"
+	"github.com/giakiet05/lkforum/internal/util"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// keyRefreshInterval is how often the key manager rotates and picks up keys created by other instances
+	keyRefreshInterval = time.Minute
+	// minKeyReload keeps tokens with an unknown key ID from making us query the store on every request
+	minKeyReload = 10 * time.Second
+	// keyExpiryGrace keeps a retired key published a little longer than its last token lives, for clock skew
+	keyExpiryGrace = time.Hour
+)
+
+// SigningAlgorithms are the algorithms access tokens may be signed with
+var SigningAlgorithms = []string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}
+
+// KeyStore persists signing keys so that every instance signs with the same key
+type KeyStore interface {
+	Create(ctx context.Context, key *model.SigningKey) (*model.SigningKey, error)
+	GetUnexpired(ctx context.Context) ([]model.SigningKey, error)
+	DeleteExpired(ctx context.Context) (int64, error)
+	UpdatePrivateKey(ctx context.Context, id primitive.ObjectID, privateKey string) error
+}
+
+// Global key manager instance
+var Keys *KeyManager
+
+// SetKeyManager sets the key manager used to sign and verify access tokens
+func SetKeyManager(manager *KeyManager) {
+	Keys = manager
+}
+
+// KeyManager signs access tokens with a rotating key pair and verifies them with any key that has
+// not expired yet. It is configured from the environment:
+//
+//	JWT_SIGNING_ALG=RS256 (or EdDSA)
+//	JWT_KEY_ROTATION_DAYS=30     how long each key signs
+//	JWT_KEY_PREPUBLISH_HOURS=24  how early the next key shows up in the JWKS before it signs
+//	JWT_KEY_ENCRYPTION_KEY       base64 of 32 random bytes, required; private keys are stored sealed with it
+//
+// Keys live in the store, so instances racing to rotate at the same moment may both add a key.
+// That is harmless: every instance signs with the latest one and all of them are published.
+type KeyManager struct {
+	store      KeyStore
+	algorithm  string
+	rotation   time.Duration
+	prepublish time.Duration
+	kek        cipher.AEAD // AES-GCM under JWT_KEY_ENCRYPTION_KEY, seals the private keys in the store
+
+	mu         sync.RWMutex
+	keys       []signingKey
+	lastReload time.Time
+}
+
+type signingKey struct {
+	kid         string
+	method      jwt.SigningMethod
+	private     crypto.Signer
+	activatesAt time.Time
+	retiresAt   time.Time
+}
+
+func NewKeyManager(store KeyStore) (*KeyManager, error) {
+	algorithm := config.GetEnvWithDefault("JWT_SIGNING_ALG", jwt.SigningMethodRS256.Alg())
+	if jwt.GetSigningMethod(algorithm) == nil || !isSigningAlgorithm(algorithm) {
+		return nil, fmt.Errorf("unsupported JWT_SIGNING_ALG %q, use RS256 or EdDSA", algorithm)
+	}
+
+	rotationDays := config.GetEnvIntWithDefault("JWT_KEY_ROTATION_DAYS", 30)
+	prepublishHours := config.GetEnvIntWithDefault("JWT_KEY_PREPUBLISH_HOURS", 24)
+	if rotationDays < 1 {
+		return nil, errors.New("JWT_KEY_ROTATION_DAYS must be at least 1")
+	}
+
+	kek, err := newKeyEncryptionCipher(config.GetEnvWithDefault("JWT_KEY_ENCRYPTION_KEY", ""))
+	if err != nil {
+		return nil, err
+	}
+
+	return &KeyManager{
+		store:      store,
+		algorithm:  algorithm,
+		rotation:   24 * time.Hour * time.Duration(rotationDays),
+		prepublish: time.Hour * time.Duration(prepublishHours),
+		kek:        kek,
+	}, nil
+}
+
+// Rotate makes sure a key is signing now and that its successor is published ahead of its turn,
+// drops expired keys, then reloads the key set
+func (m *KeyManager) Rotate(ctx context.Context) error {
+	if _, err := m.store.DeleteExpired(ctx); err != nil {
+		return err
+	}
+
+	records, err := m.store.GetUnexpired(ctx)
+	if err != nil {
+		return err
+	}
+	if err := m.sealLegacyKeys(ctx, records); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var current *model.SigningKey
+	hasNext := false
+	for i := range records {
+		record := &records[i]
+		if record.Algorithm != m.algorithm {
+			continue
+		}
+		if !record.ActivatesAt.After(now) && now.Before(record.RetiresAt) {
+			current = record
+		}
+		if record.ActivatesAt.After(now) {
+			hasNext = true
+		}
+	}
+
+	if current == nil {
+		current, err = m.createKey(ctx, now)
+		if err != nil {
+			return err
+		}
+		records = append(records, *current)
+	}
+	if !hasNext && current.RetiresAt.Sub(now) <= m.prepublish {
+		next, err := m.createKey(ctx, current.RetiresAt)
+		if err != nil {
+			return err
+		}
+		records = append(records, *next)
+	}
+
+	m.load(records)
+	return nil
+}
+
+// Start rotates the keys once, failing if no key can be set up, and then keeps rotating in the background
+func (m *KeyManager) Start() error {
+	ctx, cancel := util.NewDefaultDBContext()
+	err := m.Rotate(ctx)
+	cancel()
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(keyRefreshInterval)
+	go func() {
+		for range ticker.C {
+			ctx, cancel := util.NewDefaultDBContext()
+			if err := m.Rotate(ctx); err != nil {
+				log.Printf("⚠️ Signing key rotation failed: %v", err)
+			}
+			cancel()
+		}
+	}()
+	return nil
+}
+
+// Sign signs the claims with the current key and names the key in the kid header
+func (m *KeyManager) Sign(claims jwt.Claims) (string, error) {
+	key, err := m.currentKey(time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.kid
+	return token.SignedString(key.private)
+}
+
+// Keyfunc finds the public key a token was signed with. A key ID we do not know may belong to a key
+// another instance just created, so the key set is reloaded before giving up.
+func (m *KeyManager) Keyfunc(t *jwt.Token) (interface{}, error) {
+	kid, _ := t.Header["kid"].(string)
+	if kid == "" {
+		return nil, errors.New("token has no key ID")
+	}
+
+	key, ok := m.lookup(kid)
+	if !ok && m.claimReload() {
+		ctx, cancel := util.NewDefaultDBContext()
+		defer cancel()
+		if records, err := m.store.GetUnexpired(ctx); err == nil {
+			m.load(records)
+		}
+		key, ok = m.lookup(kid)
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	if t.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("token algorithm %s does not match key %q", t.Method.Alg(), kid)
+	}
+	return key.private.Public(), nil
+}
+
+// JSONWebKey is a public key as published in a JWKS (RFC 7517, RFC 8037 for Ed25519)
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// JWKS publishes every key that may have signed a token still in circulation, plus the next key
+// before it starts signing
+func (m *KeyManager) JWKS() JSONWebKeySet {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	set := JSONWebKeySet{Keys: make([]JSONWebKey, 0, len(m.keys))}
+	for _, key := range m.keys {
+		jwk := JSONWebKey{Kid: key.kid, Use: "sig", Alg: key.method.Alg()}
+		switch public := key.private.Public().(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+// currentKey picks the newest key whose signing window is open. If rotation is overdue, for example
+// because the store is unreachable, the newest activated key keeps signing rather than failing logins.
+func (m *KeyManager) currentKey(now time.Time) (*signingKey, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var current, fallback *signingKey
+	for i := range m.keys {
+		key := &m.keys[i]
+		if key.method.Alg() != m.algorithm || key.activatesAt.After(now) {
+			continue
+		}
+		fallback = key
+		if now.Before(key.retiresAt) {
+			current = key
+		}
+	}
+
+	if current != nil {
+		return current, nil
+	}
+	if fallback != nil {
+		return fallback, nil
+	}
+	return nil, errors.New("no signing key available")
+}
+
+func (m *KeyManager) lookup(kid string) (*signingKey, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for i := range m.keys {
+		if m.keys[i].kid == kid {
+			return &m.keys[i], true
+		}
+	}
+	return nil, false
+}
+
+// claimReload reports whether the caller may reload the key set now, at most once per minKeyReload
+func (m *KeyManager) claimReload() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if time.Since(m.lastReload) < minKeyReload {
+		return false
+	}
+	m.lastReload = time.Now()
+	return true
+}
+
+// load replaces the key set with the given records, which are sorted by activation
+func (m *KeyManager) load(records []model.SigningKey) {
+	keys := make([]signingKey, 0, len(records))
+	for _, record := range records {
+		key, err := m.parseSigningKey(record)
+		if err != nil {
+			log.Printf("⚠️ Skipping signing key %s: %v", record.KeyID, err)
+			continue
+		}
+		keys = append(keys, *key)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.keys = keys
+	m.lastReload = time.Now()
+}
+
+func (m *KeyManager) createKey(ctx context.Context, activatesAt time.Time) (*model.SigningKey, error) {
+	var private crypto.Signer
+	var err error
+	switch m.algorithm {
+	case jwt.SigningMethodEdDSA.Alg():
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		private, err = rsa.GenerateKey(rand.Reader, 2048)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+
+	// The key must outlive the last access token it signs
+	accessTokenTTL := time.Minute * time.Duration(config.GetEnvIntWithDefault("ACCESS_TOKEN_EXP_MIN", 15))
+	retiresAt := activatesAt.Add(m.rotation)
+
+	kid := uuid.New().String()
+	sealed, err := m.sealPrivateKey(kid, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	if err != nil {
+		return nil, err
+	}
+
+	record, err := m.store.Create(ctx, &model.SigningKey{
+		KeyID:       kid,
+		Algorithm:   m.algorithm,
+		PrivateKey:  sealed,
+		CreatedAt:   time.Now(),
+		ActivatesAt: activatesAt,
+		RetiresAt:   retiresAt,
+		ExpiresAt:   retiresAt.Add(accessTokenTTL + keyExpiryGrace),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("✅ Created %s signing key %s, signing from %s", record.Algorithm, record.KeyID, record.ActivatesAt.Format(time.RFC3339))
+	return record, nil
+}
+
+func (m *KeyManager) parseSigningKey(record model.SigningKey) (*signingKey, error) {
+	method := jwt.GetSigningMethod(record.Algorithm)
+	if method == nil || !isSigningAlgorithm(record.Algorithm) {
+		return nil, fmt.Errorf("unsupported algorithm %q", record.Algorithm)
+	}
+
+	pemBytes, err := m.openPrivateKey(record)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, errors.New("private key is not PEM encoded")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	var private crypto.Signer
+	switch key := parsed.(type) {
+	case *rsa.PrivateKey:
+		if method != jwt.SigningMethodRS256 {
+			return nil, errors.New("RSA key stored for a non-RSA algorithm")
+		}
+		private = key
+	case ed25519.PrivateKey:
+		if method != jwt.SigningMethodEdDSA {
+			return nil, errors.New("Ed25519 key stored for a non-EdDSA algorithm")
+		}
+		private = key
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+
+	return &signingKey{
+		kid:         record.KeyID,
+		method:      method,
+		private:     private,
+		activatesAt: record.ActivatesAt,
+		retiresAt:   record.RetiresAt,
+	}, nil
+}
+
+// newKeyEncryptionCipher builds the AES-GCM cipher that seals private keys from the base64 encoded key
+func newKeyEncryptionCipher(encoded string) (cipher.AEAD, error) {
+	if encoded == "" {
+		return nil, errors.New("JWT_KEY_ENCRYPTION_KEY is not set, generate one with: openssl rand -base64 32")
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(key) != 32 {
+		return nil, errors.New("JWT_KEY_ENCRYPTION_KEY must be the base64 encoding of 32 random bytes")
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// sealPrivateKey encrypts a PEM encoded private key for the store. The key ID is authenticated along
+// with it, so a sealed key copied onto another record does not open.
+func (m *KeyManager) sealPrivateKey(kid string, pemBytes []byte) (string, error) {
+	nonce := make([]byte, m.kek.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := m.kek.Seal(nonce, nonce, pemBytes, []byte(kid))
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// openPrivateKey decrypts the private key of a record back to PEM
+func (m *KeyManager) openPrivateKey(record model.SigningKey) ([]byte, error) {
+	if isPlaintextKey(record.PrivateKey) {
+		return nil, errors.New("private key is stored unencrypted")
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(record.PrivateKey)
+	if err != nil || len(sealed) < m.kek.NonceSize() {
+		return nil, errors.New("private key is not sealed")
+	}
+	nonce, ciphertext := sealed[:m.kek.NonceSize()], sealed[m.kek.NonceSize():]
+	pemBytes, err := m.kek.Open(nil, nonce, ciphertext, []byte(record.KeyID))
+	if err != nil {
+		return nil, errors.New("private key does not decrypt, check JWT_KEY_ENCRYPTION_KEY")
+	}
+	return pemBytes, nil
+}
+
+// sealLegacyKeys encrypts keys stored before private keys were sealed, in the store and in records
+func (m *KeyManager) sealLegacyKeys(ctx context.Context, records []model.SigningKey) error {
+	for i := range records {
+		record := &records[i]
+		if !isPlaintextKey(record.PrivateKey) {
+			continue
+		}
+
+		sealed, err := m.sealPrivateKey(record.KeyID, []byte(record.PrivateKey))
+		if err != nil {
+			return err
+		}
+		if err := m.store.UpdatePrivateKey(ctx, record.ID, sealed); err != nil {
+			return fmt.Errorf("sealing signing key %s: %w", record.KeyID, err)
+		}
+		record.PrivateKey = sealed
+		log.Printf("✅ Sealed unencrypted signing key %s", record.KeyID)
+	}
+	return nil
+}
+
+func isPlaintextKey(privateKey string) bool {
+	return strings.HasPrefix(strings.TrimSpace(privateKey), "-----BEGIN")
+}
+
+func isSigningAlgorithm(algorithm string) bool {
+	for _, supported := range SigningAlgorithms {
+		if algorithm == supported {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,37 @@
+package auth
+
+import (
+	"strings"
+
+	"github.com/giakiet05/lkforum/internal/apperror"
+)
+
+// PersonalAccessTokenPrefix starts every personal access token, so they are told apart from JWTs
+// without parsing and leaked ones are easy to search for
+const PersonalAccessTokenPrefix = "lkf_pat_"
+
+// PersonalAccessTokenVerifier looks up a personal access token and returns the user it acts for
+type PersonalAccessTokenVerifier interface {
+	VerifyPersonalAccessToken(token string) (AuthUser, error)
+}
+
+// Global personal access token verifier, tokens are stored in the database so the check lives in a service
+var PersonalAccessTokens PersonalAccessTokenVerifier
+
+// SetPersonalAccessTokenVerifier sets the verifier used by ParsePersonalAccessToken
+func SetPersonalAccessTokenVerifier(verifier PersonalAccessTokenVerifier) {
+	PersonalAccessTokens = verifier
+}
+
+// IsPersonalAccessToken reports whether the bearer token is a personal access token rather than a JWT
+func IsPersonalAccessToken(token string) bool {
+	return strings.HasPrefix(token, PersonalAccessTokenPrefix)
+}
+
+// ParsePersonalAccessToken validates a personal access token. The returned user carries the token's scopes.
+func ParsePersonalAccessToken(token string) (AuthUser, error) {
+	if PersonalAccessTokens == nil {
+		return AuthUser{}, apperror.ErrInvalidToken
+	}
+	return PersonalAccessTokens.VerifyPersonalAccessToken(token)
+}
@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+// maxStatusCacheEntries bounds the cache; past it expired entries are swept before adding more
+const maxStatusCacheEntries = 10000
+
+// statusCache remembers the outcome of recent token checks for a few seconds, so a client firing
+// many requests with the same token costs one Redis round-trip instead of one per request.
+// Revocations made by this instance clear it right away, other instances notice within the TTL.
+type statusCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]statusEntry
+}
+
+type statusEntry struct {
+	err       error
+	expiresAt time.Time
+}
+
+func newStatusCache(ttl time.Duration) *statusCache {
+	return &statusCache{
+		ttl:     ttl,
+		entries: make(map[string]statusEntry),
+	}
+}
+
+// get returns the cached outcome for the token ID, the bool is false on a miss
+func (c *statusCache) get(tokenID string) (error, bool) {
+	if c.ttl <= 0 || tokenID == "" {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[tokenID]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.err, true
+}
+
+func (c *statusCache) set(tokenID string, err error) {
+	if c.ttl <= 0 || tokenID == "" {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if len(c.entries) >= maxStatusCacheEntries {
+		for key, entry := range c.entries {
+			if now.After(entry.expiresAt) {
+				delete(c.entries, key)
+			}
+		}
+		if len(c.entries) >= maxStatusCacheEntries {
+			c.entries = make(map[string]statusEntry)
+		}
+	}
+	c.entries[tokenID] = statusEntry{err: err, expiresAt: now.Add(c.ttl)}
+}
+
+// clear drops every cached outcome, called whenever this instance revokes something
+func (c *statusCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]statusEntry)
+}
@@ -2,32 +2,207 @@ package auth
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"github.com/redis/go-redis/v9"
+	"log"
+	"strconv"
 	"time"
+
+	"github.com/giakiet05/lkforum/internal/apperror"
+	"github.com/giakiet05/lkforum/internal/config"
+	"github.com/redis/go-redis/v9"
 )
 
 // TokenService handles token operations including invalidation
 type TokenService struct {
 	redisClient *redis.Client
+	cache       *statusCache
+	failOpen    bool // honor tokens while Redis cannot be reached, instead of refusing them
 }
 
 // NewTokenService creates a new token service with Redis client
 func NewTokenService(redisClient *redis.Client) *TokenService {
+	cacheSeconds := config.GetEnvIntWithDefault("TOKEN_STATUS_CACHE_SEC", 5)
 	return &TokenService{
 		redisClient: redisClient,
+		cache:       newStatusCache(time.Second * time.Duration(cacheSeconds)),
+		failOpen:    config.GetEnvBoolWithDefault("TOKEN_STATUS_FAIL_OPEN", false),
 	}
 }
 
-// InvalidateAllUserTokens marks a user as deleted in Redis
-func (s *TokenService) InvalidateAllUserTokens(ctx context.Context, userID string) error {
-	key := fmt.Sprintf("invalidated:user:%s", userID)
-	return s.redisClient.Set(ctx, key, time.Now().Unix(), 90*24*time.Hour).Err()
+// CheckToken tells whether a token is still honored: it returns ErrTokenInvalidated when the token
+// was denied, its session revoked or every token of the user revoked after it was issued, and
+// ErrUserInactive while the user is suspended. All checks share one Redis round-trip and the
+// outcome is cached briefly by token ID. When Redis fails the token is refused with
+// ErrTokenStatusUnavailable, unless TOKEN_STATUS_FAIL_OPEN lets it through.
+func (s *TokenService) CheckToken(ctx context.Context, tokenID, sessionID, userID string, issuedAt time.Time) error {
+	if err, ok := s.cache.get(tokenID); ok {
+		return err
+	}
+
+	pipe := s.redisClient.Pipeline()
+	var denied, sessionRevoked *redis.IntCmd
+	if tokenID != "" {
+		denied = pipe.Exists(ctx, fmt.Sprintf("denied:jti:%s", tokenID))
+	}
+	if sessionID != "" {
+		sessionRevoked = pipe.Exists(ctx, fmt.Sprintf("revoked:session:%s", sessionID))
+	}
+	revokedBefore := pipe.Get(ctx, fmt.Sprintf("revoked_before:user:%s", userID))
+	suspended := pipe.Exists(ctx, fmt.Sprintf("suspended:user:%s", userID))
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		log.Printf("⚠️ Failed to check token status: %v\n", err)
+		if s.failOpen {
+			return nil
+		}
+		return apperror.ErrTokenStatusUnavailable
+	}
+
+	var status error
+	if denied != nil && denied.Val() > 0 {
+		status = apperror.ErrTokenInvalidated
+	} else if sessionRevoked != nil && sessionRevoked.Val() > 0 {
+		status = apperror.ErrTokenInvalidated
+	} else if before, err := strconv.ParseInt(revokedBefore.Val(), 10, 64); err == nil && issuedAt.Unix() < before {
+		status = apperror.ErrTokenInvalidated
+	} else if suspended.Val() > 0 {
+		status = apperror.ErrUserInactive
+	}
+
+	s.cache.set(tokenID, status)
+	return status
 }
 
-// IsUserValid checks if a user is still valid (not invalidated)
-func (s *TokenService) IsUserValid(ctx context.Context, userID string) bool {
-	key := fmt.Sprintf("invalidated:user:%s", userID)
+// DenyAccessToken rejects a single access token until it expires on its own
+func (s *TokenService) DenyAccessToken(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+
+	defer s.cache.clear()
+	key := fmt.Sprintf("denied:jti:%s", tokenID)
+	return s.redisClient.Set(ctx, key, time.Now().Unix(), ttl).Err()
+}
+
+// ConsumeToken denies a single-use token until it expires and reports whether this call was the
+// one that denied it, so a token raced by two requests is only honored once
+func (s *TokenService) ConsumeToken(ctx context.Context, tokenID string, expiresAt time.Time) (bool, error) {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return false, nil
+	}
+
+	defer s.cache.clear()
+	key := fmt.Sprintf("denied:jti:%s", tokenID)
+	return s.redisClient.SetNX(ctx, key, time.Now().Unix(), ttl).Result()
+}
+
+// CountMFAFailure counts a wrong code entered against a login challenge and returns the total so
+// far. The counter expires with the challenge.
+func (s *TokenService) CountMFAFailure(ctx context.Context, tokenID string, expiresAt time.Time) (int64, error) {
+	key := fmt.Sprintf("mfa_failures:jti:%s", tokenID)
+	pipe := s.redisClient.TxPipeline()
+	count := pipe.Incr(ctx, key)
+	pipe.ExpireAt(ctx, key, expiresAt)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return count.Val(), nil
+}
+
+// RevokeSession rejects the access tokens of a session right away. The session's refresh token is
+// refused by the sessions collection, so the key only has to outlive the access tokens.
+func (s *TokenService) RevokeSession(ctx context.Context, sessionID string) error {
+	defer s.cache.clear()
+	expMinutes := config.GetEnvIntWithDefault("ACCESS_TOKEN_EXP_MIN", 15)
+	key := fmt.Sprintf("revoked:session:%s", sessionID)
+	return s.redisClient.Set(ctx, key, time.Now().Unix(), time.Minute*time.Duration(expMinutes)).Err()
+}
+
+// SuspendUser blocks the user until the suspension ends and revokes every token issued so far.
+// A nil until suspends the user permanently; otherwise the key expires with the suspension.
+func (s *TokenService) SuspendUser(ctx context.Context, userID string, until *time.Time) error {
+	defer s.cache.clear()
+
+	var ttl time.Duration
+	if until != nil {
+		ttl = time.Until(*until)
+		if ttl <= 0 {
+			return nil
+		}
+	}
+
+	key := fmt.Sprintf("suspended:user:%s", userID)
+	if err := s.redisClient.Set(ctx, key, time.Now().Unix(), ttl).Err(); err != nil {
+		return err
+	}
+
+	return s.RevokeTokensIssuedBefore(ctx, userID, time.Now())
+}
+
+// UnsuspendUser lifts the suspension. Tokens revoked when the user was suspended stay revoked.
+func (s *TokenService) UnsuspendUser(ctx context.Context, userID string) error {
+	defer s.cache.clear()
+	key := fmt.Sprintf("suspended:user:%s", userID)
+	return s.redisClient.Del(ctx, key).Err()
+}
+
+// IsUserSuspended checks if the user is currently suspended
+func (s *TokenService) IsUserSuspended(ctx context.Context, userID string) bool {
+	key := fmt.Sprintf("suspended:user:%s", userID)
 	exists, err := s.redisClient.Exists(ctx, key).Result()
-	return exists == 0 && err == nil
+	return err == nil && exists > 0
+}
+
+// RevokeTokensIssuedBefore invalidates every token of the user issued before the given time.
+// The marker lives as long as a refresh token so nothing issued earlier can outlive it.
+func (s *TokenService) RevokeTokensIssuedBefore(ctx context.Context, userID string, before time.Time) error {
+	defer s.cache.clear()
+	expDays := config.GetEnvIntWithDefault("REFRESH_TOKEN_EXP_DAYS", 7)
+	key := fmt.Sprintf("revoked_before:user:%s", userID)
+	return s.redisClient.Set(ctx, key, before.Unix(), 24*time.Hour*time.Duration(expDays)).Err()
+}
+
+// IsTokenRevoked checks if a token issued at the given time has been revoked
+func (s *TokenService) IsTokenRevoked(ctx context.Context, userID string, issuedAt time.Time) bool {
+	key := fmt.Sprintf("revoked_before:user:%s", userID)
+	value, err := s.redisClient.Get(ctx, key).Result()
+	if err != nil {
+		return false
+	}
+
+	revokedBefore, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return false
+	}
+
+	return issuedAt.Unix() < revokedBefore
+}
+
+// StorePasswordResetToken saves the hash of a reset token for the user. Only the latest token
+// is honored: issuing a new one drops the previous hash.
+func (s *TokenService) StorePasswordResetToken(ctx context.Context, userID string, tokenHash string, ttl time.Duration) error {
+	userKey := fmt.Sprintf("password_reset:user:%s", userID)
+	if previous, err := s.redisClient.Get(ctx, userKey).Result(); err == nil {
+		s.redisClient.Del(ctx, fmt.Sprintf("password_reset:token:%s", previous))
+	}
+
+	pipe := s.redisClient.TxPipeline()
+	pipe.Set(ctx, fmt.Sprintf("password_reset:token:%s", tokenHash), userID, ttl)
+	pipe.Set(ctx, userKey, tokenHash, ttl)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// ConsumePasswordResetToken returns the user the token hash was issued to and deletes it in the same step,
+// so a token can only be used once. It returns redis.Nil for unknown or expired tokens.
+func (s *TokenService) ConsumePasswordResetToken(ctx context.Context, tokenHash string) (string, error) {
+	userID, err := s.redisClient.GetDel(ctx, fmt.Sprintf("password_reset:token:%s", tokenHash)).Result()
+	if err != nil {
+		return "", err
+	}
+
+	s.redisClient.Del(ctx, fmt.Sprintf("password_reset:user:%s", userID))
+	return userID, nil
 }
@@ -3,10 +3,16 @@ package bootstrap
 import (
 	"log"
 	"os"
+	"strings"
 
+	"github.com/giakiet05/lkforum/internal/auth"
 	"github.com/giakiet05/lkforum/internal/config"
 	"github.com/giakiet05/lkforum/internal/controller"
+	"github.com/giakiet05/lkforum/internal/mail"
+	"github.com/giakiet05/lkforum/internal/middleware"
+	"github.com/giakiet05/lkforum/internal/oidc"
 	"github.com/giakiet05/lkforum/internal/repo"
+	adminroute "github.com/giakiet05/lkforum/internal/route/admin"
 	route "github.com/giakiet05/lkforum/internal/route/user"
 	"github.com/giakiet05/lkforum/internal/service"
 	"github.com/gin-gonic/gin"
@@ -18,44 +24,163 @@ type Repos struct {
 	repo.UserRepo
 	repo.CommunityRepo
 	repo.MembershipRepo
+	repo.ConversationRepo
+	repo.NotificationRepo
+	repo.ReportRepo
+	repo.ModLogRepo
+	repo.CommunityBanRepo
+	repo.ModeratorInviteRepo
+	repo.AutoModRepo
+	repo.ContentFilterRepo
+	repo.ModmailRepo
+	repo.RemovalRepo
+	repo.AppealRepo
+	repo.ExternalIdentityRepo
+	repo.SessionRepo
+	repo.SecurityEventRepo
+	repo.SigningKeyRepo
+	repo.PersonalAccessTokenRepo
+	repo.MigrationRepo
 }
 
 type Services struct {
 	service.UserService
 	service.CommunityService
 	service.MembershipService
+	service.ConversationService
+	service.NotificationService
+	service.ReportService
+	service.ModLogService
+	service.CommunityBanService
+	service.ModeratorInviteService
+	service.AutoModService
+	service.ContentFilterService
+	service.PostingLimitService
+	service.ModmailService
+	service.RemovalService
+	service.AppealService
+	service.AdminService
+	service.TwoFactorService
+	service.OIDCService
+	service.SessionService
+	service.PersonalAccessTokenService
+	service.SecurityEventService
+	service.LoginAttemptService
 }
 
 type Controllers struct {
 	controller.UserController
 	controller.CommunityController
 	controller.MembershipController
+	controller.ConversationController
+	controller.NotificationController
+	controller.ReportController
+	controller.ModLogController
+	controller.CommunityBanController
+	controller.ModeratorInviteController
+	controller.AutoModController
+	controller.ContentFilterController
+	controller.ModmailController
+	controller.RemovalController
+	controller.AppealController
+	controller.AdminController
+	controller.TwoFactorController
+	controller.OIDCController
+	controller.SessionController
+	controller.PersonalAccessTokenController
+	controller.JWKSController
 }
 
 // initRepos initializes repositories with the given database
 func initRepos(db *mongo.Database) *Repos {
 	return &Repos{
-		UserRepo:       repo.NewUserRepo(db),
-		CommunityRepo:  repo.NewCommunityRepo(db),
-		MembershipRepo: repo.NewMembershipRepo(db),
+		UserRepo:                repo.NewUserRepo(db),
+		CommunityRepo:           repo.NewCommunityRepo(db),
+		MembershipRepo:          repo.NewMembershipRepo(db),
+		ConversationRepo:        repo.NewConversationRepo(db),
+		NotificationRepo:        repo.NewNotificationRepo(db),
+		ReportRepo:              repo.NewReportRepo(db),
+		ModLogRepo:              repo.NewModLogRepo(db),
+		CommunityBanRepo:        repo.NewCommunityBanRepo(db),
+		ModeratorInviteRepo:     repo.NewModeratorInviteRepo(db),
+		AutoModRepo:             repo.NewAutoModRepo(db),
+		ContentFilterRepo:       repo.NewContentFilterRepo(db),
+		ModmailRepo:             repo.NewModmailRepo(db),
+		RemovalRepo:             repo.NewRemovalRepo(db),
+		AppealRepo:              repo.NewAppealRepo(db),
+		ExternalIdentityRepo:    repo.NewExternalIdentityRepo(db),
+		SessionRepo:             repo.NewSessionRepo(db),
+		SecurityEventRepo:       repo.NewSecurityEventRepo(db),
+		SigningKeyRepo:          repo.NewSigningKeyRepo(db),
+		PersonalAccessTokenRepo: repo.NewPersonalAccessTokenRepo(db),
+		MigrationRepo:           repo.NewMigrationRepo(db),
 	}
 }
 
 // initServices Initialize services with the given repositories
 func initServices(repos *Repos, redisClient *redis.Client) *Services {
+	notificationService := service.NewNotificationService(repos.NotificationRepo)
+	modLogService := service.NewModLogService(repos.ModLogRepo, repos.CommunityRepo)
+	moderatorInviteService := service.NewModeratorInviteService(repos.ModeratorInviteRepo, repos.CommunityRepo, notificationService, modLogService)
+	communityBanService := service.NewCommunityBanService(repos.CommunityBanRepo, repos.CommunityRepo, repos.RemovalRepo, notificationService, modLogService)
+	reportService := service.NewReportService(repos.ReportRepo, repos.CommunityRepo, communityBanService, notificationService, modLogService)
+	removalService := service.NewRemovalService(repos.RemovalRepo, repos.CommunityRepo, reportService, notificationService, modLogService)
+	securityEventService := service.NewSecurityEventService(repos.SecurityEventRepo)
+	sessionService := service.NewSessionService(repos.SessionRepo, repos.UserRepo, securityEventService)
+	contentFilterService := service.NewContentFilterService(repos.ContentFilterRepo, repos.CommunityRepo, modLogService)
+	mailer := mail.NewMailerFromEnv()
+	loginAttemptService := service.NewLoginAttemptService(redisClient, mailer, securityEventService)
+	adminService := service.NewAdminService(repos.UserRepo, repos.CommunityRepo, repos.ReportRepo, modLogService, securityEventService, loginAttemptService, mailer)
+
 	return &Services{
-		UserService:       service.NewUserService(repos.UserRepo),
-		CommunityService:  service.NewCommunityService(repos.CommunityRepo),
-		MembershipService: service.NewMembershipService(repos.MembershipRepo, redisClient),
+		UserService:                service.NewUserService(repos.UserRepo, sessionService, loginAttemptService, mailer),
+		CommunityService:           service.NewCommunityService(repos.CommunityRepo, moderatorInviteService, notificationService, modLogService),
+		MembershipService:          service.NewMembershipService(repos.MembershipRepo, redisClient, communityBanService),
+		ConversationService:        service.NewConversationService(repos.ConversationRepo, repos.MembershipRepo),
+		NotificationService:        notificationService,
+		ReportService:              reportService,
+		ModLogService:              modLogService,
+		CommunityBanService:        communityBanService,
+		ModeratorInviteService:     moderatorInviteService,
+		AutoModService:             service.NewAutoModService(repos.AutoModRepo, repos.CommunityRepo, repos.UserRepo, repos.ReportRepo, notificationService, modLogService),
+		ContentFilterService:       contentFilterService,
+		PostingLimitService:        service.NewPostingLimitService(repos.CommunityRepo, repos.UserRepo, redisClient, communityBanService),
+		ModmailService:             service.NewModmailService(repos.ModmailRepo, repos.CommunityRepo, communityBanService, contentFilterService, notificationService),
+		RemovalService:             removalService,
+		AppealService:              service.NewAppealService(repos.AppealRepo, repos.ModLogRepo, repos.CommunityRepo, repos.UserRepo, communityBanService, removalService, adminService, loginAttemptService, notificationService, modLogService, mailer),
+		AdminService:               adminService,
+		TwoFactorService:           service.NewTwoFactorService(repos.UserRepo, sessionService, loginAttemptService),
+		OIDCService:                service.NewOIDCService(repos.ExternalIdentityRepo, repos.UserRepo, sessionService, redisClient, oidc.LoadProvidersFromEnv()),
+		SessionService:             sessionService,
+		PersonalAccessTokenService: service.NewPersonalAccessTokenService(repos.PersonalAccessTokenRepo, repos.UserRepo),
+		SecurityEventService:       securityEventService,
+		LoginAttemptService:        loginAttemptService,
 	}
 }
 
 // initControllers Initialize controllers with the given services
 func initControllers(services *Services) *Controllers {
 	return &Controllers{
-		UserController:       *controller.NewUserController(services.UserService),
-		CommunityController:  *controller.NewCommunityController(services.CommunityService),
-		MembershipController: *controller.NewMembershipController(services.MembershipService),
+		UserController:                *controller.NewUserController(services.UserService),
+		CommunityController:           *controller.NewCommunityController(services.CommunityService),
+		MembershipController:          *controller.NewMembershipController(services.MembershipService),
+		ConversationController:        *controller.NewConversationController(services.ConversationService),
+		NotificationController:        *controller.NewNotificationController(services.NotificationService),
+		ReportController:              *controller.NewReportController(services.ReportService),
+		ModLogController:              *controller.NewModLogController(services.ModLogService),
+		CommunityBanController:        *controller.NewCommunityBanController(services.CommunityBanService),
+		ModeratorInviteController:     *controller.NewModeratorInviteController(services.ModeratorInviteService),
+		AutoModController:             *controller.NewAutoModController(services.AutoModService),
+		ContentFilterController:       *controller.NewContentFilterController(services.ContentFilterService),
+		ModmailController:             *controller.NewModmailController(services.ModmailService),
+		RemovalController:             *controller.NewRemovalController(services.RemovalService),
+		AppealController:              *controller.NewAppealController(services.AppealService),
+		AdminController:               *controller.NewAdminController(services.AdminService),
+		TwoFactorController:           *controller.NewTwoFactorController(services.TwoFactorService),
+		OIDCController:                *controller.NewOIDCController(services.OIDCService),
+		SessionController:             *controller.NewSessionController(services.SessionService),
+		PersonalAccessTokenController: *controller.NewPersonalAccessTokenController(services.PersonalAccessTokenService),
+		JWKSController:                *controller.NewJWKSController(),
 	}
 }
 
@@ -66,17 +191,45 @@ func initRoutes(controllers *Controllers, r *gin.Engine) {
 		c.JSON(200, gin.H{"message": "pong"})
 	})
 
+	// Public keys for verifying access tokens
+	route.RegisterWellKnownRoutes(&r.RouterGroup, &controllers.JWKSController)
+
 	//Test API group
 	api := r.Group("/api")
+	api.Use(middleware.RateLimit(middleware.RateLimitDefault))
 	api.GET("/", func(c *gin.Context) {
 		c.JSON(200, gin.H{"message": "Welcome to LKForum API!"})
 	})
 
 	//Register more routes here
 	route.RegisterAuthRoutes(api, &controllers.UserController)
+	route.RegisterTwoFactorRoutes(api, &controllers.TwoFactorController)
+	route.RegisterOIDCRoutes(api, &controllers.OIDCController)
+	route.RegisterSessionRoutes(api, &controllers.SessionController)
+	route.RegisterPersonalAccessTokenRoutes(api, &controllers.PersonalAccessTokenController)
 	route.RegisterUserRoutes(api, &controllers.UserController)
 	route.RegisterCommunityRoutes(api, &controllers.CommunityController)
 	route.RegisterMembershipRoutes(api, &controllers.MembershipController)
+	route.RegisterConversationRoutes(api, &controllers.ConversationController)
+	route.RegisterNotificationRoutes(api, &controllers.NotificationController)
+	route.RegisterReportRoutes(api, &controllers.ReportController)
+	route.RegisterModLogRoutes(api, &controllers.ModLogController)
+	route.RegisterCommunityBanRoutes(api, &controllers.CommunityBanController)
+	route.RegisterModeratorInviteRoutes(api, &controllers.ModeratorInviteController)
+	route.RegisterAutoModRoutes(api, &controllers.AutoModController)
+	route.RegisterContentFilterRoutes(api, &controllers.ContentFilterController)
+	route.RegisterModmailRoutes(api, &controllers.ModmailController)
+	route.RegisterRemovalRoutes(api, &controllers.RemovalController)
+	route.RegisterAppealRoutes(api, &controllers.AppealController)
+
+	// Admin routes
+	admin := api.Group("/admin")
+	adminroute.RegisterAdminUserRoutes(admin, &controllers.AdminController)
+	adminroute.RegisterAdminCommunityRoutes(admin, &controllers.AdminController)
+	adminroute.RegisterAdminReportRoutes(admin, &controllers.AdminController)
+	adminroute.RegisterAdminAuditRoutes(admin, &controllers.AdminController)
+	adminroute.RegisterAdminPermissionRoutes(admin, &controllers.AdminController)
+	adminroute.RegisterAdminAppealRoutes(admin, &controllers.AppealController)
 }
 
 // Init initializes all application components
@@ -90,11 +243,20 @@ func Init() (*gin.Engine, error) {
 		log.Printf("Warning: Token invalidation service not available: %v\n", err)
 	}
 
+	// Rate limits are counted in Redis too
+	middleware.SetRateLimitClient(redisClient)
+
 	// Connect to MongoDB
 	client := config.NewMongoClient()
 	db := client.Database(os.Getenv("DB_NAME"))
 	router := gin.Default()
 
+	// Forwarded headers are only believed from TRUSTED_PROXIES, so ClientIP, which rate limits and
+	// login throttling count by, cannot be spoofed by the client
+	if err := router.SetTrustedProxies(trustedProxies()); err != nil {
+		return nil, err
+	}
+
 	// Register CORS middleware before any routes or other middleware
 	allowOrigin := os.Getenv("FRONTEND_URL")
 	if allowOrigin == "" {
@@ -114,7 +276,15 @@ func Init() (*gin.Engine, error) {
 
 	// Initialize other components
 	repos := initRepos(db)
+	RunMigrations(repos)
+
+	// Access tokens cannot be signed or verified without keys, so this one is fatal
+	if err := InitializeKeyManager(repos.SigningKeyRepo); err != nil {
+		return nil, err
+	}
+
 	services := initServices(repos, redisClient)
+	auth.SetPersonalAccessTokenVerifier(services.PersonalAccessTokenService)
 	controllers := initControllers(services)
 	initRoutes(controllers, router)
 
@@ -122,3 +292,14 @@ func Init() (*gin.Engine, error) {
 
 	return router, nil
 }
+
+// trustedProxies reads the comma-separated IPs or CIDRs of TRUSTED_PROXIES. None are trusted by default.
+func trustedProxies() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
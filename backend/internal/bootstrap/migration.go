@@ -0,0 +1,79 @@
+package bootstrap
+
+import (
+	"log"
+	"time"
+
+	"github.com/giakiet05/lkforum/internal/config"
+	"github.com/giakiet05/lkforum/internal/model"
+	"github.com/giakiet05/lkforum/internal/util"
+)
+
+// RunMigrations brings data written by older versions up to date. Steps that only touch documents
+// still in the old shape run on every start; the rest are recorded and run once.
+func RunMigrations(repos *Repos) {
+	assignLegacyAdminTemplate(repos)
+	runOnce(repos, "backfill_email_verification", backfillEmailVerification)
+}
+
+// runOnce runs the migration unless it was recorded before, and records it once it succeeds
+func runOnce(repos *Repos, name string, migrate func(repos *Repos) error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	applied, err := repos.MigrationRepo.IsApplied(ctx, name)
+	if err != nil {
+		log.Printf("⚠️ Failed to check migration %q: %v\n", name, err)
+		return
+	}
+	if applied {
+		return
+	}
+
+	if err := migrate(repos); err != nil {
+		log.Printf("⚠️ Migration %q failed, it will be retried on the next start: %v\n", name, err)
+		return
+	}
+	if err := repos.MigrationRepo.MarkApplied(ctx, name, time.Now()); err != nil {
+		log.Printf("⚠️ Failed to record migration %q: %v\n", name, err)
+		return
+	}
+	log.Printf("✅ Applied migration %q\n", name)
+}
+
+// backfillEmailVerification treats the addresses of accounts that existed before email verification
+// as verified, so those users keep access to what now requires a verified email
+func backfillEmailVerification(repos *Repos) error {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	count, err := repos.UserRepo.MarkAllEmailsVerified(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	log.Printf("Marked the email of %d existing users as verified\n", count)
+	return nil
+}
+
+// assignLegacyAdminTemplate gives admins promoted before permissions existed a template. Without
+// one they have no admin permissions at all. LEGACY_ADMIN_TEMPLATE picks the template and defaults
+// to super_admin, the full access those admins had before.
+func assignLegacyAdminTemplate(repos *Repos) {
+	template := config.GetEnvWithDefault("LEGACY_ADMIN_TEMPLATE", model.AdminTemplateSuperAdmin)
+	if _, ok := model.AdminRoleTemplates[template]; !ok {
+		log.Printf("⚠️ Unknown LEGACY_ADMIN_TEMPLATE %q, admins without permissions are left as they are\n", template)
+		return
+	}
+
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	count, err := repos.UserRepo.AssignTemplateToUnscopedAdmins(ctx, template)
+	if err != nil {
+		log.Printf("⚠️ Failed to assign a template to admins without permissions: %v\n", err)
+		return
+	}
+	if count > 0 {
+		log.Printf("✅ Assigned the %q template to %d admins without permissions\n", template, count)
+	}
+}
@@ -12,3 +12,18 @@ func InitializeTokenService(redisClient *redis.Client) error {
 
 	return nil
 }
+
+// InitializeKeyManager loads the access token signing keys, creating the first one if needed, and
+// starts rotating them
+func InitializeKeyManager(store auth.KeyStore) error {
+	keyManager, err := auth.NewKeyManager(store)
+	if err != nil {
+		return err
+	}
+	if err := keyManager.Start(); err != nil {
+		return err
+	}
+
+	auth.SetKeyManager(keyManager)
+	return nil
+}
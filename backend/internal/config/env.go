@@ -33,3 +33,13 @@ func GetEnvIntWithDefault(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+// Helper function to get boolean environment variable with default value
+func GetEnvBoolWithDefault(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
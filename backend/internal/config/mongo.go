@@ -7,6 +7,7 @@ import (
 	"os"
 	"time"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -17,19 +18,35 @@ var (
 )
 
 const (
-	UserColName            = "users"
-	PostColName            = "posts"
-	CommunityColName       = "communities"
-	CommentColName         = "comments"
-	ConversationColName    = "conversations"
-	MessageColName         = "messages"
-	VoteColName            = "votes"
-	NotificationColName    = "notifications"
-	ReportColName          = "reports"
-	MembershipColName      = "memberships"
-	LikedPostColName       = "liked_posts"
-	SavedPostColName       = "saved_posts"
-	UserPostHistoryColName = "user_post_history"
+	UserColName                = "users"
+	PostColName                = "posts"
+	CommunityColName           = "communities"
+	CommentColName             = "comments"
+	ConversationColName        = "conversations"
+	MessageColName             = "messages"
+	VoteColName                = "votes"
+	NotificationColName        = "notifications"
+	ReportColName              = "reports"
+	MembershipColName          = "memberships"
+	LikedPostColName           = "liked_posts"
+	SavedPostColName           = "saved_posts"
+	UserPostHistoryColName     = "user_post_history"
+	UserBlockColName           = "user_blocks"
+	ModLogColName              = "mod_logs"
+	CommunityBanColName        = "community_bans"
+	ModeratorInviteColName     = "moderator_invites"
+	AutoModColName             = "automod_configs"
+	ContentFilterColName       = "content_filters"
+	ModmailThreadColName       = "modmail_threads"
+	ModmailMessageColName      = "modmail_messages"
+	RemovalReasonColName       = "removal_reasons"
+	AppealColName              = "appeals"
+	ExternalIdentityColName    = "external_identities"
+	SessionColName             = "sessions"
+	SecurityEventColName       = "security_events"
+	SigningKeyColName          = "signing_keys"
+	PersonalAccessTokenColName = "personal_access_tokens"
+	MigrationColName           = "migrations"
 )
 
 // NewMongoClient creates and returns a new MongoDB client
@@ -61,16 +78,20 @@ func NewMongoClient() *mongo.Client {
 
 	db = client.Database(dbName)
 
-	// Verify required collections exist
-	if err := verifyCollections(ctx, db); err != nil {
-		log.Fatalf("Collection verification failed: %v", err)
+	// Create the collections and indexes a fresh or older database is missing
+	setupCtx, setupCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer setupCancel()
+	if err := ensureCollections(setupCtx, db); err != nil {
+		log.Fatalf("Collection setup failed: %v", err)
 	}
 
 	log.Printf("Using database: %s\n", dbName)
 	return client
 }
 
-func verifyCollections(ctx context.Context, db *mongo.Database) error {
+// ensureCollections creates the collections that do not exist yet, so a database set up before a
+// feature was added keeps starting, then makes sure their indexes are in place
+func ensureCollections(ctx context.Context, db *mongo.Database) error {
 	collections, err := db.ListCollectionNames(ctx, struct{}{})
 	if err != nil {
 		return fmt.Errorf("failed to list collections: %w", err)
@@ -90,6 +111,22 @@ func verifyCollections(ctx context.Context, db *mongo.Database) error {
 		LikedPostColName,
 		SavedPostColName,
 		UserPostHistoryColName,
+		UserBlockColName,
+		ModLogColName,
+		CommunityBanColName,
+		ModeratorInviteColName,
+		AutoModColName,
+		ContentFilterColName,
+		ModmailThreadColName,
+		ModmailMessageColName,
+		RemovalReasonColName,
+		AppealColName,
+		ExternalIdentityColName,
+		SessionColName,
+		SecurityEventColName,
+		SigningKeyColName,
+		PersonalAccessTokenColName,
+		MigrationColName,
 	}
 
 	existing := make(map[string]bool, len(collections))
@@ -98,11 +135,92 @@ func verifyCollections(ctx context.Context, db *mongo.Database) error {
 	}
 
 	for _, name := range required {
-		if !existing[name] {
-			return fmt.Errorf("required collection %q does not exist in database", name)
+		if existing[name] {
+			continue
 		}
+		if err := db.CreateCollection(ctx, name); err != nil {
+			return fmt.Errorf("failed to create collection %q: %w", name, err)
+		}
+		log.Printf("Created missing collection %q\n", name)
 	}
 
+	ensureIndexes(ctx, db)
+
 	log.Println("All required collections verified")
 	return nil
 }
+
+// collectionIndexes are the indexes the repositories rely on. Unique ones back the upserts that
+// must not create duplicates.
+var collectionIndexes = map[string][]mongo.IndexModel{
+	UserBlockColName: {
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "blocked_user_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+	},
+	ReportColName: {
+		// One unresolved report per target, new reports are merged into it. Needs MongoDB 6.0 for $in.
+		{
+			Keys: bson.D{{Key: "target_type", Value: 1}, {Key: "target_id", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("unresolved_target_unique").
+				SetPartialFilterExpression(bson.M{"status": bson.M{"$in": bson.A{"open", "in_review"}}}),
+		},
+	},
+	ModLogColName: {
+		{Keys: bson.D{{Key: "community_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "affected_user_id", Value: 1}}},
+	},
+	CommunityBanColName: {
+		{Keys: bson.D{{Key: "community_id", Value: 1}, {Key: "user_id", Value: 1}, {Key: "type", Value: 1}}, Options: options.Index().SetUnique(true)},
+	},
+	ModeratorInviteColName: {
+		{Keys: bson.D{{Key: "invitee_id", Value: 1}, {Key: "status", Value: 1}}},
+		{Keys: bson.D{{Key: "community_id", Value: 1}, {Key: "status", Value: 1}}},
+	},
+	AutoModColName: {
+		{Keys: bson.D{{Key: "community_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+	},
+	ContentFilterColName: {
+		{Keys: bson.D{{Key: "community_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+	},
+	ModmailThreadColName: {
+		{Keys: bson.D{{Key: "community_id", Value: 1}, {Key: "status", Value: 1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+	},
+	ModmailMessageColName: {
+		{Keys: bson.D{{Key: "thread_id", Value: 1}, {Key: "created_at", Value: 1}}},
+	},
+	RemovalReasonColName: {
+		{Keys: bson.D{{Key: "community_id", Value: 1}}},
+	},
+	AppealColName: {
+		{Keys: bson.D{{Key: "mod_log_id", Value: 1}}, Options: options.Index().SetUnique(true)}, // one appeal per action
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+		{Keys: bson.D{{Key: "community_id", Value: 1}, {Key: "status", Value: 1}}},
+	},
+	ExternalIdentityColName: {
+		{Keys: bson.D{{Key: "provider", Value: 1}, {Key: "subject", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+	},
+	SessionColName: {
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+	},
+	SecurityEventColName: {
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	},
+	SigningKeyColName: {
+		{Keys: bson.D{{Key: "kid", Value: 1}}, Options: options.Index().SetUnique(true)},
+	},
+	PersonalAccessTokenColName: {
+		{Keys: bson.D{{Key: "token_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+	},
+}
+
+// ensureIndexes creates missing indexes. Creating an existing index is a no-op; a failure, e.g. old
+// duplicates blocking a unique index, is logged and does not stop the server.
+func ensureIndexes(ctx context.Context, db *mongo.Database) {
+	for name, indexes := range collectionIndexes {
+		if _, err := db.Collection(name).Indexes().CreateMany(ctx, indexes); err != nil {
+			log.Printf("⚠️ Failed to create indexes on %q: %v\n", name, err)
+		}
+	}
+}
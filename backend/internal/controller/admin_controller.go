@@ -0,0 +1,352 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/giakiet05/lkforum/internal/apperror"
+	"github.com/giakiet05/lkforum/internal/auth"
+	"github.com/giakiet05/lkforum/internal/dto"
+	"github.com/giakiet05/lkforum/internal/model"
+	"github.com/giakiet05/lkforum/internal/repo"
+	"github.com/giakiet05/lkforum/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type AdminController struct {
+	adminService service.AdminService
+}
+
+func NewAdminController(adminService service.AdminService) *AdminController {
+	return &AdminController{adminService: adminService}
+}
+
+func (a *AdminController) SearchUsers(ctx *gin.Context) {
+	includeDeleted, _ := strconv.ParseBool(ctx.DefaultQuery("include_deleted", "false"))
+	filter := repo.UserSearchFilter{
+		Query:          ctx.Query("q"),
+		Role:           model.Role(ctx.Query("role")),
+		IncludeDeleted: includeDeleted,
+	}
+	page, pageSize := parsePagination(ctx)
+
+	response, err := a.adminService.SearchUsers(filter, page, pageSize)
+	if err != nil {
+		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (a *AdminController) ChangeUserRole(ctx *gin.Context) {
+	userID := ctx.Param("user_id")
+	if userID == "" {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.ErrBadRequest.Message})
+		return
+	}
+
+	var req dto.ChangeRoleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.Message(err)})
+		return
+	}
+
+	authUser, exists := ctx.Get("authUser")
+	if !exists {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrForbidden), dto.ErrorResponse{ErrorCode: apperror.ErrForbidden.Code, Message: apperror.ErrForbidden.Message})
+		return
+	}
+
+	user, err := a.adminService.ChangeUserRole(userID, &req, authUser.(auth.AuthUser).ID)
+	if err != nil {
+		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, user)
+}
+
+func (a *AdminController) UpdateAdminPermissions(ctx *gin.Context) {
+	userID := ctx.Param("user_id")
+	if userID == "" {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.ErrBadRequest.Message})
+		return
+	}
+
+	var req dto.UpdateAdminPermissionsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.Message(err)})
+		return
+	}
+
+	authUser, exists := ctx.Get("authUser")
+	if !exists {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrForbidden), dto.ErrorResponse{ErrorCode: apperror.ErrForbidden.Code, Message: apperror.ErrForbidden.Message})
+		return
+	}
+
+	user, err := a.adminService.UpdateAdminPermissions(userID, &req, authUser.(auth.AuthUser).ID)
+	if err != nil {
+		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, user)
+}
+
+func (a *AdminController) GetPermissionCatalog(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, a.adminService.GetPermissionCatalog())
+}
+
+func (a *AdminController) ResetUserPassword(ctx *gin.Context) {
+	userID := ctx.Param("user_id")
+	if userID == "" {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.ErrBadRequest.Message})
+		return
+	}
+
+	authUser, exists := ctx.Get("authUser")
+	if !exists {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrForbidden), dto.ErrorResponse{ErrorCode: apperror.ErrForbidden.Code, Message: apperror.ErrForbidden.Message})
+		return
+	}
+
+	response, err := a.adminService.ResetUserPassword(userID, authUser.(auth.AuthUser).ID)
+	if err != nil {
+		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (a *AdminController) RestoreUser(ctx *gin.Context) {
+	userID := ctx.Param("user_id")
+	if userID == "" {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.ErrBadRequest.Message})
+		return
+	}
+
+	authUser, exists := ctx.Get("authUser")
+	if !exists {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrForbidden), dto.ErrorResponse{ErrorCode: apperror.ErrForbidden.Code, Message: apperror.ErrForbidden.Message})
+		return
+	}
+
+	if err := a.adminService.RestoreUser(userID, authUser.(auth.AuthUser).ID); err != nil {
+		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.SuccessResponse{
+		ID:      userID,
+		Message: "Restore user successfully",
+	})
+}
+
+func (a *AdminController) SuspendUser(ctx *gin.Context) {
+	userID := ctx.Param("user_id")
+	if userID == "" {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.ErrBadRequest.Message})
+		return
+	}
+
+	var req dto.SuspendUserRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.Message(err)})
+		return
+	}
+
+	authUser, exists := ctx.Get("authUser")
+	if !exists {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrForbidden), dto.ErrorResponse{ErrorCode: apperror.ErrForbidden.Code, Message: apperror.ErrForbidden.Message})
+		return
+	}
+
+	suspension, err := a.adminService.SuspendUser(userID, &req, authUser.(auth.AuthUser).ID)
+	if err != nil {
+		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, suspension)
+}
+
+func (a *AdminController) UnsuspendUser(ctx *gin.Context) {
+	userID := ctx.Param("user_id")
+	if userID == "" {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.ErrBadRequest.Message})
+		return
+	}
+
+	authUser, exists := ctx.Get("authUser")
+	if !exists {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrForbidden), dto.ErrorResponse{ErrorCode: apperror.ErrForbidden.Code, Message: apperror.ErrForbidden.Message})
+		return
+	}
+
+	if err := a.adminService.UnsuspendUser(userID, authUser.(auth.AuthUser).ID); err != nil {
+		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.SuccessResponse{
+		ID:      userID,
+		Message: "Unsuspend user successfully",
+	})
+}
+
+func (a *AdminController) UnlockUser(ctx *gin.Context) {
+	userID := ctx.Param("user_id")
+	if userID == "" {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.ErrBadRequest.Message})
+		return
+	}
+
+	authUser, exists := ctx.Get("authUser")
+	if !exists {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrForbidden), dto.ErrorResponse{ErrorCode: apperror.ErrForbidden.Code, Message: apperror.ErrForbidden.Message})
+		return
+	}
+
+	if err := a.adminService.UnlockUser(userID, authUser.(auth.AuthUser).ID, clientInfo(ctx)); err != nil {
+		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.SuccessResponse{
+		ID:      userID,
+		Message: "Unlock user successfully",
+	})
+}
+
+func (a *AdminController) SetBot(ctx *gin.Context) {
+	userID := ctx.Param("user_id")
+	if userID == "" {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.ErrBadRequest.Message})
+		return
+	}
+
+	var req dto.SetBotRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.Message(err)})
+		return
+	}
+
+	authUser, exists := ctx.Get("authUser")
+	if !exists {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrForbidden), dto.ErrorResponse{ErrorCode: apperror.ErrForbidden.Code, Message: apperror.ErrForbidden.Message})
+		return
+	}
+
+	user, err := a.adminService.SetBot(userID, *req.IsBot, authUser.(auth.AuthUser).ID)
+	if err != nil {
+		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, user)
+}
+
+func (a *AdminController) BanCommunity(ctx *gin.Context) {
+	communityID := ctx.Param("community_id")
+	if communityID == "" {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.ErrBadRequest.Message})
+		return
+	}
+
+	var req dto.BanCommunityRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.Message(err)})
+		return
+	}
+
+	authUser, exists := ctx.Get("authUser")
+	if !exists {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrForbidden), dto.ErrorResponse{ErrorCode: apperror.ErrForbidden.Code, Message: apperror.ErrForbidden.Message})
+		return
+	}
+
+	community, err := a.adminService.BanCommunity(communityID, &req, authUser.(auth.AuthUser).ID)
+	if err != nil {
+		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, community)
+}
+
+func (a *AdminController) UnbanCommunity(ctx *gin.Context) {
+	communityID := ctx.Param("community_id")
+	if communityID == "" {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.ErrBadRequest.Message})
+		return
+	}
+
+	authUser, exists := ctx.Get("authUser")
+	if !exists {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrForbidden), dto.ErrorResponse{ErrorCode: apperror.ErrForbidden.Code, Message: apperror.ErrForbidden.Message})
+		return
+	}
+
+	community, err := a.adminService.UnbanCommunity(communityID, authUser.(auth.AuthUser).ID)
+	if err != nil {
+		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, community)
+}
+
+func (a *AdminController) GetReports(ctx *gin.Context) {
+	communityID := ctx.Query("community_id")
+	targetType := model.ReportTargetType(ctx.Query("target_type"))
+	status := model.ReportStatus(ctx.Query("status"))
+	page, pageSize := parsePagination(ctx)
+
+	response, err := a.adminService.GetReports(communityID, targetType, status, page, pageSize)
+	if err != nil {
+		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (a *AdminController) GetAuditLog(ctx *gin.Context) {
+	filter, ok := parseModLogFilter(ctx)
+	if !ok {
+		return
+	}
+	filter.CommunityID = ctx.Query("community_id")
+	page, pageSize := parsePagination(ctx)
+
+	response, err := a.adminService.GetAuditLog(filter, page, pageSize)
+	if err != nil {
+		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// GetSecurityEvents lists suspicious account activity such as reused refresh tokens
+func (a *AdminController) GetSecurityEvents(ctx *gin.Context) {
+	filter := repo.SecurityEventFilter{
+		UserID: ctx.Query("user_id"),
+		Type:   model.SecurityEventType(ctx.Query("type")),
+	}
+	if !parseTimeRange(ctx, &filter.From, &filter.To) {
+		return
+	}
+	page, pageSize := parsePagination(ctx)
+
+	response, err := a.adminService.GetSecurityEvents(filter, page, pageSize)
+	if err != nil {
+		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
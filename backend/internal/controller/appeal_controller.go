@@ -0,0 +1,173 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/giakiet05/lkforum/internal/apperror"
+	"github.com/giakiet05/lkforum/internal/auth"
+	"github.com/giakiet05/lkforum/internal/dto"
+	"github.com/giakiet05/lkforum/internal/model"
+	"github.com/giakiet05/lkforum/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type AppealController struct {
+	appealService service.AppealService
+}
+
+func NewAppealController(appealService service.AppealService) *AppealController {
+	return &AppealController{appealService: appealService}
+}
+
+func (a *AppealController) FileAppeal(ctx *gin.Context) {
+	var req dto.CreateAppealRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.Message(err)})
+		return
+	}
+
+	authUser, exists := ctx.Get("authUser")
+	if !exists {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrForbidden), dto.ErrorResponse{ErrorCode: apperror.ErrForbidden.Code, Message: apperror.ErrForbidden.Message})
+		return
+	}
+
+	appeal, err := a.appealService.FileAppeal(&req, authUser.(auth.AuthUser).ID)
+	if err != nil {
+		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, appeal)
+}
+
+// FileSuspensionAppeal needs no session, the request carries the suspended user's credentials
+func (a *AppealController) FileSuspensionAppeal(ctx *gin.Context) {
+	var req dto.CreateSuspensionAppealRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.Message(err)})
+		return
+	}
+
+	appeal, err := a.appealService.FileSuspensionAppeal(&req, clientInfo(ctx))
+	if err != nil {
+		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, appeal)
+}
+
+func (a *AppealController) GetMyAppeals(ctx *gin.Context) {
+	authUser, exists := ctx.Get("authUser")
+	if !exists {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrForbidden), dto.ErrorResponse{ErrorCode: apperror.ErrForbidden.Code, Message: apperror.ErrForbidden.Message})
+		return
+	}
+
+	page, pageSize := parsePagination(ctx)
+
+	response, err := a.appealService.GetMyAppeals(authUser.(auth.AuthUser).ID, page, pageSize)
+	if err != nil {
+		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (a *AppealController) GetAppealByID(ctx *gin.Context) {
+	appealID := ctx.Param("appeal_id")
+	if appealID == "" {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.ErrBadRequest.Message})
+		return
+	}
+
+	authUser, exists := ctx.Get("authUser")
+	if !exists {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrForbidden), dto.ErrorResponse{ErrorCode: apperror.ErrForbidden.Code, Message: apperror.ErrForbidden.Message})
+		return
+	}
+
+	appeal, err := a.appealService.GetAppealByID(appealID, authUser.(auth.AuthUser).ID, auth.HasPermission(ctx, model.PermissionReportsView))
+	if err != nil {
+		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, appeal)
+}
+
+func (a *AppealController) GetCommunityAppeals(ctx *gin.Context) {
+	communityID := ctx.Param("community_id")
+	if communityID == "" {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.ErrBadRequest.Message})
+		return
+	}
+
+	authUser, exists := ctx.Get("authUser")
+	if !exists {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrForbidden), dto.ErrorResponse{ErrorCode: apperror.ErrForbidden.Code, Message: apperror.ErrForbidden.Message})
+		return
+	}
+
+	status := model.AppealStatus(ctx.Query("status"))
+	page, pageSize := parsePagination(ctx)
+
+	response, err := a.appealService.GetCommunityAppeals(communityID, authUser.(auth.AuthUser).ID, status, page, pageSize)
+	if err != nil {
+		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// GetAppeals serves the admin review queue
+func (a *AppealController) GetAppeals(ctx *gin.Context) {
+	authUser, exists := ctx.Get("authUser")
+	if !exists {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrForbidden), dto.ErrorResponse{ErrorCode: apperror.ErrForbidden.Code, Message: apperror.ErrForbidden.Message})
+		return
+	}
+
+	communityID := ctx.Query("community_id")
+	status := model.AppealStatus(ctx.Query("status"))
+	page, pageSize := parsePagination(ctx)
+
+	response, err := a.appealService.GetAppeals(communityID, status, authUser.(auth.AuthUser).ID, page, pageSize)
+	if err != nil {
+		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (a *AppealController) ReviewAppeal(ctx *gin.Context) {
+	appealID := ctx.Param("appeal_id")
+	if appealID == "" {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.ErrBadRequest.Message})
+		return
+	}
+
+	var req dto.ReviewAppealRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.Message(err)})
+		return
+	}
+
+	authUser, exists := ctx.Get("authUser")
+	if !exists {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrForbidden), dto.ErrorResponse{ErrorCode: apperror.ErrForbidden.Code, Message: apperror.ErrForbidden.Message})
+		return
+	}
+
+	appeal, err := a.appealService.ReviewAppeal(appealID, &req, authUser.(auth.AuthUser).ID, auth.HasPermission(ctx, model.PermissionReportsResolve))
+	if err != nil {
+		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, appeal)
+}
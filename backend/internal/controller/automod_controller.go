@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/giakiet05/lkforum/internal/apperror"
+	"github.com/giakiet05/lkforum/internal/auth"
+	"github.com/giakiet05/lkforum/internal/dto"
+	"github.com/giakiet05/lkforum/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type AutoModController struct {
+	autoModService service.AutoModService
+}
+
+func NewAutoModController(autoModService service.AutoModService) *AutoModController {
+	return &AutoModController{autoModService: autoModService}
+}
+
+func (a *AutoModController) GetRules(ctx *gin.Context) {
+	communityID := ctx.Param("community_id")
+	if communityID == "" {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.ErrBadRequest.Message})
+		return
+	}
+
+	authUser, exists := ctx.Get("authUser")
+	if !exists {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrForbidden), dto.ErrorResponse{ErrorCode: apperror.ErrForbidden.Code, Message: apperror.ErrForbidden.Message})
+		return
+	}
+
+	autoModConfig, err := a.autoModService.GetRules(communityID, authUser.(auth.AuthUser).ID)
+	if err != nil {
+		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, autoModConfig)
+}
+
+// UpdateRules accepts the rules as JSON or, with a YAML content type, as YAML
+func (a *AutoModController) UpdateRules(ctx *gin.Context) {
+	communityID := ctx.Param("community_id")
+	if communityID == "" {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.ErrBadRequest.Message})
+		return
+	}
+
+	var req dto.UpdateAutoModRulesRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.Message(err)})
+		return
+	}
+
+	authUser, exists := ctx.Get("authUser")
+	if !exists {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrForbidden), dto.ErrorResponse{ErrorCode: apperror.ErrForbidden.Code, Message: apperror.ErrForbidden.Message})
+		return
+	}
+
+	autoModConfig, err := a.autoModService.UpdateRules(communityID, &req, authUser.(auth.AuthUser).ID)
+	if err != nil {
+		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, autoModConfig)
+}
+
+func (a *AutoModController) TestRules(ctx *gin.Context) {
+	communityID := ctx.Param("community_id")
+	if communityID == "" {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.ErrBadRequest.Message})
+		return
+	}
+
+	var req dto.TestAutoModRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.Message(err)})
+		return
+	}
+
+	authUser, exists := ctx.Get("authUser")
+	if !exists {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrForbidden), dto.ErrorResponse{ErrorCode: apperror.ErrForbidden.Code, Message: apperror.ErrForbidden.Message})
+		return
+	}
+
+	response, err := a.autoModService.TestRules(communityID, &req, authUser.(auth.AuthUser).ID)
+	if err != nil {
+		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
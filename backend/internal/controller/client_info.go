@@ -0,0 +1,14 @@
+package controller
+
+import (
+	"github.com/giakiet05/lkforum/internal/dto"
+	"github.com/gin-gonic/gin"
+)
+
+// clientInfo reads the device details stored with a session
+func clientInfo(ctx *gin.Context) dto.ClientInfo {
+	return dto.ClientInfo{
+		IP:        ctx.ClientIP(),
+		UserAgent: ctx.Request.UserAgent(),
+	}
+}
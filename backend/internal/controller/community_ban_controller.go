@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/giakiet05/lkforum/internal/apperror"
+	"github.com/giakiet05/lkforum/internal/auth"
+	"github.com/giakiet05/lkforum/internal/dto"
+	"github.com/giakiet05/lkforum/internal/model"
+	"github.com/giakiet05/lkforum/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type CommunityBanController struct {
+	communityBanService service.CommunityBanService
+}
+
+func NewCommunityBanController(communityBanService service.CommunityBanService) *CommunityBanController {
+	return &CommunityBanController{communityBanService: communityBanService}
+}
+
+func (c *CommunityBanController) BanUser(ctx *gin.Context) {
+	communityID := ctx.Param("community_id")
+	if communityID == "" {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.ErrBadRequest.Message})
+		return
+	}
+
+	var req dto.CommunityBanRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.Message(err)})
+		return
+	}
+
+	authUser, exists := ctx.Get("authUser")
+	if !exists {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrForbidden), dto.ErrorResponse{ErrorCode: apperror.ErrForbidden.Code, Message: apperror.ErrForbidden.Message})
+		return
+	}
+
+	ban, err := c.communityBanService.BanUser(communityID, &req, authUser.(auth.AuthUser).ID, auth.HasPermission(ctx, model.PermissionUsersBan))
+	if err != nil {
+		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, ban)
+}
+
+func (c *CommunityBanController) LiftBan(ctx *gin.Context) {
+	communityID := ctx.Param("community_id")
+	userID := ctx.Param("user_id")
+	if communityID == "" || userID == "" {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.ErrBadRequest.Message})
+		return
+	}
+
+	banType := model.CommunityBanType(ctx.DefaultQuery("type", string(model.CommunityBanTypeBan)))
+
+	authUser, exists := ctx.Get("authUser")
+	if !exists {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrForbidden), dto.ErrorResponse{ErrorCode: apperror.ErrForbidden.Code, Message: apperror.ErrForbidden.Message})
+		return
+	}
+
+	err := c.communityBanService.LiftBan(communityID, userID, banType, authUser.(auth.AuthUser).ID, auth.HasPermission(ctx, model.PermissionUsersBan))
+	if err != nil {
+		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.SuccessResponse{
+		ID:      userID,
+		Message: "Lift " + string(banType) + " successfully",
+	})
+}
+
+func (c *CommunityBanController) GetCommunityBans(ctx *gin.Context) {
+	communityID := ctx.Param("community_id")
+	if communityID == "" {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.ErrBadRequest.Message})
+		return
+	}
+
+	authUser, exists := ctx.Get("authUser")
+	if !exists {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrForbidden), dto.ErrorResponse{ErrorCode: apperror.ErrForbidden.Code, Message: apperror.ErrForbidden.Message})
+		return
+	}
+
+	banType := model.CommunityBanType(ctx.Query("type"))
+	page, pageSize := parsePagination(ctx)
+
+	response, err := c.communityBanService.GetCommunityBans(communityID, banType, authUser.(auth.AuthUser).ID, auth.HasPermission(ctx, model.PermissionUsersBan), page, pageSize)
+	if err != nil {
+		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/giakiet05/lkforum/internal/apperror"
 	"github.com/giakiet05/lkforum/internal/auth"
 	"github.com/giakiet05/lkforum/internal/dto"
+	"github.com/giakiet05/lkforum/internal/model"
 	"github.com/giakiet05/lkforum/internal/service"
 	"github.com/gin-gonic/gin"
 )
@@ -52,7 +53,7 @@ func (c *CommunityController) GetCommunityByID(ctx *gin.Context) {
 		return
 	}
 
-	community, err := c.communityService.GetCommunityByID(communityID)
+	community, err := c.communityService.GetCommunityByID(communityID, auth.HasPermission(ctx, model.PermissionCommunitiesBan))
 	if err != nil {
 		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
 		return
@@ -196,7 +197,7 @@ func (c *CommunityController) AddModerator(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, dto.SuccessResponse{
 		ID:      req.CommunityID,
-		Message: "Add moderator successfully",
+		Message: "Invite moderator successfully",
 	})
 }
 
@@ -225,6 +226,95 @@ func (c *CommunityController) RemoveModerator(ctx *gin.Context) {
 	})
 }
 
+func (c *CommunityController) UpdateModeratorPermissions(ctx *gin.Context) {
+	var req dto.UpdateModeratorPermissionsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.Message(err)})
+		return
+	}
+
+	authUser, exists := ctx.Get("authUser")
+	if !exists {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrForbidden), dto.ErrorResponse{ErrorCode: apperror.ErrForbidden.Code, Message: apperror.ErrForbidden.Message})
+		return
+	}
+
+	err := c.communityService.UpdateModeratorPermissions(&req, authUser.(auth.AuthUser).ID)
+	if err != nil {
+		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.SuccessResponse{
+		ID:      req.CommunityID,
+		Message: "Update moderator permissions successfully",
+	})
+}
+
+func (c *CommunityController) OfferOwnership(ctx *gin.Context) {
+	communityID := ctx.Param("community_id")
+	if communityID == "" {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.ErrBadRequest.Message})
+		return
+	}
+
+	var req dto.TransferOwnershipRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.Message(err)})
+		return
+	}
+
+	authUser, exists := ctx.Get("authUser")
+	if !exists {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrForbidden), dto.ErrorResponse{ErrorCode: apperror.ErrForbidden.Code, Message: apperror.ErrForbidden.Message})
+		return
+	}
+
+	err := c.communityService.OfferOwnership(communityID, req.UserID, authUser.(auth.AuthUser).ID)
+	if err != nil {
+		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.SuccessResponse{
+		ID:      communityID,
+		Message: "Offer ownership successfully",
+	})
+}
+
+func (c *CommunityController) AcceptOwnership(ctx *gin.Context) {
+	c.handleOwnershipAction(ctx, c.communityService.AcceptOwnership, "Accept ownership successfully")
+}
+
+func (c *CommunityController) CancelOwnershipTransfer(ctx *gin.Context) {
+	c.handleOwnershipAction(ctx, c.communityService.CancelOwnershipTransfer, "Cancel ownership transfer successfully")
+}
+
+// handleOwnershipAction runs an ownership transfer step on the community in the path on behalf of the current user
+func (c *CommunityController) handleOwnershipAction(ctx *gin.Context, action func(communityID string, userID string) error, successMessage string) {
+	communityID := ctx.Param("community_id")
+	if communityID == "" {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.ErrBadRequest.Message})
+		return
+	}
+
+	authUser, exists := ctx.Get("authUser")
+	if !exists {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrForbidden), dto.ErrorResponse{ErrorCode: apperror.ErrForbidden.Code, Message: apperror.ErrForbidden.Message})
+		return
+	}
+
+	if err := action(communityID, authUser.(auth.AuthUser).ID); err != nil {
+		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.SuccessResponse{
+		ID:      communityID,
+		Message: successMessage,
+	})
+}
+
 func (c *CommunityController) DeleteCommunityByID(ctx *gin.Context) {
 	communityID := ctx.Param("community_id")
 	if communityID == "" {
@@ -0,0 +1,167 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/giakiet05/lkforum/internal/apperror"
+	"github.com/giakiet05/lkforum/internal/auth"
+	"github.com/giakiet05/lkforum/internal/dto"
+	"github.com/giakiet05/lkforum/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type ConversationController struct {
+	conversationService service.ConversationService
+}
+
+func NewConversationController(conversationService service.ConversationService) *ConversationController {
+	return &ConversationController{conversationService: conversationService}
+}
+
+func (c *ConversationController) SendDirectMessage(ctx *gin.Context) {
+	var req dto.SendDirectMessageRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.Message(err)})
+		return
+	}
+
+	authUser, exists := ctx.Get("authUser")
+	if !exists {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrForbidden), dto.ErrorResponse{ErrorCode: apperror.ErrForbidden.Code, Message: apperror.ErrForbidden.Message})
+		return
+	}
+
+	message, err := c.conversationService.SendDirectMessage(&req, authUser.(auth.AuthUser).ID)
+	if err != nil {
+		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, message)
+}
+
+func (c *ConversationController) GetInbox(ctx *gin.Context) {
+	authUser, exists := ctx.Get("authUser")
+	if !exists {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrForbidden), dto.ErrorResponse{ErrorCode: apperror.ErrForbidden.Code, Message: apperror.ErrForbidden.Message})
+		return
+	}
+
+	page, pageSize := parsePagination(ctx)
+
+	response, err := c.conversationService.GetInbox(authUser.(auth.AuthUser).ID, page, pageSize)
+	if err != nil {
+		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *ConversationController) GetMessageRequests(ctx *gin.Context) {
+	authUser, exists := ctx.Get("authUser")
+	if !exists {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrForbidden), dto.ErrorResponse{ErrorCode: apperror.ErrForbidden.Code, Message: apperror.ErrForbidden.Message})
+		return
+	}
+
+	page, pageSize := parsePagination(ctx)
+
+	response, err := c.conversationService.GetMessageRequests(authUser.(auth.AuthUser).ID, page, pageSize)
+	if err != nil {
+		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *ConversationController) GetMessages(ctx *gin.Context) {
+	conversationID := ctx.Param("conversation_id")
+	if conversationID == "" {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.ErrBadRequest.Message})
+		return
+	}
+
+	authUser, exists := ctx.Get("authUser")
+	if !exists {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrForbidden), dto.ErrorResponse{ErrorCode: apperror.ErrForbidden.Code, Message: apperror.ErrForbidden.Message})
+		return
+	}
+
+	page, pageSize := parsePagination(ctx)
+
+	response, err := c.conversationService.GetMessages(conversationID, authUser.(auth.AuthUser).ID, page, pageSize)
+	if err != nil {
+		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *ConversationController) MarkAsRead(ctx *gin.Context) {
+	c.handleConversationAction(ctx, c.conversationService.MarkAsRead, "Mark conversation as read successfully")
+}
+
+func (c *ConversationController) AcceptRequest(ctx *gin.Context) {
+	c.handleConversationAction(ctx, c.conversationService.AcceptRequest, "Accept message request successfully")
+}
+
+func (c *ConversationController) DeclineRequest(ctx *gin.Context) {
+	c.handleConversationAction(ctx, c.conversationService.DeclineRequest, "Decline message request successfully")
+}
+
+func (c *ConversationController) BlockRequest(ctx *gin.Context) {
+	c.handleConversationAction(ctx, c.conversationService.BlockRequest, "Block sender successfully")
+}
+
+func (c *ConversationController) UnblockUser(ctx *gin.Context) {
+	blockedUserID := ctx.Param("user_id")
+	if blockedUserID == "" {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.ErrBadRequest.Message})
+		return
+	}
+
+	authUser, exists := ctx.Get("authUser")
+	if !exists {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrForbidden), dto.ErrorResponse{ErrorCode: apperror.ErrForbidden.Code, Message: apperror.ErrForbidden.Message})
+		return
+	}
+
+	err := c.conversationService.UnblockUser(blockedUserID, authUser.(auth.AuthUser).ID)
+	if err != nil {
+		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.SuccessResponse{
+		ID:      blockedUserID,
+		Message: "Unblock user successfully",
+	})
+}
+
+// handleConversationAction runs an action on the conversation in the path on behalf of the current user
+func (c *ConversationController) handleConversationAction(ctx *gin.Context, action func(conversationID string, userID string) error, successMessage string) {
+	conversationID := ctx.Param("conversation_id")
+	if conversationID == "" {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.ErrBadRequest.Message})
+		return
+	}
+
+	authUser, exists := ctx.Get("authUser")
+	if !exists {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrForbidden), dto.ErrorResponse{ErrorCode: apperror.ErrForbidden.Code, Message: apperror.ErrForbidden.Message})
+		return
+	}
+
+	if err := action(conversationID, authUser.(auth.AuthUser).ID); err != nil {
+		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.SuccessResponse{
+		ID:      conversationID,
+		Message: successMessage,
+	})
+}
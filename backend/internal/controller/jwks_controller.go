@@ -0,0 +1,29 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/giakiet05/lkforum/internal/apperror"
+	"github.com/giakiet05/lkforum/internal/auth"
+	"github.com/giakiet05/lkforum/internal/dto"
+	"github.com/gin-gonic/gin"
+)
+
+// JWKSController publishes the public keys access tokens are signed with, so other services can
+// verify them without sharing a secret
+type JWKSController struct{}
+
+func NewJWKSController() *JWKSController {
+	return &JWKSController{}
+}
+
+func (j *JWKSController) GetJWKS(ctx *gin.Context) {
+	if auth.Keys == nil {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrInternal), dto.ErrorResponse{ErrorCode: apperror.ErrInternal.Code, Message: apperror.ErrInternal.Message})
+		return
+	}
+
+	// The next key is published a day before it signs, so caching for a few minutes is safe
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, auth.Keys.JWKS())
+}
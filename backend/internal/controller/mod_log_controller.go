@@ -0,0 +1,118 @@
+package controller
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/giakiet05/lkforum/internal/apperror"
+	"github.com/giakiet05/lkforum/internal/auth"
+	"github.com/giakiet05/lkforum/internal/dto"
+	"github.com/giakiet05/lkforum/internal/model"
+	"github.com/giakiet05/lkforum/internal/repo"
+	"github.com/giakiet05/lkforum/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type ModLogController struct {
+	modLogService service.ModLogService
+}
+
+func NewModLogController(modLogService service.ModLogService) *ModLogController {
+	return &ModLogController{modLogService: modLogService}
+}
+
+func (m *ModLogController) GetCommunityModLogs(ctx *gin.Context) {
+	communityID := ctx.Param("community_id")
+	if communityID == "" {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.ErrBadRequest.Message})
+		return
+	}
+
+	authUser, exists := ctx.Get("authUser")
+	if !exists {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrForbidden), dto.ErrorResponse{ErrorCode: apperror.ErrForbidden.Code, Message: apperror.ErrForbidden.Message})
+		return
+	}
+
+	filter, ok := parseModLogFilter(ctx)
+	if !ok {
+		return
+	}
+	page, pageSize := parsePagination(ctx)
+
+	response, err := m.modLogService.GetCommunityModLogs(communityID, authUser.(auth.AuthUser).ID, filter, page, pageSize)
+	if err != nil {
+		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (m *ModLogController) GetPublicCommunityModLogs(ctx *gin.Context) {
+	communityID := ctx.Param("community_id")
+	if communityID == "" {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.ErrBadRequest.Message})
+		return
+	}
+
+	filter, ok := parseModLogFilter(ctx)
+	if !ok {
+		return
+	}
+	page, pageSize := parsePagination(ctx)
+
+	response, err := m.modLogService.GetPublicCommunityModLogs(communityID, filter, page, pageSize)
+	if err != nil {
+		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (m *ModLogController) GetModLogs(ctx *gin.Context) {
+	filter, ok := parseModLogFilter(ctx)
+	if !ok {
+		return
+	}
+	filter.CommunityID = ctx.Query("community_id")
+	page, pageSize := parsePagination(ctx)
+
+	response, err := m.modLogService.GetModLogs(filter, page, pageSize)
+	if err != nil {
+		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// parseModLogFilter reads the mod log filters from the query string and writes a 400 response if they are malformed
+func parseModLogFilter(ctx *gin.Context) (repo.ModLogFilter, bool) {
+	filter := repo.ModLogFilter{
+		ActorID:    ctx.Query("actor_id"),
+		Action:     model.ModAction(ctx.Query("action")),
+		TargetType: model.ModTargetType(ctx.Query("target_type")),
+		TargetID:   ctx.Query("target_id"),
+	}
+
+	return filter, parseTimeRange(ctx, &filter.From, &filter.To)
+}
+
+// parseTimeRange reads the optional RFC 3339 "from" and "to" query parameters, responding with 400 if either is malformed
+func parseTimeRange(ctx *gin.Context, from *time.Time, to *time.Time) bool {
+	for key, dst := range map[string]*time.Time{"from": from, "to": to} {
+		value := ctx.Query(key)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.ErrBadRequest.Message})
+			return false
+		}
+		*dst = t
+	}
+	return true
+}
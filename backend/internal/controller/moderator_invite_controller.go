@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/giakiet05/lkforum/internal/apperror"
+	"github.com/giakiet05/lkforum/internal/auth"
+	"github.com/giakiet05/lkforum/internal/dto"
+	"github.com/giakiet05/lkforum/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type ModeratorInviteController struct {
+	moderatorInviteService service.ModeratorInviteService
+}
+
+func NewModeratorInviteController(moderatorInviteService service.ModeratorInviteService) *ModeratorInviteController {
+	return &ModeratorInviteController{moderatorInviteService: moderatorInviteService}
+}
+
+func (m *ModeratorInviteController) GetMyInvites(ctx *gin.Context) {
+	authUser, exists := ctx.Get("authUser")
+	if !exists {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrForbidden), dto.ErrorResponse{ErrorCode: apperror.ErrForbidden.Code, Message: apperror.ErrForbidden.Message})
+		return
+	}
+
+	page, pageSize := parsePagination(ctx)
+
+	response, err := m.moderatorInviteService.GetMyInvites(authUser.(auth.AuthUser).ID, page, pageSize)
+	if err != nil {
+		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (m *ModeratorInviteController) GetCommunityInvites(ctx *gin.Context) {
+	communityID := ctx.Param("community_id")
+	if communityID == "" {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.ErrBadRequest.Message})
+		return
+	}
+
+	authUser, exists := ctx.Get("authUser")
+	if !exists {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrForbidden), dto.ErrorResponse{ErrorCode: apperror.ErrForbidden.Code, Message: apperror.ErrForbidden.Message})
+		return
+	}
+
+	page, pageSize := parsePagination(ctx)
+
+	response, err := m.moderatorInviteService.GetCommunityInvites(communityID, authUser.(auth.AuthUser).ID, page, pageSize)
+	if err != nil {
+		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (m *ModeratorInviteController) AcceptInvite(ctx *gin.Context) {
+	m.handleInviteAction(ctx, m.moderatorInviteService.AcceptInvite, "Accept moderator invitation successfully")
+}
+
+func (m *ModeratorInviteController) DeclineInvite(ctx *gin.Context) {
+	m.handleInviteAction(ctx, m.moderatorInviteService.DeclineInvite, "Decline moderator invitation successfully")
+}
+
+func (m *ModeratorInviteController) CancelInvite(ctx *gin.Context) {
+	m.handleInviteAction(ctx, m.moderatorInviteService.CancelInvite, "Cancel moderator invitation successfully")
+}
+
+// handleInviteAction runs an action on the invitation in the path on behalf of the current user
+func (m *ModeratorInviteController) handleInviteAction(ctx *gin.Context, action func(inviteID string, userID string) error, successMessage string) {
+	inviteID := ctx.Param("invite_id")
+	if inviteID == "" {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.ErrBadRequest.Message})
+		return
+	}
+
+	authUser, exists := ctx.Get("authUser")
+	if !exists {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrForbidden), dto.ErrorResponse{ErrorCode: apperror.ErrForbidden.Code, Message: apperror.ErrForbidden.Message})
+		return
+	}
+
+	if err := action(inviteID, authUser.(auth.AuthUser).ID); err != nil {
+		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.SuccessResponse{
+		ID:      inviteID,
+		Message: successMessage,
+	})
+}
@@ -0,0 +1,202 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/giakiet05/lkforum/internal/apperror"
+	"github.com/giakiet05/lkforum/internal/auth"
+	"github.com/giakiet05/lkforum/internal/dto"
+	"github.com/giakiet05/lkforum/internal/model"
+	"github.com/giakiet05/lkforum/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type ModmailController struct {
+	modmailService service.ModmailService
+}
+
+func NewModmailController(modmailService service.ModmailService) *ModmailController {
+	return &ModmailController{modmailService: modmailService}
+}
+
+func (m *ModmailController) CreateThread(ctx *gin.Context) {
+	var req dto.CreateModmailThreadRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.Message(err)})
+		return
+	}
+
+	authUser, exists := ctx.Get("authUser")
+	if !exists {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrForbidden), dto.ErrorResponse{ErrorCode: apperror.ErrForbidden.Code, Message: apperror.ErrForbidden.Message})
+		return
+	}
+
+	thread, err := m.modmailService.CreateThread(&req, authUser.(auth.AuthUser).ID)
+	if err != nil {
+		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, thread)
+}
+
+func (m *ModmailController) GetMyThreads(ctx *gin.Context) {
+	authUser, exists := ctx.Get("authUser")
+	if !exists {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrForbidden), dto.ErrorResponse{ErrorCode: apperror.ErrForbidden.Code, Message: apperror.ErrForbidden.Message})
+		return
+	}
+
+	page, pageSize := parsePagination(ctx)
+
+	response, err := m.modmailService.GetMyThreads(authUser.(auth.AuthUser).ID, page, pageSize)
+	if err != nil {
+		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// GetCommunityThreads serves the team inbox, filtered by ?status=open|archived and ?highlighted=true
+func (m *ModmailController) GetCommunityThreads(ctx *gin.Context) {
+	communityID := ctx.Param("community_id")
+	if communityID == "" {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.ErrBadRequest.Message})
+		return
+	}
+
+	authUser, exists := ctx.Get("authUser")
+	if !exists {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrForbidden), dto.ErrorResponse{ErrorCode: apperror.ErrForbidden.Code, Message: apperror.ErrForbidden.Message})
+		return
+	}
+
+	page, pageSize := parsePagination(ctx)
+	status := model.ModmailThreadStatus(ctx.Query("status"))
+	highlightedOnly := ctx.Query("highlighted") == "true"
+
+	response, err := m.modmailService.GetCommunityThreads(communityID, authUser.(auth.AuthUser).ID, status, highlightedOnly, page, pageSize)
+	if err != nil {
+		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (m *ModmailController) GetThread(ctx *gin.Context) {
+	threadID := ctx.Param("thread_id")
+	if threadID == "" {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.ErrBadRequest.Message})
+		return
+	}
+
+	authUser, exists := ctx.Get("authUser")
+	if !exists {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrForbidden), dto.ErrorResponse{ErrorCode: apperror.ErrForbidden.Code, Message: apperror.ErrForbidden.Message})
+		return
+	}
+
+	thread, err := m.modmailService.GetThread(threadID, authUser.(auth.AuthUser).ID)
+	if err != nil {
+		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, thread)
+}
+
+func (m *ModmailController) GetMessages(ctx *gin.Context) {
+	threadID := ctx.Param("thread_id")
+	if threadID == "" {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.ErrBadRequest.Message})
+		return
+	}
+
+	authUser, exists := ctx.Get("authUser")
+	if !exists {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrForbidden), dto.ErrorResponse{ErrorCode: apperror.ErrForbidden.Code, Message: apperror.ErrForbidden.Message})
+		return
+	}
+
+	page, pageSize := parsePagination(ctx)
+
+	response, err := m.modmailService.GetMessages(threadID, authUser.(auth.AuthUser).ID, page, pageSize)
+	if err != nil {
+		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (m *ModmailController) Reply(ctx *gin.Context) {
+	threadID := ctx.Param("thread_id")
+	if threadID == "" {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.ErrBadRequest.Message})
+		return
+	}
+
+	var req dto.ModmailReplyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.Message(err)})
+		return
+	}
+
+	authUser, exists := ctx.Get("authUser")
+	if !exists {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrForbidden), dto.ErrorResponse{ErrorCode: apperror.ErrForbidden.Code, Message: apperror.ErrForbidden.Message})
+		return
+	}
+
+	message, err := m.modmailService.Reply(threadID, &req, authUser.(auth.AuthUser).ID)
+	if err != nil {
+		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, message)
+}
+
+func (m *ModmailController) ArchiveThread(ctx *gin.Context) {
+	m.handleThreadAction(ctx, m.modmailService.ArchiveThread, "Archive modmail thread successfully")
+}
+
+func (m *ModmailController) UnarchiveThread(ctx *gin.Context) {
+	m.handleThreadAction(ctx, m.modmailService.UnarchiveThread, "Unarchive modmail thread successfully")
+}
+
+func (m *ModmailController) HighlightThread(ctx *gin.Context) {
+	m.handleThreadAction(ctx, m.modmailService.HighlightThread, "Highlight modmail thread successfully")
+}
+
+func (m *ModmailController) UnhighlightThread(ctx *gin.Context) {
+	m.handleThreadAction(ctx, m.modmailService.UnhighlightThread, "Unhighlight modmail thread successfully")
+}
+
+// handleThreadAction runs an action on the thread in the path on behalf of the current user
+func (m *ModmailController) handleThreadAction(ctx *gin.Context, action func(threadID string, userID string) error, successMessage string) {
+	threadID := ctx.Param("thread_id")
+	if threadID == "" {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.ErrBadRequest.Message})
+		return
+	}
+
+	authUser, exists := ctx.Get("authUser")
+	if !exists {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrForbidden), dto.ErrorResponse{ErrorCode: apperror.ErrForbidden.Code, Message: apperror.ErrForbidden.Message})
+		return
+	}
+
+	if err := action(threadID, authUser.(auth.AuthUser).ID); err != nil {
+		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.SuccessResponse{
+		ID:      threadID,
+		Message: successMessage,
+	})
+}
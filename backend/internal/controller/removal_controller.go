@@ -0,0 +1,208 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/giakiet05/lkforum/internal/apperror"
+	"github.com/giakiet05/lkforum/internal/auth"
+	"github.com/giakiet05/lkforum/internal/dto"
+	"github.com/giakiet05/lkforum/internal/model"
+	"github.com/giakiet05/lkforum/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type RemovalController struct {
+	removalService service.RemovalService
+}
+
+func NewRemovalController(removalService service.RemovalService) *RemovalController {
+	return &RemovalController{removalService: removalService}
+}
+
+func (r *RemovalController) GetReasons(ctx *gin.Context) {
+	communityID := ctx.Param("community_id")
+	if communityID == "" {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.ErrBadRequest.Message})
+		return
+	}
+
+	authUser, exists := ctx.Get("authUser")
+	if !exists {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrForbidden), dto.ErrorResponse{ErrorCode: apperror.ErrForbidden.Code, Message: apperror.ErrForbidden.Message})
+		return
+	}
+
+	reasons, err := r.removalService.GetReasons(communityID, authUser.(auth.AuthUser).ID)
+	if err != nil {
+		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"reasons": reasons})
+}
+
+func (r *RemovalController) CreateReason(ctx *gin.Context) {
+	communityID := ctx.Param("community_id")
+	if communityID == "" {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.ErrBadRequest.Message})
+		return
+	}
+
+	var req dto.CreateRemovalReasonRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.Message(err)})
+		return
+	}
+
+	authUser, exists := ctx.Get("authUser")
+	if !exists {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrForbidden), dto.ErrorResponse{ErrorCode: apperror.ErrForbidden.Code, Message: apperror.ErrForbidden.Message})
+		return
+	}
+
+	reason, err := r.removalService.CreateReason(communityID, &req, authUser.(auth.AuthUser).ID)
+	if err != nil {
+		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, reason)
+}
+
+func (r *RemovalController) UpdateReason(ctx *gin.Context) {
+	communityID := ctx.Param("community_id")
+	reasonID := ctx.Param("reason_id")
+	if communityID == "" || reasonID == "" {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.ErrBadRequest.Message})
+		return
+	}
+
+	var req dto.UpdateRemovalReasonRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.Message(err)})
+		return
+	}
+
+	authUser, exists := ctx.Get("authUser")
+	if !exists {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrForbidden), dto.ErrorResponse{ErrorCode: apperror.ErrForbidden.Code, Message: apperror.ErrForbidden.Message})
+		return
+	}
+
+	reason, err := r.removalService.UpdateReason(communityID, reasonID, &req, authUser.(auth.AuthUser).ID)
+	if err != nil {
+		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, reason)
+}
+
+func (r *RemovalController) DeleteReason(ctx *gin.Context) {
+	communityID := ctx.Param("community_id")
+	reasonID := ctx.Param("reason_id")
+	if communityID == "" || reasonID == "" {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.ErrBadRequest.Message})
+		return
+	}
+
+	authUser, exists := ctx.Get("authUser")
+	if !exists {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrForbidden), dto.ErrorResponse{ErrorCode: apperror.ErrForbidden.Code, Message: apperror.ErrForbidden.Message})
+		return
+	}
+
+	if err := r.removalService.DeleteReason(communityID, reasonID, authUser.(auth.AuthUser).ID); err != nil {
+		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.SuccessResponse{
+		ID:      reasonID,
+		Message: "Delete removal reason successfully",
+	})
+}
+
+func (r *RemovalController) RemovePost(ctx *gin.Context) {
+	r.handleRemoval(ctx, "post_id", r.removalService.RemovePost, "Remove post successfully")
+}
+
+func (r *RemovalController) RemoveComment(ctx *gin.Context) {
+	r.handleRemoval(ctx, "comment_id", r.removalService.RemoveComment, "Remove comment successfully")
+}
+
+func (r *RemovalController) RestorePost(ctx *gin.Context) {
+	r.handleRestore(ctx, "post_id", r.removalService.RestorePost, "Restore post successfully")
+}
+
+func (r *RemovalController) RestoreComment(ctx *gin.Context) {
+	r.handleRestore(ctx, "comment_id", r.removalService.RestoreComment, "Restore comment successfully")
+}
+
+// handleRemoval removes the post or comment named by param. Admins who can resolve reports may remove
+// content in any community.
+func (r *RemovalController) handleRemoval(
+	ctx *gin.Context,
+	param string,
+	action func(communityID string, targetID string, req *dto.RemoveContentRequest, actorID string, isAdmin bool) error,
+	successMessage string,
+) {
+	communityID := ctx.Param("community_id")
+	targetID := ctx.Param(param)
+	if communityID == "" || targetID == "" {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.ErrBadRequest.Message})
+		return
+	}
+
+	var req dto.RemoveContentRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.Message(err)})
+		return
+	}
+
+	authUser, exists := ctx.Get("authUser")
+	if !exists {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrForbidden), dto.ErrorResponse{ErrorCode: apperror.ErrForbidden.Code, Message: apperror.ErrForbidden.Message})
+		return
+	}
+
+	if err := action(communityID, targetID, &req, authUser.(auth.AuthUser).ID, auth.HasPermission(ctx, model.PermissionReportsResolve)); err != nil {
+		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.SuccessResponse{
+		ID:      targetID,
+		Message: successMessage,
+	})
+}
+
+func (r *RemovalController) handleRestore(
+	ctx *gin.Context,
+	param string,
+	action func(communityID string, targetID string, actorID string, isAdmin bool) error,
+	successMessage string,
+) {
+	communityID := ctx.Param("community_id")
+	targetID := ctx.Param(param)
+	if communityID == "" || targetID == "" {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.ErrBadRequest.Message})
+		return
+	}
+
+	authUser, exists := ctx.Get("authUser")
+	if !exists {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrForbidden), dto.ErrorResponse{ErrorCode: apperror.ErrForbidden.Code, Message: apperror.ErrForbidden.Message})
+		return
+	}
+
+	if err := action(communityID, targetID, authUser.(auth.AuthUser).ID, auth.HasPermission(ctx, model.PermissionReportsResolve)); err != nil {
+		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.SuccessResponse{
+		ID:      targetID,
+		Message: successMessage,
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/giakiet05/lkforum/internal/apperror"
 	"github.com/giakiet05/lkforum/internal/auth"
 	"github.com/giakiet05/lkforum/internal/dto"
+	"github.com/giakiet05/lkforum/internal/model"
 	"github.com/giakiet05/lkforum/internal/service"
 	"github.com/gin-gonic/gin"
 )
@@ -56,7 +57,7 @@ func (c *UserController) RegisterUser(ctx *gin.Context) {
 		return
 	}
 
-	user, accessToken, refreshToken, err := c.service.RegisterUser(req.Username, req.Email, req.Password)
+	user, accessToken, refreshToken, err := c.service.RegisterUser(req.Username, req.Email, req.Password, clientInfo(ctx))
 	if err != nil {
 		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
 		return
@@ -78,23 +79,102 @@ func (c *UserController) Login(ctx *gin.Context) {
 		return
 	}
 
-	user, accessToken, refreshToken, err := c.service.Login(req.Identifier, req.Password)
+	response, err := c.service.Login(req.Identifier, req.Password, clientInfo(ctx))
 	if err != nil {
 		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, dto.AuthResponse{
-		User:         dto.FromUser(user),
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-	})
+	ctx.JSON(http.StatusOK, response)
+}
+
+// VerifyEmail confirms the email address from a verification link
+func (c *UserController) VerifyEmail(ctx *gin.Context) {
+	var req dto.VerifyEmailRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.Message(err)})
+		return
+	}
+
+	if err := c.service.VerifyEmail(req.Token); err != nil {
+		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.SuccessResponse{Message: "Email verified successfully"})
+}
+
+// ResendVerificationEmail sends the current user a new verification link
+func (c *UserController) ResendVerificationEmail(ctx *gin.Context) {
+	authUser, exists := ctx.Get("authUser")
+	if !exists {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrForbidden), dto.ErrorResponse{ErrorCode: apperror.ErrForbidden.Code, Message: apperror.ErrForbidden.Message})
+		return
+	}
+	userID := authUser.(auth.AuthUser).ID
+
+	if err := c.service.ResendVerificationEmail(userID); err != nil {
+		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.SuccessResponse{ID: userID, Message: "Verification email sent successfully"})
+}
+
+// Logout signs out the current device
+func (c *UserController) Logout(ctx *gin.Context) {
+	authUser, exists := ctx.Get("authUser")
+	if !exists {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrForbidden), dto.ErrorResponse{ErrorCode: apperror.ErrForbidden.Code, Message: apperror.ErrForbidden.Message})
+		return
+	}
+	user := authUser.(auth.AuthUser)
+
+	if err := c.service.Logout(user); err != nil {
+		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.SuccessResponse{ID: user.SessionID, Message: "Logged out successfully"})
+}
+
+// ForgotPassword sends a password reset link. The response is the same whether or not the email is registered.
+func (c *UserController) ForgotPassword(ctx *gin.Context) {
+	var req dto.ForgotPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.Message(err)})
+		return
+	}
+
+	if err := c.service.ForgotPassword(req.Email); err != nil {
+		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.SuccessResponse{Message: "If the email is registered, a password reset link has been sent"})
+}
+
+// ResetPassword sets a new password from a reset link
+func (c *UserController) ResetPassword(ctx *gin.Context) {
+	var req dto.ResetPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(apperror.StatusFromError(apperror.ErrBadRequest), dto.ErrorResponse{ErrorCode: apperror.ErrBadRequest.Code, Message: apperror.Message(err)})
+		return
+	}
+
+	if err := c.service.ResetPassword(req.Token, req.NewPassword, clientInfo(ctx)); err != nil {
+		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{ErrorCode: apperror.Code(err), Message: apperror.Message(err)})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.SuccessResponse{Message: "Password reset successfully"})
 }
 
-// UpdateUser handles user profile updates
+// UpdateUser handles user profile updates. Changing someone else's email hands over their account,
+// so admins need the same permission as for resetting a password.
 func (c *UserController) UpdateUser(ctx *gin.Context) {
 	userID := ctx.Param("id")
-	if !auth.IsOwner(ctx, userID) && !auth.IsAdmin(ctx) {
+	if !auth.IsOwner(ctx, userID) && !auth.HasPermission(ctx, model.PermissionUsersResetPassword) {
 		ctx.JSON(apperror.StatusFromError(apperror.ErrForbidden), dto.ErrorResponse{ErrorCode: apperror.ErrForbidden.Code, Message: apperror.ErrForbidden.Message})
 		return
 	}
@@ -131,7 +211,7 @@ func (c *UserController) UpdateUser(ctx *gin.Context) {
 // DeleteUser handles user account deletion
 func (c *UserController) DeleteUser(ctx *gin.Context) {
 	userID := ctx.Param("id")
-	if !auth.IsOwner(ctx, userID) && !auth.IsAdmin(ctx) {
+	if !auth.IsOwner(ctx, userID) && !auth.HasPermission(ctx, model.PermissionUsersBan) {
 		ctx.JSON(apperror.StatusFromError(apperror.ErrForbidden), dto.ErrorResponse{ErrorCode: apperror.ErrForbidden.Code, Message: apperror.ErrForbidden.Message})
 		return
 	}
@@ -216,7 +296,7 @@ func (c *UserController) RefreshToken(ctx *gin.Context) {
 		return
 	}
 
-	accessToken, refreshToken, err := c.service.RefreshToken(req.RefreshToken)
+	accessToken, refreshToken, err := c.service.RefreshToken(req.RefreshToken, clientInfo(ctx))
 	if err != nil {
 		ctx.JSON(apperror.StatusFromError(err), dto.ErrorResponse{
 			ErrorCode: apperror.Code(err),
@@ -0,0 +1,90 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/giakiet05/lkforum/internal/model"
+)
+
+// Request DTOs
+
+type ChangeRoleRequest struct {
+	Role             model.Role         `json:"role" binding:"required"`
+	Template         string             `json:"template"`           // admin role template, only used when promoting
+	Permissions      []model.Permission `json:"permissions"`        // extra admin permissions, only used when promoting
+	RequireTwoFactor bool               `json:"require_two_factor"` // only used when promoting
+}
+
+type UpdateAdminPermissionsRequest struct {
+	Template         string             `json:"template"`
+	Permissions      []model.Permission `json:"permissions"`
+	RequireTwoFactor bool               `json:"require_two_factor"`
+}
+
+type BanCommunityRequest struct {
+	Reason string `json:"reason" binding:"required,max=500"`
+}
+
+// SetBotRequest flags an account as automated, its content is badged as written by a bot
+type SetBotRequest struct {
+	IsBot *bool `json:"is_bot" binding:"required"`
+}
+
+type SuspendUserRequest struct {
+	Reason          string `json:"reason" binding:"required,max=500"`
+	DurationMinutes int    `json:"duration_minutes" binding:"min=0"` // 0 means permanent
+}
+
+// Response DTOs
+
+// AdminUserResponse exposes the account state admins need on top of the public user fields
+type AdminUserResponse struct {
+	UserResponse
+	CreateAt  time.Time               `json:"create_at"`
+	DeletedAt *time.Time              `json:"deleted_at,omitempty"`
+	Suspended bool                    `json:"suspended"`
+	Admin     *model.AdminRoleContent `json:"admin,omitempty"`
+	BanEnd    *time.Time              `json:"ban_end,omitempty"`
+	BanReason string                  `json:"ban_reason,omitempty"`
+}
+
+type PermissionCatalogResponse struct {
+	Permissions []model.Permission            `json:"permissions"`
+	Templates   map[string][]model.Permission `json:"templates"`
+}
+
+// PasswordResetLinkResponse confirms a reset link was emailed to the user. The link itself is never returned.
+type PasswordResetLinkResponse struct {
+	UserID           string `json:"user_id"`
+	ExpiresInMinutes int    `json:"expires_in_minutes"`
+}
+
+type SuspensionResponse struct {
+	UserID   string     `json:"user_id"`
+	Reason   string     `json:"reason"`
+	BanStart *time.Time `json:"ban_start"`
+	BanEnd   *time.Time `json:"ban_end,omitempty"` // omitted for permanent suspensions
+}
+
+func FromAdminUser(u *model.User) AdminUserResponse {
+	response := AdminUserResponse{
+		UserResponse: FromUser(u),
+		CreateAt:     u.CreateAt,
+		DeletedAt:    u.DeletedAt,
+		Suspended:    u.IsSuspended(time.Now()),
+		Admin:        u.RoleContent.Admin,
+	}
+	if content := u.RoleContent.User; content != nil && response.Suspended {
+		response.BanEnd = content.BanEnd
+		response.BanReason = content.BanReason
+	}
+	return response
+}
+
+func FromAdminUsers(users []*model.User) []AdminUserResponse {
+	responses := make([]AdminUserResponse, 0, len(users))
+	for _, u := range users {
+		responses = append(responses, FromAdminUser(u))
+	}
+	return responses
+}
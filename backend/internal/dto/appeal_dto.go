@@ -0,0 +1,21 @@
+package dto
+
+import "github.com/giakiet05/lkforum/internal/model"
+
+type CreateAppealRequest struct {
+	ModLogID string `json:"mod_log_id" binding:"required"`
+	Message  string `json:"message" binding:"required,max=2000"`
+}
+
+// CreateSuspensionAppealRequest is filed without a session: suspended users cannot log in, so they
+// sign the appeal with their password instead
+type CreateSuspensionAppealRequest struct {
+	Identifier string `json:"identifier" binding:"required"` // Username or Email
+	Password   string `json:"password" binding:"required"`
+	Message    string `json:"message" binding:"required,max=2000"`
+}
+
+type ReviewAppealRequest struct {
+	Status model.AppealStatus `json:"status" binding:"required"`
+	Note   string             `json:"note,omitempty" binding:"max=1000"`
+}
@@ -0,0 +1,33 @@
+package dto
+
+import "github.com/giakiet05/lkforum/internal/model"
+
+// UpdateAutoModRulesRequest replaces every rule of the community; it can be sent as JSON or YAML
+type UpdateAutoModRulesRequest struct {
+	Rules []model.AutoModRule `json:"rules" yaml:"rules"`
+}
+
+// TestAutoModRequest runs rules against sample content without touching anything.
+// The community's saved rules are used when Rules is empty.
+type TestAutoModRequest struct {
+	Rules          []model.AutoModRule  `json:"rules,omitempty" yaml:"rules,omitempty"`
+	Target         model.AutoModTarget  `json:"target" yaml:"target" binding:"required"`
+	Trigger        model.AutoModTrigger `json:"trigger,omitempty" yaml:"trigger,omitempty"` // defaults to create
+	Title          string               `json:"title,omitempty" yaml:"title,omitempty"`
+	Body           string               `json:"body,omitempty" yaml:"body,omitempty"`
+	PostType       model.PostType       `json:"post_type,omitempty" yaml:"post_type,omitempty"`
+	Links          []string             `json:"links,omitempty" yaml:"links,omitempty"` // in addition to the links found in the body
+	AccountAgeDays int                  `json:"account_age_days" yaml:"account_age_days"`
+	Karma          int                  `json:"karma" yaml:"karma"`
+	ReportCount    int                  `json:"report_count" yaml:"report_count"`
+}
+
+// AutoModMatch is a rule that matched and the actions it takes
+type AutoModMatch struct {
+	Rule    string                `json:"rule"`
+	Actions []model.AutoModAction `json:"actions"`
+}
+
+type AutoModTestResponse struct {
+	Matches []AutoModMatch `json:"matches"`
+}
@@ -0,0 +1,11 @@
+package dto
+
+import "github.com/giakiet05/lkforum/internal/model"
+
+type CommunityBanRequest struct {
+	UserID          string                 `json:"user_id" binding:"required"`
+	Type            model.CommunityBanType `json:"type" binding:"required"`
+	RemovalReasonID string                 `json:"removal_reason_id,omitempty"`                               // reason from the community's library
+	Reason          string                 `json:"reason" binding:"required_without=RemovalReasonID,max=500"` // free-form reason, or a note added to the library reason
+	DurationMinutes int                    `json:"duration_minutes" binding:"min=0"`                          // 0 means permanent
+}
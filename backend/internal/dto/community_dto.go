@@ -10,8 +10,8 @@ type CreateCommunityRequest struct {
 	Avatar        *string                `json:"avatar,omitempty"`
 	Banner        *string                `json:"banner,omitempty"`
 	Setting       model.CommunitySetting `json:"setting,omitempty"`
-	Moderators    []model.Moderator      `json:"moderators,omitempty"`
-	CreatorName   string                 `json:"creator_name,omitempty"`
+	Moderators    []ModeratorDTO         `json:"moderators,omitempty"`   // invited once the community exists
+	CreatorName   string                 `json:"creator_name,omitempty"` // ignored, the creator's username is looked up server-side
 	CreatorAvatar string                 `json:"creator_avatar,omitempty"`
 }
 
@@ -23,9 +23,10 @@ type UpdateCommunityRequest struct {
 	Setting     *model.CommunitySetting `json:"setting,omitempty"`
 }
 
+// ModeratorDTO names a user to invite as moderator; the username is looked up server-side
 type ModeratorDTO struct {
-	ModeratorID string `json:"id" binding:"required"`
-	Username    string `json:"username" binding:"required"`
+	ModeratorID string                `json:"id" binding:"required"`
+	Permissions []model.ModPermission `json:"permissions,omitempty"` // empty means full permissions
 }
 
 type AddModeratorRequest struct {
@@ -38,6 +39,16 @@ type RemoveModeratorRequest struct {
 	RemovedModerator []string `json:"removed_moderator" binding:"required"`
 }
 
+type UpdateModeratorPermissionsRequest struct {
+	CommunityID string                `json:"id" binding:"required"`
+	ModeratorID string                `json:"moderator_id" binding:"required"`
+	Permissions []model.ModPermission `json:"permissions"` // empty means full permissions
+}
+
+type TransferOwnershipRequest struct {
+	UserID string `json:"user_id" binding:"required"`
+}
+
 type CommunityResponse struct {
 	ID          string                 `json:"id"`
 	Name        string                 `json:"name"`
@@ -0,0 +1,23 @@
+package dto
+
+import "github.com/giakiet05/lkforum/internal/model"
+
+// UpdateContentFilterRequest replaces every entry of the community's filter
+type UpdateContentFilterRequest struct {
+	Entries []model.ContentFilterEntry `json:"entries"`
+}
+
+type CheckContentRequest struct {
+	Text  string   `json:"text"`
+	Links []string `json:"links,omitempty"` // in addition to the links found in the text
+}
+
+// ContentFilterResult is the verdict on a piece of content. Blocking wins over queueing;
+// masking is applied either way.
+type ContentFilterResult struct {
+	Blocked bool                       `json:"blocked"`
+	Message string                     `json:"message,omitempty"` // why the submission was blocked
+	Queued  bool                       `json:"queued"`
+	Text    string                     `json:"text"` // the content with masked matches replaced
+	Matches []model.ContentFilterEntry `json:"matches"`
+}
@@ -0,0 +1,6 @@
+package dto
+
+type SendDirectMessageRequest struct {
+	RecipientID string `json:"recipient_id" binding:"required"`
+	Content     string `json:"content" binding:"required,max=5000"`
+}
@@ -0,0 +1,13 @@
+package dto
+
+type CreateModmailThreadRequest struct {
+	CommunityID string `json:"community_id" binding:"required"`
+	Subject     string `json:"subject" binding:"required,max=300"`
+	Content     string `json:"content" binding:"required,max=5000"`
+}
+
+type ModmailReplyRequest struct {
+	Content    string `json:"content" binding:"required,max=5000"`
+	AsTeam     bool   `json:"as_team"`     // moderators only: hide who answered behind the mod team
+	IsInternal bool   `json:"is_internal"` // moderators only: a note the user never sees
+}
@@ -0,0 +1,33 @@
+package dto
+
+// Request DTOs
+
+type OIDCCallbackRequest struct {
+	Code  string `json:"code" binding:"required"`
+	State string `json:"state" binding:"required"`
+}
+
+type OIDCSignupRequest struct {
+	SignupToken string `json:"signup_token" binding:"required"`
+	Username    string `json:"username" binding:"required,min=3,max=32"`
+}
+
+// Response DTOs
+
+type OIDCProvidersResponse struct {
+	Providers []string `json:"providers"`
+}
+
+type OIDCAuthorizationResponse struct {
+	AuthorizationURL string `json:"authorization_url"`
+}
+
+// OIDCCallbackResponse is a login response, a confirmation that the identity was linked, or, for someone
+// new, a sign up token to exchange for an account at /auth/oidc/signup once they picked a username.
+type OIDCCallbackResponse struct {
+	LoginResponse
+	Linked            bool   `json:"linked,omitempty"`
+	SignupRequired    bool   `json:"signup_required,omitempty"`
+	SignupToken       string `json:"signup_token,omitempty"`
+	SuggestedUsername string `json:"suggested_username,omitempty"`
+}
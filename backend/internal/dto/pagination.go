@@ -13,6 +13,11 @@ type PaginatedUsersResponse struct {
 	Pagination Pagination     `json:"pagination"`
 }
 
+type PaginatedAdminUsersResponse struct {
+	Users      []AdminUserResponse `json:"users"`
+	Pagination Pagination          `json:"pagination"`
+}
+
 type PaginatedCommunitiesResponse struct {
 	Communities []CommunityResponse `json:"communities"`
 	Pagination  Pagination          `json:"pagination"`
@@ -22,3 +27,58 @@ type PaginatedMembershipsResponse struct {
 	Memberships []model.Membership `json:"memberships"`
 	Pagination  Pagination         `json:"pagination"`
 }
+
+type PaginatedConversationsResponse struct {
+	Conversations []model.Conversation `json:"conversations"`
+	Pagination    Pagination           `json:"pagination"`
+}
+
+type PaginatedMessagesResponse struct {
+	Messages   []model.Message `json:"messages"`
+	Pagination Pagination      `json:"pagination"`
+}
+
+type PaginatedNotificationsResponse struct {
+	Notifications []model.Notification `json:"notifications"`
+	Pagination    Pagination           `json:"pagination"`
+}
+
+type PaginatedReportsResponse struct {
+	Reports    []model.Report `json:"reports"`
+	Pagination Pagination     `json:"pagination"`
+}
+
+type PaginatedModLogsResponse struct {
+	ModLogs    []model.ModLog `json:"mod_logs"`
+	Pagination Pagination     `json:"pagination"`
+}
+
+type PaginatedSecurityEventsResponse struct {
+	SecurityEvents []model.SecurityEvent `json:"security_events"`
+	Pagination     Pagination            `json:"pagination"`
+}
+
+type PaginatedCommunityBansResponse struct {
+	Bans       []model.CommunityBan `json:"bans"`
+	Pagination Pagination           `json:"pagination"`
+}
+
+type PaginatedModeratorInvitesResponse struct {
+	Invites    []model.ModeratorInvite `json:"invites"`
+	Pagination Pagination              `json:"pagination"`
+}
+
+type PaginatedModmailThreadsResponse struct {
+	Threads    []model.ModmailThread `json:"threads"`
+	Pagination Pagination            `json:"pagination"`
+}
+
+type PaginatedModmailMessagesResponse struct {
+	Messages   []model.ModmailMessage `json:"messages"`
+	Pagination Pagination             `json:"pagination"`
+}
+
+type PaginatedAppealsResponse struct {
+	Appeals    []model.Appeal `json:"appeals"`
+	Pagination Pagination     `json:"pagination"`
+}
@@ -0,0 +1,47 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/giakiet05/lkforum/internal/model"
+)
+
+// Request DTOs
+
+type CreatePersonalAccessTokenRequest struct {
+	Name          string             `json:"name" binding:"required,max=100"`
+	Scopes        []model.TokenScope `json:"scopes" binding:"required,min=1"`
+	ExpiresInDays int                `json:"expires_in_days" binding:"required,min=1,max=365"`
+}
+
+// Response DTOs
+
+type PersonalAccessTokenResponse struct {
+	ID         string             `json:"id"`
+	Name       string             `json:"name"`
+	Prefix     string             `json:"prefix"`
+	Scopes     []model.TokenScope `json:"scopes"`
+	CreatedAt  time.Time          `json:"created_at"`
+	ExpiresAt  time.Time          `json:"expires_at"`
+	LastUsedAt *time.Time         `json:"last_used_at,omitempty"`
+	Expired    bool               `json:"expired"`
+}
+
+// CreatedPersonalAccessTokenResponse is the only response that holds the token itself
+type CreatedPersonalAccessTokenResponse struct {
+	PersonalAccessTokenResponse
+	Token string `json:"token"`
+}
+
+func FromPersonalAccessToken(t *model.PersonalAccessToken) PersonalAccessTokenResponse {
+	return PersonalAccessTokenResponse{
+		ID:         t.ID.Hex(),
+		Name:       t.Name,
+		Prefix:     t.Prefix,
+		Scopes:     t.Scopes,
+		CreatedAt:  t.CreatedAt,
+		ExpiresAt:  t.ExpiresAt,
+		LastUsedAt: t.LastUsedAt,
+		Expired:    t.IsExpired(time.Now()),
+	}
+}
@@ -0,0 +1,18 @@
+package dto
+
+type CreateRemovalReasonRequest struct {
+	Title   string `json:"title" binding:"required,max=100"`
+	Message string `json:"message" binding:"required,max=2000"`
+}
+
+type UpdateRemovalReasonRequest struct {
+	Title   *string `json:"title,omitempty" binding:"omitempty,min=1,max=100"`
+	Message *string `json:"message,omitempty" binding:"omitempty,min=1,max=2000"`
+}
+
+// RemoveContentRequest takes a reason from the community's library, a free-form note, or both
+type RemoveContentRequest struct {
+	RemovalReasonID string `json:"removal_reason_id,omitempty" binding:"required_without=Note"`
+	Note            string `json:"note,omitempty" binding:"max=1000"`
+	ReportID        string `json:"report_id,omitempty"` // report on the content to resolve as actioned
+}
@@ -0,0 +1,15 @@
+package dto
+
+import "github.com/giakiet05/lkforum/internal/model"
+
+type CreateReportRequest struct {
+	TargetType  model.ReportTargetType `json:"target_type" binding:"required"`
+	TargetID    string                 `json:"target_id" binding:"required"`
+	Reason      model.ReportReason     `json:"reason" binding:"required"`
+	Description *string                `json:"description,omitempty" binding:"omitempty,max=1000"`
+}
+
+type UpdateReportStatusRequest struct {
+	Status model.ReportStatus `json:"status" binding:"required"`
+	Note   string             `json:"note,omitempty" binding:"max=1000"`
+}
@@ -0,0 +1,39 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/giakiet05/lkforum/internal/model"
+)
+
+// ClientInfo describes the device a login or refresh comes from
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+// Response DTOs
+
+type SessionResponse struct {
+	ID         string    `json:"id"`
+	Device     string    `json:"device"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"user_agent"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"` // the session making the request
+}
+
+func FromSession(s *model.Session, currentSessionID string) SessionResponse {
+	return SessionResponse{
+		ID:         s.ID.Hex(),
+		Device:     s.Device,
+		IP:         s.IP,
+		UserAgent:  s.UserAgent,
+		CreatedAt:  s.CreatedAt,
+		LastUsedAt: s.LastUsedAt,
+		ExpiresAt:  s.ExpiresAt,
+		Current:    s.ID.Hex() == currentSessionID,
+	}
+}
@@ -15,6 +15,19 @@ type UserLoginRequest struct {
 	Password   string `json:"password" binding:"required"`
 }
 
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=6"`
+}
+
 type UserUpdateRequest struct {
 	Username string `json:"username"`
 	Email    string `json:"email"`
@@ -28,10 +41,13 @@ type ChangePasswordRequest struct {
 // Response DTOs
 
 type UserResponse struct {
-	ID       string     `json:"id"`
-	Username string     `json:"username"`
-	Email    string     `json:"email,omitempty"`
-	Role     model.Role `json:"role"`
+	ID            string     `json:"id"`
+	Username      string     `json:"username"`
+	Email         string     `json:"email,omitempty"`
+	EmailVerified bool       `json:"email_verified"`
+	TwoFactor     bool       `json:"two_factor_enabled"`
+	Role          model.Role `json:"role"`
+	IsBot         bool       `json:"is_bot"`
 }
 
 type AuthResponse struct {
@@ -40,12 +56,31 @@ type AuthResponse struct {
 	RefreshToken string       `json:"refresh_token"`
 }
 
+// LoginResponse holds the tokens, or only an MFA challenge when the account has two-factor
+// authentication; the challenge is exchanged for tokens at /auth/login/2fa.
+type LoginResponse struct {
+	User         *UserResponse `json:"user,omitempty"`
+	AccessToken  string        `json:"access_token,omitempty"`
+	RefreshToken string        `json:"refresh_token,omitempty"`
+	MFARequired  bool          `json:"mfa_required"`
+	MFAToken     string        `json:"mfa_token,omitempty"`
+}
+
+// NewLoginResponse builds the response of a completed login
+func NewLoginResponse(u *model.User, accessToken, refreshToken string) *LoginResponse {
+	user := FromUser(u)
+	return &LoginResponse{User: &user, AccessToken: accessToken, RefreshToken: refreshToken}
+}
+
 func FromUser(u *model.User) UserResponse {
 	return UserResponse{
-		ID:       u.ID.Hex(),
-		Username: u.Username,
-		Email:    u.Email,
-		Role:     u.Role,
+		ID:            u.ID.Hex(),
+		Username:      u.Username,
+		Email:         u.Email,
+		EmailVerified: u.IsEmailVerified(),
+		TwoFactor:     u.TwoFactorEnabled(),
+		Role:          u.Role,
+		IsBot:         u.IsBot,
 	}
 }
 
@@ -0,0 +1,74 @@
+package mail
+
+import (
+	"fmt"
+	"log"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/giakiet05/lkforum/internal/config"
+)
+
+// Mailer sends plain text emails
+type Mailer interface {
+	Send(to, subject, body string) error
+}
+
+// NewMailerFromEnv returns an SMTP mailer when SMTP_HOST is set and a log mailer otherwise.
+// Point SMTP_HOST at a local sink such as MailHog or smtp4dev to inspect mails during development.
+func NewMailerFromEnv() Mailer {
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		log.Println("SMTP_HOST not set. Emails will be written to the log instead of being sent")
+		return &logMailer{}
+	}
+
+	return &smtpMailer{
+		addr:     fmt.Sprintf("%s:%d", host, config.GetEnvIntWithDefault("SMTP_PORT", 587)),
+		host:     host,
+		username: os.Getenv("SMTP_USERNAME"),
+		password: os.Getenv("SMTP_PASSWORD"),
+		from:     config.GetEnvWithDefault("MAIL_FROM", "no-reply@lkforum.local"),
+	}
+}
+
+type smtpMailer struct {
+	addr     string
+	host     string
+	username string
+	password string
+	from     string
+}
+
+func (m *smtpMailer) Send(to, subject, body string) error {
+	// Local sinks usually run without auth, and net/smtp refuses PLAIN auth over plain connections to remote hosts anyway
+	var auth smtp.Auth
+	if m.username != "" {
+		auth = smtp.PlainAuth("", m.username, m.password, m.host)
+	}
+
+	return smtp.SendMail(m.addr, auth, m.from, []string{to}, buildMessage(m.from, to, subject, body))
+}
+
+func buildMessage(from, to, subject, body string) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + to + "\r\n")
+	b.WriteString("Subject: " + subject + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+// logMailer prints mails instead of sending them, for development without an SMTP server
+type logMailer struct{}
+
+func (m *logMailer) Send(to, subject, body string) error {
+	log.Printf("Mail to %s: %s\n%s\n", to, subject, body)
+	return nil
+}
@@ -1,13 +1,17 @@
 package middleware
 
 import (
+	"errors"
+	"github.com/giakiet05/lkforum/internal/apperror"
 	"github.com/giakiet05/lkforum/internal/auth"
+	"github.com/giakiet05/lkforum/internal/dto"
+	"github.com/giakiet05/lkforum/internal/model"
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"strings"
 )
 
-// AuthMiddleware parse access token và nhét AuthUser vào context
+// AuthMiddleware parse access token hoặc personal access token và nhét AuthUser vào context
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
@@ -25,13 +29,40 @@ func AuthMiddleware() gin.HandlerFunc {
 		}
 
 		token := parts[1]
-		user, err := auth.ParseAccessToken(token)
+		var user auth.AuthUser
+		var err error
+		if auth.IsPersonalAccessToken(token) {
+			user, err = auth.ParsePersonalAccessToken(token)
+		} else {
+			user, err = auth.ParseAccessToken(token)
+		}
+		if errors.Is(err, apperror.ErrUserInactive) {
+			c.JSON(http.StatusForbidden, gin.H{"error": apperror.ErrUserInactive.Message})
+			c.Abort()
+			return
+		}
+		if errors.Is(err, apperror.ErrTokenStatusUnavailable) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": apperror.ErrTokenStatusUnavailable.Message})
+			c.Abort()
+			return
+		}
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
 			c.Abort()
 			return
 		}
 
+		// Personal access tokens read with the read scope and write with the post scope
+		scope := model.TokenScopePost
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			scope = model.TokenScopeRead
+		}
+		if !user.HasScope(scope) {
+			c.JSON(http.StatusForbidden, dto.ErrorResponse{ErrorCode: apperror.ErrInsufficientScope.Code, Message: apperror.ErrInsufficientScope.Message})
+			c.Abort()
+			return
+		}
+
 		// Nhét user vào context
 		c.Set("authUser", user)
 		c.Next()
@@ -61,6 +92,124 @@ func RequireAdmin() gin.HandlerFunc {
 			return
 		}
 
+		if user.MFASetupRequired {
+			c.JSON(http.StatusForbidden, dto.ErrorResponse{ErrorCode: apperror.ErrTwoFactorRequired.Code, Message: apperror.ErrTwoFactorRequired.Message})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireVerifiedEmail keeps unverified accounts away from creating content.
+// The claim is refreshed with the tokens, so a newly verified user refreshes once to lift it.
+func RequireVerifiedEmail() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		val, exists := c.Get("authUser")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+			c.Abort()
+			return
+		}
+
+		user, ok := val.(auth.AuthUser)
+		if !ok {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid auth context"})
+			c.Abort()
+			return
+		}
+
+		if !user.EmailVerified {
+			c.JSON(http.StatusForbidden, dto.ErrorResponse{ErrorCode: apperror.ErrEmailNotVerified.Code, Message: apperror.ErrEmailNotVerified.Message})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequirePermission check the admin holds every listed permission
+func RequirePermission(permissions ...model.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		val, exists := c.Get("authUser")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+			c.Abort()
+			return
+		}
+
+		if _, ok := val.(auth.AuthUser); !ok {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid auth context"})
+			c.Abort()
+			return
+		}
+
+		for _, permission := range permissions {
+			if !auth.HasPermission(c, permission) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Missing permission: " + string(permission)})
+				c.Abort()
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// RequireScope keeps personal access tokens without the scope away, e.g. the moderate scope for moderator tools.
+// Session tokens pass.
+func RequireScope(scope model.TokenScope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		val, exists := c.Get("authUser")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+			c.Abort()
+			return
+		}
+
+		user, ok := val.(auth.AuthUser)
+		if !ok {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid auth context"})
+			c.Abort()
+			return
+		}
+
+		if !user.HasScope(scope) {
+			c.JSON(http.StatusForbidden, dto.ErrorResponse{ErrorCode: apperror.ErrInsufficientScope.Code, Message: apperror.ErrInsufficientScope.Message})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireSessionLogin refuses personal access tokens on routes that manage the account itself,
+// so a leaked token cannot be turned into control of the account
+func RequireSessionLogin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		val, exists := c.Get("authUser")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+			c.Abort()
+			return
+		}
+
+		user, ok := val.(auth.AuthUser)
+		if !ok {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid auth context"})
+			c.Abort()
+			return
+		}
+
+		if user.IsPersonalAccessToken() {
+			c.JSON(http.StatusForbidden, dto.ErrorResponse{ErrorCode: apperror.ErrSessionLoginRequired.Code, Message: apperror.ErrSessionLoginRequired.Message})
+			c.Abort()
+			return
+		}
+
 		c.Next()
 	}
 }
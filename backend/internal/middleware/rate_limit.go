@@ -0,0 +1,169 @@
+package middleware
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/giakiet05/lkforum/internal/apperror"
+	"github.com/giakiet05/lkforum/internal/auth"
+	"github.com/giakiet05/lkforum/internal/config"
+	"github.com/giakiet05/lkforum/internal/dto"
+	"github.com/giakiet05/lkforum/internal/util"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// RateLimitKey decides who a limit is counted against
+type RateLimitKey string
+
+const (
+	RateLimitByUser RateLimitKey = "user" // the authenticated user, the client IP for anonymous requests
+	RateLimitByIP   RateLimitKey = "ip"
+)
+
+// RateLimitPolicy allows Limit requests per sliding Window. Every policy can be overridden with a
+// RATE_LIMIT_<NAME> environment variable such as RATE_LIMIT_LOGIN=10/1m.
+type RateLimitPolicy struct {
+	Name   string
+	Limit  int
+	Window time.Duration
+	Key    RateLimitKey
+}
+
+var (
+	RateLimitDefault         = RateLimitPolicy{Name: "default", Limit: 300, Window: time.Minute, Key: RateLimitByIP}
+	RateLimitLogin           = RateLimitPolicy{Name: "login", Limit: 10, Window: time.Minute, Key: RateLimitByIP}
+	RateLimitRegister        = RateLimitPolicy{Name: "register", Limit: 5, Window: time.Hour, Key: RateLimitByIP}
+	RateLimitCreateCommunity = RateLimitPolicy{Name: "create_community", Limit: 5, Window: time.Hour, Key: RateLimitByUser}
+	RateLimitMembership      = RateLimitPolicy{Name: "membership", Limit: 30, Window: time.Minute, Key: RateLimitByUser}
+	RateLimitCreateContent   = RateLimitPolicy{Name: "create_content", Limit: 30, Window: 10 * time.Minute, Key: RateLimitByUser}
+	RateLimitVerifyEmail     = RateLimitPolicy{Name: "verify_email", Limit: 3, Window: time.Hour, Key: RateLimitByUser}
+	RateLimitPasswordReset   = RateLimitPolicy{Name: "password_reset", Limit: 5, Window: time.Hour, Key: RateLimitByIP}
+)
+
+// slidingWindowScript keeps one sorted-set member per request inside the window. It returns whether the
+// request is allowed, how many requests the window holds and how many milliseconds until the oldest
+// one leaves it.
+var slidingWindowScript = redis.NewScript(`
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+
+redis.call('ZREMRANGEBYSCORE', key, 0, now - window)
+local count = redis.call('ZCARD', key)
+local allowed = 0
+if count < limit then
+	redis.call('ZADD', key, now, ARGV[4])
+	redis.call('PEXPIRE', key, window)
+	count = count + 1
+	allowed = 1
+end
+
+local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+local reset = window
+if oldest[2] then
+	reset = tonumber(oldest[2]) + window - now
+end
+return {allowed, count, reset}
+`)
+
+var rateLimitClient *redis.Client
+
+// SetRateLimitClient sets the Redis client the rate limiter counts requests in.
+// Without one, rate limiting is disabled.
+func SetRateLimitClient(client *redis.Client) {
+	rateLimitClient = client
+}
+
+// RateLimit rejects requests over the policy with 429 and reports the limit in X-RateLimit-* headers.
+// Limits keyed by user must come after AuthMiddleware. If Redis is unavailable, requests are let through.
+func RateLimit(policy RateLimitPolicy) gin.HandlerFunc {
+	policy = policy.withEnvOverride()
+
+	return func(c *gin.Context) {
+		if rateLimitClient == nil {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := util.NewDefaultRedisContext()
+		defer cancel()
+
+		now := time.Now().UnixMilli()
+		member, err := util.RandomToken(8)
+		if err != nil {
+			member = strconv.FormatInt(time.Now().UnixNano(), 10)
+		}
+
+		result, err := slidingWindowScript.Run(ctx, rateLimitClient,
+			[]string{rateLimitKey(c, policy)},
+			now, policy.Window.Milliseconds(), policy.Limit, member,
+		).Int64Slice()
+		if err != nil || len(result) != 3 {
+			log.Printf("rate limiter unavailable for policy %s: %v", policy.Name, err)
+			c.Next()
+			return
+		}
+
+		allowed, count, resetMillis := result[0] == 1, int(result[1]), result[2]
+		resetSeconds := int64(math.Ceil(float64(resetMillis) / 1000))
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(policy.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(max(policy.Limit-count, 0)))
+		c.Header("X-RateLimit-Reset", strconv.FormatInt(time.Now().Unix()+resetSeconds, 10))
+
+		if !allowed {
+			c.Header("Retry-After", strconv.FormatInt(max(resetSeconds, 1), 10))
+			c.AbortWithStatusJSON(apperror.StatusFromError(apperror.ErrTooManyRequests), dto.ErrorResponse{
+				ErrorCode: apperror.ErrTooManyRequests.Code,
+				Message:   apperror.ErrTooManyRequests.Message,
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// rateLimitKey counts anonymous requests by ClientIP, which only reads forwarded headers set by the
+// proxies configured in TRUSTED_PROXIES and otherwise is the address the request came from
+func rateLimitKey(c *gin.Context, policy RateLimitPolicy) string {
+	if policy.Key == RateLimitByUser {
+		if val, exists := c.Get("authUser"); exists {
+			if user, ok := val.(auth.AuthUser); ok {
+				return fmt.Sprintf("ratelimit:%s:user:%s", policy.Name, user.ID)
+			}
+		}
+	}
+	return fmt.Sprintf("ratelimit:%s:ip:%s", policy.Name, c.ClientIP())
+}
+
+// withEnvOverride applies RATE_LIMIT_<NAME>=<limit>/<window>, ignoring values that do not parse
+func (p RateLimitPolicy) withEnvOverride() RateLimitPolicy {
+	envKey := "RATE_LIMIT_" + strings.ToUpper(p.Name)
+	value := config.GetEnvWithDefault(envKey, "")
+	if value == "" {
+		return p
+	}
+
+	limitPart, windowPart, found := strings.Cut(value, "/")
+	limit, err := strconv.Atoi(limitPart)
+	if !found || err != nil || limit <= 0 {
+		log.Printf("ignoring invalid %s=%q, expected <limit>/<window> like 10/1m", envKey, value)
+		return p
+	}
+	window, err := time.ParseDuration(windowPart)
+	if err != nil || window <= 0 {
+		log.Printf("ignoring invalid %s=%q, expected <limit>/<window> like 10/1m", envKey, value)
+		return p
+	}
+
+	p.Limit = limit
+	p.Window = window
+	return p
+}
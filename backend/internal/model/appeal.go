@@ -0,0 +1,49 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Appeal asks for a second look at a moderator or admin action taken against the user. Each mod log
+// entry can be appealed once, and the appeal is reviewed by someone other than whoever took the action.
+type Appeal struct {
+	ID            primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	ModLogID      primitive.ObjectID  `bson:"mod_log_id" json:"mod_log_id"`                         // the action being appealed
+	CommunityID   *primitive.ObjectID `bson:"community_id,omitempty" json:"community_id,omitempty"` // nil for site-wide suspensions, which only admins review
+	UserID        primitive.ObjectID  `bson:"user_id" json:"user_id"`
+	Action        ModAction           `bson:"action" json:"action"`
+	TargetType    ModTargetType       `bson:"target_type" json:"target_type"`
+	TargetID      primitive.ObjectID  `bson:"target_id" json:"target_id"`
+	ActedBy       primitive.ObjectID  `bson:"acted_by" json:"acted_by"`
+	ActionReason  string              `bson:"action_reason,omitempty" json:"action_reason,omitempty"`
+	ActionDetails interface{}         `bson:"action_details,omitempty" json:"action_details,omitempty"` // snapshot of the action's result, e.g. the ban
+	Message       string              `bson:"message" json:"message"`
+	Status        AppealStatus        `bson:"status" json:"status"`
+	ReviewedBy    *primitive.ObjectID `bson:"reviewed_by,omitempty" json:"reviewed_by,omitempty"`
+	ReviewNote    string              `bson:"review_note,omitempty" json:"review_note,omitempty"`
+	ReviewedAt    *time.Time          `bson:"reviewed_at,omitempty" json:"reviewed_at,omitempty"`
+	CreatedAt     time.Time           `bson:"created_at" json:"created_at"`
+}
+
+type AppealStatus string
+
+const (
+	AppealStatusPending AppealStatus = "pending"
+	AppealStatusGranted AppealStatus = "granted" // the action was reversed
+	AppealStatusDenied  AppealStatus = "denied"
+)
+
+func IsValidAppealStatus(status AppealStatus) bool {
+	return status == AppealStatusPending || status == AppealStatusGranted || status == AppealStatusDenied
+}
+
+// IsAppealableAction reports whether users can appeal the action
+func IsAppealableAction(action ModAction) bool {
+	switch action {
+	case ModActionBanUser, ModActionMuteUser, ModActionRemovePost, ModActionRemoveComment, ModActionSuspendUser:
+		return true
+	}
+	return false
+}
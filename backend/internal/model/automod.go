@@ -0,0 +1,111 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AutoModConfig holds the AutoMod rules of a community. Rules are evaluated in order and every
+// matching rule applies its actions.
+type AutoModConfig struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	CommunityID primitive.ObjectID `bson:"community_id" json:"community_id"`
+	Rules       []AutoModRule      `bson:"rules" json:"rules"`
+	UpdatedBy   primitive.ObjectID `bson:"updated_by,omitempty" json:"updated_by,omitempty"`
+	UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+type AutoModRule struct {
+	Name       string            `bson:"name" json:"name" yaml:"name"`
+	Disabled   bool              `bson:"disabled,omitempty" json:"disabled,omitempty" yaml:"disabled,omitempty"`
+	Targets    []AutoModTarget   `bson:"targets,omitempty" json:"targets,omitempty" yaml:"targets,omitempty"`    // empty means posts and comments
+	Triggers   []AutoModTrigger  `bson:"triggers,omitempty" json:"triggers,omitempty" yaml:"triggers,omitempty"` // empty means create and edit
+	Conditions AutoModConditions `bson:"conditions" json:"conditions" yaml:"conditions"`
+	Actions    []AutoModAction   `bson:"actions" json:"actions" yaml:"actions"`
+}
+
+// AutoModConditions must all hold for a rule to match; unset conditions are ignored
+type AutoModConditions struct {
+	TitleRegex          string     `bson:"title_regex,omitempty" json:"title_regex,omitempty" yaml:"title_regex,omitempty"` // posts only
+	BodyRegex           string     `bson:"body_regex,omitempty" json:"body_regex,omitempty" yaml:"body_regex,omitempty"`
+	AccountAgeDaysBelow *int       `bson:"account_age_days_below,omitempty" json:"account_age_days_below,omitempty" yaml:"account_age_days_below,omitempty"`
+	KarmaBelow          *int       `bson:"karma_below,omitempty" json:"karma_below,omitempty" yaml:"karma_below,omitempty"`
+	Domains             []string   `bson:"domains,omitempty" json:"domains,omitempty" yaml:"domains,omitempty"` // any link to one of these domains or their subdomains
+	PostTypes           []PostType `bson:"post_types,omitempty" json:"post_types,omitempty" yaml:"post_types,omitempty"`
+	ReportCountAtLeast  *int       `bson:"report_count_at_least,omitempty" json:"report_count_at_least,omitempty" yaml:"report_count_at_least,omitempty"`
+}
+
+type AutoModAction struct {
+	Type    AutoModActionType `bson:"type" json:"type" yaml:"type"`
+	Flair   string            `bson:"flair,omitempty" json:"flair,omitempty" yaml:"flair,omitempty"`       // for flair
+	Message string            `bson:"message,omitempty" json:"message,omitempty" yaml:"message,omitempty"` // for reply and notify_mods
+}
+
+type AutoModTarget string
+
+const (
+	AutoModTargetPost    AutoModTarget = "post"
+	AutoModTargetComment AutoModTarget = "comment"
+)
+
+type AutoModTrigger string
+
+const (
+	AutoModTriggerCreate AutoModTrigger = "create"
+	AutoModTriggerEdit   AutoModTrigger = "edit"
+)
+
+type AutoModActionType string
+
+const (
+	AutoModActionRemove     AutoModActionType = "remove"
+	AutoModActionFilter     AutoModActionType = "filter" // hold for moderator review
+	AutoModActionLock       AutoModActionType = "lock"   // locks the post, or the comment's post
+	AutoModActionFlair      AutoModActionType = "flair"  // posts only
+	AutoModActionReply      AutoModActionType = "reply"
+	AutoModActionNotifyMods AutoModActionType = "notify_mods"
+)
+
+// AutoModActionTypes is the full catalog of actions a rule can take
+var AutoModActionTypes = []AutoModActionType{
+	AutoModActionRemove,
+	AutoModActionFilter,
+	AutoModActionLock,
+	AutoModActionFlair,
+	AutoModActionReply,
+	AutoModActionNotifyMods,
+}
+
+// AppliesTo checks whether the rule runs for the target and trigger
+func (r *AutoModRule) AppliesTo(target AutoModTarget, trigger AutoModTrigger) bool {
+	if r.Disabled {
+		return false
+	}
+
+	targetMatched := len(r.Targets) == 0
+	for _, t := range r.Targets {
+		if t == target {
+			targetMatched = true
+		}
+	}
+
+	triggerMatched := len(r.Triggers) == 0
+	for _, t := range r.Triggers {
+		if t == trigger {
+			triggerMatched = true
+		}
+	}
+
+	return targetMatched && triggerMatched
+}
+
+// IsValidAutoModAction checks the action type against the catalog
+func IsValidAutoModAction(actionType AutoModActionType) bool {
+	for _, a := range AutoModActionTypes {
+		if a == actionType {
+			return true
+		}
+	}
+	return false
+}
@@ -7,14 +7,16 @@ import (
 )
 
 type Comment struct {
-	ID             primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
-	AuthorID       primitive.ObjectID  `bson:"author_id" json:"author_id"`
-	AuthorUsername string              `bson:"author_username,omitempty" json:"author_username,omitempty"`
-	AuthorAvatar   string              `bson:"author_avatar,omitempty" json:"author_avatar,omitempty"`
-	PostID         primitive.ObjectID  `bson:"post_id" json:"post_id"`
-	ParentID       *primitive.ObjectID `bson:"parent_id,omitempty" json:"parent_id,omitempty"`
-	Content        string              `bson:"content" json:"content"`
-	CreatedAt      time.Time           `bson:"created_at,omitempty" json:"created_at,omitempty"`
-	UpdatedAt      *time.Time          `bson:"updated_at,omitempty" json:"updated_at,omitempty"`
-	IsDeleted      bool                `bson:"is_deleted,omitempty" json:"is_deleted,omitempty"`
+	ID               primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	AuthorID         primitive.ObjectID  `bson:"author_id" json:"author_id"`
+	AuthorUsername   string              `bson:"author_username,omitempty" json:"author_username,omitempty"`
+	AuthorAvatar     string              `bson:"author_avatar,omitempty" json:"author_avatar,omitempty"`
+	AuthorIsBot      bool                `bson:"author_is_bot,omitempty" json:"author_is_bot,omitempty"`
+	PostID           primitive.ObjectID  `bson:"post_id" json:"post_id"`
+	ParentID         *primitive.ObjectID `bson:"parent_id,omitempty" json:"parent_id,omitempty"`
+	Content          string              `bson:"content" json:"content"`
+	CreatedAt        time.Time           `bson:"created_at,omitempty" json:"created_at,omitempty"`
+	UpdatedAt        *time.Time          `bson:"updated_at,omitempty" json:"updated_at,omitempty"`
+	IsDeleted        bool                `bson:"is_deleted,omitempty" json:"is_deleted,omitempty"`
+	ModerationStatus ModerationStatus    `bson:"moderation_status,omitempty" json:"moderation_status,omitempty"`
 }
@@ -7,21 +7,22 @@ import (
 )
 
 type Community struct {
-	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	Name           string             `bson:"name,omitempty" json:"name,omitempty"`
-	Description    *string            `bson:"description,omitempty" json:"description,omitempty"`
-	Avatar         *string            `bson:"avatar,omitempty" json:"avatar,omitempty"`
-	Banner         *string            `bson:"banner,omitempty" json:"banner,omitempty"`
-	Setting        CommunitySetting   `bson:"setting,omitempty" json:"setting,omitempty"`
-	Moderators     []Moderator        `bson:"moderators,omitempty" json:"moderators,omitempty"`
-	MemberCount    int64              `bson:"member_count,omitempty" json:"member_count,omitempty"`
-	PostCount      int64              `bson:"post_count,omitempty" json:"post_count,omitempty"`
-	CreateAt       time.Time          `bson:"create_at,omitempty" json:"create_at,omitempty"`
-	CreateByID     primitive.ObjectID `bson:"create_by_id,omitempty" json:"create_by_id,omitempty"`
-	CreateByName   string             `bson:"create_by_name,omitempty" json:"create_by_name,omitempty"`
-	CreateByAvatar string             `bson:"create_by_avatar,omitempty" json:"create_by_avatar,omitempty"`
-	IsDeleted      bool               `bson:"is_deleted" json:"is_deleted"`
-	IsBanned       bool               `bson:"is_banned" json:"is_banned"`
+	ID             primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	Name           string              `bson:"name,omitempty" json:"name,omitempty"`
+	Description    *string             `bson:"description,omitempty" json:"description,omitempty"`
+	Avatar         *string             `bson:"avatar,omitempty" json:"avatar,omitempty"`
+	Banner         *string             `bson:"banner,omitempty" json:"banner,omitempty"`
+	Setting        CommunitySetting    `bson:"setting,omitempty" json:"setting,omitempty"`
+	Moderators     []Moderator         `bson:"moderators,omitempty" json:"moderators,omitempty"`
+	MemberCount    int64               `bson:"member_count,omitempty" json:"member_count,omitempty"`
+	PostCount      int64               `bson:"post_count,omitempty" json:"post_count,omitempty"`
+	CreateAt       time.Time           `bson:"create_at,omitempty" json:"create_at,omitempty"`
+	CreateByID     primitive.ObjectID  `bson:"create_by_id,omitempty" json:"create_by_id,omitempty"`
+	CreateByName   string              `bson:"create_by_name,omitempty" json:"create_by_name,omitempty"`
+	CreateByAvatar string              `bson:"create_by_avatar,omitempty" json:"create_by_avatar,omitempty"`
+	IsDeleted      bool                `bson:"is_deleted" json:"is_deleted"`
+	IsBanned       bool                `bson:"is_banned" json:"is_banned"`
+	PendingOwnerID *primitive.ObjectID `bson:"pending_owner_id,omitempty" json:"pending_owner_id,omitempty"` // moderator offered ownership, until they accept
 }
 
 type CommunitySetting struct {
@@ -32,10 +33,67 @@ type CommunitySetting struct {
 	PostRequireApproval bool `bson:"requireApproval" json:"requireApproval"`         // new posts need moderator approval
 	JoinRequireApproval bool `bson:"joinRequireApproval" json:"joinRequireApproval"` // new member need moderator approval
 	MaxPostLength       int  `bson:"maxPostLength,omitempty" json:"maxPostLength,omitempty"`
+	PublicModLog        bool `bson:"publicModLog" json:"publicModLog"` // anyone can read the mod log
+
+	// Throttles for regular members; moderators are exempt. Zero disables each one.
+	PostIntervalSeconds    int `bson:"postIntervalSeconds,omitempty" json:"postIntervalSeconds,omitempty"`       // slow mode between one user's posts
+	CommentIntervalSeconds int `bson:"commentIntervalSeconds,omitempty" json:"commentIntervalSeconds,omitempty"` // slow mode between one user's comments
+	MinAccountAgeDays      int `bson:"minAccountAgeDays,omitempty" json:"minAccountAgeDays,omitempty"`
+	MinKarma               int `bson:"minKarma,omitempty" json:"minKarma,omitempty"`
 }
 
+// Moderator is ordered by seniority in Community.Moderators: the owner comes first and a moderator
+// can only manage the ones listed after them.
 type Moderator struct {
-	UserID     primitive.ObjectID `bson:"user_id,omitempty" json:"user_id,omitempty"`
-	Username   string             `bson:"username,omitempty" json:"username,omitempty"`
-	AssignedAt time.Time          `bson:"assigned_at,omitempty" json:"assigned_at,omitempty"`
+	UserID      primitive.ObjectID `bson:"user_id,omitempty" json:"user_id,omitempty"`
+	Username    string             `bson:"username,omitempty" json:"username,omitempty"`
+	AssignedAt  time.Time          `bson:"assigned_at,omitempty" json:"assigned_at,omitempty"`
+	Permissions []ModPermission    `bson:"permissions,omitempty" json:"permissions,omitempty"` // empty means full permissions
+}
+
+type ModPermission string
+
+const (
+	ModPermissionPosts    ModPermission = "posts"    // approve, remove and lock posts and comments, handle reports
+	ModPermissionUsers    ModPermission = "users"    // ban and mute users
+	ModPermissionSettings ModPermission = "settings" // edit the community and its settings
+	ModPermissionMods     ModPermission = "mods"     // add, remove and edit moderators below them
+	ModPermissionMail     ModPermission = "mail"     // read and answer modmail
+)
+
+// ModPermissions is the full catalog of moderator permissions
+var ModPermissions = []ModPermission{
+	ModPermissionPosts,
+	ModPermissionUsers,
+	ModPermissionSettings,
+	ModPermissionMods,
+	ModPermissionMail,
+}
+
+// EffectivePermissions returns the moderator's permissions, expanding the empty set to full permissions
+func (m *Moderator) EffectivePermissions() []ModPermission {
+	if len(m.Permissions) == 0 {
+		return ModPermissions
+	}
+	return m.Permissions
+}
+
+// HasPermission checks if the moderator holds the permission
+func (m *Moderator) HasPermission(permission ModPermission) bool {
+	for _, p := range m.EffectivePermissions() {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValidModPermission checks the permission against the catalog
+func IsValidModPermission(permission ModPermission) bool {
+	for _, p := range ModPermissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
 }
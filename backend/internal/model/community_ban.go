@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CommunityBan keeps a user out of a community (ban) or makes them read-only there (mute)
+type CommunityBan struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	CommunityID primitive.ObjectID `bson:"community_id" json:"community_id"`
+	UserID      primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Type        CommunityBanType   `bson:"type" json:"type"`
+	Reason      string             `bson:"reason,omitempty" json:"reason,omitempty"`
+	IssuedBy    primitive.ObjectID `bson:"issued_by" json:"issued_by"`
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+	ExpiresAt   *time.Time         `bson:"expires_at,omitempty" json:"expires_at,omitempty"` // nil for permanent bans
+}
+
+type CommunityBanType string
+
+const (
+	CommunityBanTypeBan  CommunityBanType = "ban"
+	CommunityBanTypeMute CommunityBanType = "mute"
+)
@@ -0,0 +1,38 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ContentFilter is a community's managed list of banned words, phrases and link domains
+type ContentFilter struct {
+	ID          primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	CommunityID primitive.ObjectID   `bson:"community_id" json:"community_id"`
+	Entries     []ContentFilterEntry `bson:"entries" json:"entries"`
+	UpdatedBy   primitive.ObjectID   `bson:"updated_by,omitempty" json:"updated_by,omitempty"`
+	UpdatedAt   time.Time            `bson:"updated_at" json:"updated_at"`
+}
+
+type ContentFilterEntry struct {
+	Kind    ContentFilterKind   `bson:"kind" json:"kind"`
+	Pattern string              `bson:"pattern" json:"pattern"` // a word or phrase, or a domain that also covers its subdomains
+	Action  ContentFilterAction `bson:"action" json:"action"`
+	Message string              `bson:"message,omitempty" json:"message,omitempty"` // shown to the author when the submission is blocked
+}
+
+type ContentFilterKind string
+
+const (
+	ContentFilterKindWord   ContentFilterKind = "word" // words and phrases
+	ContentFilterKindDomain ContentFilterKind = "domain"
+)
+
+type ContentFilterAction string
+
+const (
+	ContentFilterActionBlock ContentFilterAction = "block" // reject the submission
+	ContentFilterActionQueue ContentFilterAction = "queue" // accept it into the moderation queue
+	ContentFilterActionMask  ContentFilterAction = "mask"  // replace the match with asterisks
+)
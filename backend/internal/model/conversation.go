@@ -7,11 +7,28 @@ import (
 )
 
 type Conversation struct {
-	ID        primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
-	Type      string               `bson:"type" json:"type"` // direct or group
-	Members   []primitive.ObjectID `bson:"members" json:"members"`
-	Name      string               `bson:"name,omitempty" json:"name,omitempty"`
-	Avatar    string               `bson:"avatar,omitempty" json:"avatar,omitempty"`
-	CreatedBy primitive.ObjectID   `bson:"created_by" json:"created_by"`
-	CreatedAt time.Time            `bson:"created_at" json:"created_at"`
+	ID            primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	Type          string               `bson:"type" json:"type"` // direct or group
+	Members       []primitive.ObjectID `bson:"members" json:"members"`
+	Name          string               `bson:"name,omitempty" json:"name,omitempty"`
+	Avatar        string               `bson:"avatar,omitempty" json:"avatar,omitempty"`
+	Status        ConversationStatus   `bson:"status,omitempty" json:"status,omitempty"`
+	RequestedBy   *primitive.ObjectID  `bson:"requested_by,omitempty" json:"requested_by,omitempty"` // sender of a message request
+	RequestedTo   *primitive.ObjectID  `bson:"requested_to,omitempty" json:"requested_to,omitempty"` // recipient who has to accept the request
+	CreatedBy     primitive.ObjectID   `bson:"created_by" json:"created_by"`
+	CreatedAt     time.Time            `bson:"created_at" json:"created_at"`
+	LastMessageAt *time.Time           `bson:"last_message_at,omitempty" json:"last_message_at,omitempty"`
 }
+
+const (
+	ConversationTypeDirect = "direct"
+	ConversationTypeGroup  = "group"
+)
+
+type ConversationStatus string
+
+const (
+	ConversationStatusActive   ConversationStatus = "active"
+	ConversationStatusRequest  ConversationStatus = "request"  // first contact from a stranger, waiting in the recipient's requests inbox
+	ConversationStatusDeclined ConversationStatus = "declined" // recipient declined the request
+)
@@ -0,0 +1,9 @@
+package model
+
+import "time"
+
+// Migration records a one-time data migration that has run, so it is never run again
+type Migration struct {
+	Name      string    `bson:"_id" json:"name"`
+	AppliedAt time.Time `bson:"applied_at" json:"applied_at"`
+}
@@ -0,0 +1,82 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ModLog is an append-only record of a moderator or admin action
+type ModLog struct {
+	ID              primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	CommunityID     *primitive.ObjectID `bson:"community_id,omitempty" json:"community_id,omitempty"` // nil for site-wide admin actions
+	ActorID         primitive.ObjectID  `bson:"actor_id" json:"actor_id"`
+	ActorRole       ModLogActorRole     `bson:"actor_role" json:"actor_role"`
+	Action          ModAction           `bson:"action" json:"action"`
+	TargetType      ModTargetType       `bson:"target_type" json:"target_type"`
+	TargetID        primitive.ObjectID  `bson:"target_id" json:"target_id"`
+	AffectedUserID  *primitive.ObjectID `bson:"affected_user_id,omitempty" json:"affected_user_id,omitempty"` // user the action was taken against, who may appeal it
+	Reason          string              `bson:"reason,omitempty" json:"reason,omitempty"`
+	RemovalReasonID *primitive.ObjectID `bson:"removal_reason_id,omitempty" json:"removal_reason_id,omitempty"` // template the reason came from
+	Before          interface{}         `bson:"before,omitempty" json:"before,omitempty"`                       // snapshot of the target before the action
+	After           interface{}         `bson:"after,omitempty" json:"after,omitempty"`                         // snapshot of the target after the action
+	CreatedAt       time.Time           `bson:"created_at" json:"created_at"`
+}
+
+type ModLogActorRole string
+
+const (
+	ModLogActorModerator ModLogActorRole = "moderator"
+	ModLogActorAdmin     ModLogActorRole = "admin"
+	ModLogActorAutoMod   ModLogActorRole = "automod"
+)
+
+type ModAction string
+
+const (
+	ModActionUpdateCommunity   ModAction = "update_community"
+	ModActionUpdateAutoMod     ModAction = "update_automod"
+	ModActionUpdateFilters     ModAction = "update_content_filter"
+	ModActionDeleteCommunity   ModAction = "delete_community"
+	ModActionAddModerator      ModAction = "add_moderator"
+	ModActionInviteModerator   ModAction = "invite_moderator"
+	ModActionCancelInvite      ModAction = "cancel_moderator_invite"
+	ModActionRemoveModerator   ModAction = "remove_moderator"
+	ModActionUpdateModerator   ModAction = "update_moderator"
+	ModActionOfferOwnership    ModAction = "offer_ownership"
+	ModActionCancelOwnership   ModAction = "cancel_ownership_transfer"
+	ModActionTransferOwnership ModAction = "transfer_ownership"
+	ModActionUpdateReport      ModAction = "update_report"
+	ModActionBanUser           ModAction = "ban_user"
+	ModActionUnbanUser         ModAction = "unban_user"
+	ModActionMuteUser          ModAction = "mute_user"
+	ModActionUnmuteUser        ModAction = "unmute_user"
+	ModActionSuspendUser       ModAction = "suspend_user"
+	ModActionUnsuspendUser     ModAction = "unsuspend_user"
+	ModActionChangeRole        ModAction = "change_role"
+	ModActionUpdatePermissions ModAction = "update_permissions"
+	ModActionResetPassword     ModAction = "reset_password"
+	ModActionRestoreUser       ModAction = "restore_user"
+	ModActionUnlockUser        ModAction = "unlock_user"
+	ModActionSetBot            ModAction = "set_bot"
+	ModActionBanCommunity      ModAction = "ban_community"
+	ModActionUnbanCommunity    ModAction = "unban_community"
+	ModActionAutoMod           ModAction = "automod"
+	ModActionRemovePost        ModAction = "remove_post"
+	ModActionRestorePost       ModAction = "restore_post"
+	ModActionRemoveComment     ModAction = "remove_comment"
+	ModActionRestoreComment    ModAction = "restore_comment"
+	ModActionUpdateReasons     ModAction = "update_removal_reasons"
+	ModActionReviewAppeal      ModAction = "review_appeal"
+)
+
+type ModTargetType string
+
+const (
+	ModTargetCommunity ModTargetType = "community"
+	ModTargetUser      ModTargetType = "user"
+	ModTargetPost      ModTargetType = "post"
+	ModTargetComment   ModTargetType = "comment"
+	ModTargetReport    ModTargetType = "report"
+	ModTargetAppeal    ModTargetType = "appeal"
+)
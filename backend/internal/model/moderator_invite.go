@@ -0,0 +1,31 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ModeratorInvite offers a user a moderator seat. The user only becomes a moderator once they accept.
+type ModeratorInvite struct {
+	ID            primitive.ObjectID    `bson:"_id,omitempty" json:"id"`
+	CommunityID   primitive.ObjectID    `bson:"community_id" json:"community_id"`
+	CommunityName string                `bson:"community_name" json:"community_name"`
+	InviteeID     primitive.ObjectID    `bson:"invitee_id" json:"invitee_id"`
+	InviteeName   string                `bson:"invitee_name" json:"invitee_name"`
+	InvitedBy     primitive.ObjectID    `bson:"invited_by" json:"invited_by"`
+	Permissions   []ModPermission       `bson:"permissions,omitempty" json:"permissions,omitempty"` // empty means full permissions
+	Status        ModeratorInviteStatus `bson:"status" json:"status"`
+	CreatedAt     time.Time             `bson:"created_at" json:"created_at"`
+	ExpiresAt     time.Time             `bson:"expires_at" json:"expires_at"`
+	RespondedAt   *time.Time            `bson:"responded_at,omitempty" json:"responded_at,omitempty"`
+}
+
+type ModeratorInviteStatus string
+
+const (
+	ModeratorInviteStatusPending   ModeratorInviteStatus = "pending"
+	ModeratorInviteStatusAccepted  ModeratorInviteStatus = "accepted"
+	ModeratorInviteStatusDeclined  ModeratorInviteStatus = "declined"
+	ModeratorInviteStatusCancelled ModeratorInviteStatus = "cancelled"
+)
@@ -0,0 +1,47 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ModmailThread is a conversation between one user and the whole mod team of a community.
+// The team owns its side of the thread, so any moderator with the mail permission can read and answer it.
+type ModmailThread struct {
+	ID            primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	CommunityID   primitive.ObjectID  `bson:"community_id" json:"community_id"`
+	CommunityName string              `bson:"community_name" json:"community_name"`
+	UserID        primitive.ObjectID  `bson:"user_id" json:"user_id"` // the user who opened the thread
+	Username      string              `bson:"username" json:"username"`
+	Subject       string              `bson:"subject" json:"subject"`
+	Status        ModmailThreadStatus `bson:"status" json:"status"`
+	IsHighlighted bool                `bson:"is_highlighted" json:"is_highlighted"`
+	CreatedAt     time.Time           `bson:"created_at" json:"created_at"`
+	LastMessageAt time.Time           `bson:"last_message_at" json:"last_message_at"`
+	// Which side spoke last, so the team inbox can tell threads waiting for an answer from answered ones
+	LastReplyByTeam bool `bson:"last_reply_by_team" json:"last_reply_by_team"`
+}
+
+type ModmailThreadStatus string
+
+const (
+	ModmailThreadStatusOpen     ModmailThreadStatus = "open"
+	ModmailThreadStatusArchived ModmailThreadStatus = "archived"
+)
+
+func IsValidModmailThreadStatus(status ModmailThreadStatus) bool {
+	return status == ModmailThreadStatusOpen || status == ModmailThreadStatusArchived
+}
+
+type ModmailMessage struct {
+	ID         primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	ThreadID   primitive.ObjectID  `bson:"thread_id" json:"thread_id"`
+	AuthorID   *primitive.ObjectID `bson:"author_id,omitempty" json:"author_id,omitempty"`
+	AuthorName string              `bson:"author_name,omitempty" json:"author_name,omitempty"`
+	IsFromTeam bool                `bson:"is_from_team" json:"is_from_team"` // written by a moderator
+	AsTeam     bool                `bson:"as_team" json:"as_team"`           // the user sees the community's mod team, not the moderator
+	IsInternal bool                `bson:"is_internal" json:"is_internal"`   // note between moderators, never shown to the user
+	Content    string              `bson:"content" json:"content"`
+	CreatedAt  time.Time           `bson:"created_at" json:"created_at"`
+}
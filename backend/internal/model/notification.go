@@ -18,9 +18,14 @@ type Notification struct {
 type NotificationType string
 
 const (
-	NotificationTypeComment NotificationType = "comment"
-	NotificationTypeLike    NotificationType = "like"
-	NotificationTypeFollow  NotificationType = "follow"
-	NotificationTypeMention NotificationType = "mention"
-	NotificationTypeSystem  NotificationType = "system"
+	NotificationTypeComment   NotificationType = "comment"
+	NotificationTypeLike      NotificationType = "like"
+	NotificationTypeFollow    NotificationType = "follow"
+	NotificationTypeMention   NotificationType = "mention"
+	NotificationTypeSystem    NotificationType = "system"
+	NotificationTypeReport    NotificationType = "report"
+	NotificationTypeBan       NotificationType = "ban"
+	NotificationTypeModerator NotificationType = "moderator"
+	NotificationTypeModmail   NotificationType = "modmail"
+	NotificationTypeAppeal    NotificationType = "appeal"
 )
@@ -0,0 +1,92 @@
+package model
+
+// Permission is a single admin capability. Admin endpoints declare the permissions they need
+// and the access token carries the permissions granted to the admin.
+type Permission string
+
+const (
+	PermissionUsersView          Permission = "users.view"
+	PermissionUsersBan           Permission = "users.ban"
+	PermissionUsersResetPassword Permission = "users.reset_password"
+	PermissionUsersRestore       Permission = "users.restore"
+	PermissionCommunitiesBan     Permission = "communities.ban"
+	PermissionReportsView        Permission = "reports.view"
+	PermissionReportsResolve     Permission = "reports.resolve"
+	PermissionAuditView          Permission = "audit.view"
+	PermissionAdminsManage       Permission = "admins.manage"
+)
+
+// Permissions is the full catalog of admin permissions
+var Permissions = []Permission{
+	PermissionUsersView,
+	PermissionUsersBan,
+	PermissionUsersResetPassword,
+	PermissionUsersRestore,
+	PermissionCommunitiesBan,
+	PermissionReportsView,
+	PermissionReportsResolve,
+	PermissionAuditView,
+	PermissionAdminsManage,
+}
+
+const (
+	AdminTemplateSuperAdmin    = "super_admin"
+	AdminTemplateTrustSafety   = "trust_safety"
+	AdminTemplateSupport       = "support"
+	AdminTemplateCommunityTeam = "community_team"
+)
+
+// AdminRoleTemplates are the predefined permission sets an admin can be given by name.
+// Extra permissions can be granted on top of a template.
+var AdminRoleTemplates = map[string][]Permission{
+	AdminTemplateSuperAdmin: Permissions,
+	AdminTemplateTrustSafety: {
+		PermissionUsersView,
+		PermissionUsersBan,
+		PermissionCommunitiesBan,
+		PermissionReportsView,
+		PermissionReportsResolve,
+		PermissionAuditView,
+	},
+	AdminTemplateSupport: {
+		PermissionUsersView,
+		PermissionUsersResetPassword,
+		PermissionUsersRestore,
+	},
+	AdminTemplateCommunityTeam: {
+		PermissionUsersView,
+		PermissionCommunitiesBan,
+		PermissionReportsView,
+	},
+}
+
+// IsValidPermission checks the permission against the catalog
+func IsValidPermission(permission Permission) bool {
+	for _, p := range Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// EffectivePermissions returns the permissions of the admin: the template's permissions plus any
+// granted individually. An admin with neither has no permissions; admins promoted before
+// permissions existed are given a template by a startup migration.
+func (c *AdminRoleContent) EffectivePermissions() []Permission {
+	if c == nil {
+		return nil
+	}
+
+	seen := make(map[Permission]bool)
+	var permissions []Permission
+	for _, list := range [][]Permission{AdminRoleTemplates[c.Name], c.Permissions} {
+		for _, p := range list {
+			if !seen[p] {
+				seen[p] = true
+				permissions = append(permissions, p)
+			}
+		}
+	}
+	return permissions
+}
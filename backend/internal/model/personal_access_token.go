@@ -0,0 +1,51 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PersonalAccessToken lets scripts and bots call the API as the user without a password login.
+// Only the SHA-256 of the token is stored; the token itself is shown once, when it is created.
+type PersonalAccessToken struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID     primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Name       string             `bson:"name" json:"name"`
+	Prefix     string             `bson:"prefix" json:"prefix"` // first characters of the token, to tell tokens apart
+	TokenHash  string             `bson:"token_hash" json:"-"`
+	Scopes     []TokenScope       `bson:"scopes" json:"scopes"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+	ExpiresAt  time.Time          `bson:"expires_at" json:"expires_at"`
+	LastUsedAt *time.Time         `bson:"last_used_at,omitempty" json:"last_used_at,omitempty"`
+}
+
+// IsExpired reports whether the token can no longer be used
+func (t *PersonalAccessToken) IsExpired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
+
+// TokenScope is what a personal access token may be used for
+type TokenScope string
+
+const (
+	TokenScopeRead     TokenScope = "read"     // GET requests
+	TokenScopePost     TokenScope = "post"     // requests that change something
+	TokenScopeModerate TokenScope = "moderate" // moderator tools, on top of read or post for the request itself
+)
+
+// TokenScopes is the full catalog of token scopes
+var TokenScopes = []TokenScope{
+	TokenScopeRead,
+	TokenScopePost,
+	TokenScopeModerate,
+}
+
+func IsValidTokenScope(scope TokenScope) bool {
+	for _, s := range TokenScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -7,20 +7,34 @@ import (
 )
 
 type Post struct {
-	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	AuthorID       primitive.ObjectID `bson:"author_id" json:"author_id"`
-	AuthorUsername string             `bson:"author_username,omitempty" json:"author_username,omitempty"`
-	AuthorAvatar   string             `bson:"author_avatar,omitempty" json:"author_avatar,omitempty"`
-	CommunityID    primitive.ObjectID `bson:"community_id" json:"community_id"`
-	CommunityName  string             `bson:"community_name,omitempty" json:"community_name,omitempty"`
-	Type           PostType           `bson:"type" json:"type"`
-	Content        *PostContent       `bson:"content,omitempty" json:"content,omitempty"`
-	VotesCount     *VotesCount        `bson:"votes_count" json:"votes_count"`
-	CreatedAt      time.Time          `bson:"created_at,omitempty" json:"created_at,omitempty"`
-	UpdatedAt      *time.Time         `bson:"updated_at,omitempty" json:"updated_at,omitempty"`
-	IsDeleted      bool               `bson:"is_deleted,omitempty" json:"is_deleted,omitempty"`
+	ID               primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	AuthorID         primitive.ObjectID `bson:"author_id" json:"author_id"`
+	AuthorUsername   string             `bson:"author_username,omitempty" json:"author_username,omitempty"`
+	AuthorAvatar     string             `bson:"author_avatar,omitempty" json:"author_avatar,omitempty"`
+	AuthorIsBot      bool               `bson:"author_is_bot,omitempty" json:"author_is_bot,omitempty"`
+	CommunityID      primitive.ObjectID `bson:"community_id" json:"community_id"`
+	CommunityName    string             `bson:"community_name,omitempty" json:"community_name,omitempty"`
+	Title            string             `bson:"title,omitempty" json:"title,omitempty"`
+	Type             PostType           `bson:"type" json:"type"`
+	Flair            string             `bson:"flair,omitempty" json:"flair,omitempty"`
+	Content          *PostContent       `bson:"content,omitempty" json:"content,omitempty"`
+	VotesCount       *VotesCount        `bson:"votes_count" json:"votes_count"`
+	CreatedAt        time.Time          `bson:"created_at,omitempty" json:"created_at,omitempty"`
+	UpdatedAt        *time.Time         `bson:"updated_at,omitempty" json:"updated_at,omitempty"`
+	IsDeleted        bool               `bson:"is_deleted,omitempty" json:"is_deleted,omitempty"`
+	IsLocked         bool               `bson:"is_locked,omitempty" json:"is_locked,omitempty"` // no new comments
+	ModerationStatus ModerationStatus   `bson:"moderation_status,omitempty" json:"moderation_status,omitempty"`
 }
 
+// ModerationStatus tells whether content is visible; empty means approved
+type ModerationStatus string
+
+const (
+	ModerationStatusApproved ModerationStatus = "approved"
+	ModerationStatusFiltered ModerationStatus = "filtered" // held in the mod queue until a moderator approves it
+	ModerationStatusRemoved  ModerationStatus = "removed"
+)
+
 type PostType string
 
 const (
@@ -0,0 +1,19 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RemovalReason is a reason template from a community's library. Moderators pick one when they remove
+// content or ban a user, so the affected user gets the same structured explanation every time.
+type RemovalReason struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	CommunityID primitive.ObjectID `bson:"community_id" json:"community_id"`
+	Title       string             `bson:"title" json:"title"`
+	Message     string             `bson:"message" json:"message"` // shown to the affected user
+	CreatedBy   primitive.ObjectID `bson:"created_by" json:"created_by"`
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt   *time.Time         `bson:"updated_at,omitempty" json:"updated_at,omitempty"`
+}
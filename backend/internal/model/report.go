@@ -7,13 +7,28 @@ import (
 )
 
 type Report struct {
-	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	ReporterID  primitive.ObjectID `bson:"reporter_id,omitempty" json:"reporter_id,omitempty"`
-	TargetID    primitive.ObjectID `bson:"target_id,omitempty" json:"target_id,omitempty"`
-	TargetType  ReportTargetType   `bson:"target_type" json:"target_type"`
-	Reason      string             `bson:"reason,omitempty" json:"reason,omitempty"`
+	ID             primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	ReporterID     primitive.ObjectID  `bson:"reporter_id,omitempty" json:"reporter_id,omitempty"` // first reporter of the target
+	TargetID       primitive.ObjectID  `bson:"target_id,omitempty" json:"target_id,omitempty"`
+	TargetType     ReportTargetType    `bson:"target_type" json:"target_type"`
+	CommunityID    *primitive.ObjectID `bson:"community_id,omitempty" json:"community_id,omitempty"` // set for community content, nil for user reports
+	Reason         ReportReason        `bson:"reason,omitempty" json:"reason,omitempty"`
+	Description    *string             `bson:"description,omitempty" json:"description,omitempty"`
+	Status         ReportStatus        `bson:"status,omitempty" json:"status,omitempty"`
+	Entries        []ReportEntry       `bson:"entries,omitempty" json:"entries,omitempty"` // every report merged into this one
+	ReportCount    int                 `bson:"report_count,omitempty" json:"report_count,omitempty"`
+	ResolvedBy     *primitive.ObjectID `bson:"resolved_by,omitempty" json:"resolved_by,omitempty"`
+	ResolutionNote string              `bson:"resolution_note,omitempty" json:"resolution_note,omitempty"`
+	ResolvedAt     *time.Time          `bson:"resolved_at,omitempty" json:"resolved_at,omitempty"`
+	CreatedAt      time.Time           `bson:"created_at,omitempty" json:"created_at,omitempty"`
+	UpdatedAt      *time.Time          `bson:"updated_at,omitempty" json:"updated_at,omitempty"`
+}
+
+type ReportEntry struct {
+	ReporterID  primitive.ObjectID `bson:"reporter_id" json:"reporter_id"`
+	Reason      ReportReason       `bson:"reason" json:"reason"`
 	Description *string            `bson:"description,omitempty" json:"description,omitempty"`
-	CreatedAt   time.Time          `bson:"created_at,omitempty" json:"created_at,omitempty"`
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
 }
 
 type ReportTargetType string
@@ -23,3 +38,39 @@ const (
 	ReportTypePost    ReportTargetType = "post"
 	ReportTypeComment ReportTargetType = "comment"
 )
+
+type ReportReason string
+
+const (
+	ReportReasonSpam           ReportReason = "spam"
+	ReportReasonHarassment     ReportReason = "harassment"
+	ReportReasonHateSpeech     ReportReason = "hate_speech"
+	ReportReasonViolence       ReportReason = "violence"
+	ReportReasonSexualContent  ReportReason = "sexual_content"
+	ReportReasonMisinformation ReportReason = "misinformation"
+	ReportReasonSelfHarm       ReportReason = "self_harm"
+	ReportReasonImpersonation  ReportReason = "impersonation"
+	ReportReasonOther          ReportReason = "other"
+)
+
+// ReportReasons is the fixed catalog users can pick from when filing a report
+var ReportReasons = []ReportReason{
+	ReportReasonSpam,
+	ReportReasonHarassment,
+	ReportReasonHateSpeech,
+	ReportReasonViolence,
+	ReportReasonSexualContent,
+	ReportReasonMisinformation,
+	ReportReasonSelfHarm,
+	ReportReasonImpersonation,
+	ReportReasonOther,
+}
+
+type ReportStatus string
+
+const (
+	ReportStatusOpen      ReportStatus = "open"
+	ReportStatusInReview  ReportStatus = "in_review"
+	ReportStatusActioned  ReportStatus = "actioned"
+	ReportStatusDismissed ReportStatus = "dismissed"
+)
@@ -0,0 +1,27 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SecurityEvent is an append-only record of something suspicious happening to an account
+type SecurityEvent struct {
+	ID        primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	UserID    primitive.ObjectID  `bson:"user_id" json:"user_id"`
+	Type      SecurityEventType   `bson:"type" json:"type"`
+	SessionID *primitive.ObjectID `bson:"session_id,omitempty" json:"session_id,omitempty"`
+	IP        string              `bson:"ip,omitempty" json:"ip,omitempty"`
+	UserAgent string              `bson:"user_agent,omitempty" json:"user_agent,omitempty"`
+	Details   string              `bson:"details,omitempty" json:"details,omitempty"`
+	CreatedAt time.Time           `bson:"created_at" json:"created_at"`
+}
+
+type SecurityEventType string
+
+const (
+	SecurityEventRefreshTokenReuse SecurityEventType = "refresh_token_reuse"
+	SecurityEventAccountLocked     SecurityEventType = "account_locked"
+	SecurityEventAccountUnlocked   SecurityEventType = "account_unlocked"
+)
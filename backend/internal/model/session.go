@@ -0,0 +1,28 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Session is one signed in device. It lives as long as its refresh tokens keep being used and its ID
+// travels in every token as the sid claim, so a single device can be signed out.
+type Session struct {
+	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID          primitive.ObjectID `bson:"user_id" json:"user_id"`
+	RefreshTokenID  string             `bson:"refresh_token_id" json:"-"` // jti of the refresh token issued last
+	RefreshIssuedAt time.Time          `bson:"refresh_issued_at" json:"-"`
+	Device          string             `bson:"device" json:"device"`
+	IP              string             `bson:"ip" json:"ip"`
+	UserAgent       string             `bson:"user_agent" json:"user_agent"`
+	CreatedAt       time.Time          `bson:"created_at" json:"created_at"`
+	LastUsedAt      time.Time          `bson:"last_used_at" json:"last_used_at"`
+	ExpiresAt       time.Time          `bson:"expires_at" json:"expires_at"`
+	RevokedAt       *time.Time         `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"`
+}
+
+// IsActive reports whether the session can still be refreshed
+func (s *Session) IsActive(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
@@ -0,0 +1,21 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SigningKey is a key pair used to sign access tokens. A key signs between ActivatesAt and
+// RetiresAt, and its public half stays published until ExpiresAt so the tokens it signed keep
+// verifying until they expire.
+type SigningKey struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	KeyID       string             `bson:"kid" json:"kid"`
+	Algorithm   string             `bson:"alg" json:"alg"`
+	PrivateKey  string             `bson:"private_key" json:"-"` // PKCS #8 PEM sealed with AES-GCM under JWT_KEY_ENCRYPTION_KEY, base64 encoded
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+	ActivatesAt time.Time          `bson:"activates_at" json:"activates_at"`
+	RetiresAt   time.Time          `bson:"retires_at" json:"retires_at"`
+	ExpiresAt   time.Time          `bson:"expires_at" json:"expires_at"`
+}
@@ -1,20 +1,25 @@
 package model
 
 import (
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type User struct {
-	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	Username    string             `bson:"username" json:"username"`
-	Email       string             `bson:"email,omitempty" json:"email,omitempty"`
-	Password    string             `bson:"password" json:"password"`
-	Role        Role               `bson:"role" json:"role"`
-	RoleContent RoleContent        `bson:"role_content,omitempty" json:"role_content,omitempty"`
-	CreateAt    time.Time          `bson:"create_at,omitempty" json:"create_at,omitempty"`
-	DeletedAt   *time.Time         `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Username        string             `bson:"username" json:"username"`
+	Email           string             `bson:"email,omitempty" json:"email,omitempty"`
+	VerifiedEmail   string             `bson:"verified_email,omitempty" json:"verified_email,omitempty"` // the address EmailVerifiedAt applies to
+	EmailVerifiedAt *time.Time         `bson:"email_verified_at,omitempty" json:"email_verified_at,omitempty"`
+	Password        string             `bson:"password" json:"password"`
+	Role            Role               `bson:"role" json:"role"`
+	RoleContent     RoleContent        `bson:"role_content,omitempty" json:"role_content,omitempty"`
+	TwoFactor       *TwoFactor         `bson:"two_factor,omitempty" json:"two_factor,omitempty"`
+	IsBot           bool               `bson:"is_bot,omitempty" json:"is_bot,omitempty"` // automated account, badged on its content
+	CreateAt        time.Time          `bson:"create_at,omitempty" json:"create_at,omitempty"`
+	DeletedAt       *time.Time         `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
 }
 
 type Role string
@@ -30,17 +35,67 @@ type RoleContent struct {
 }
 
 type UserRoleContent struct {
-	Avatar   string `bson:"avatar,omitempty" json:"avatar,omitempty"`
-	Cover    string `bson:"cover,omitempty" json:"cover,omitempty"`
-	BanStart string `bson:"ban_start,omitempty" json:"ban_start,omitempty"`
-	BanEnd   string `bson:"ban_end,omitempty" json:"ban_end,omitempty"`
+	Avatar    string     `bson:"avatar,omitempty" json:"avatar,omitempty"`
+	Cover     string     `bson:"cover,omitempty" json:"cover,omitempty"`
+	BanStart  *time.Time `bson:"ban_start,omitempty" json:"ban_start,omitempty"`
+	BanEnd    *time.Time `bson:"ban_end,omitempty" json:"ban_end,omitempty"` // nil with BanStart set means permanent
+	BanReason string     `bson:"ban_reason,omitempty" json:"ban_reason,omitempty"`
 }
 
 type AdminRoleContent struct {
-	Name        string             `bson:"name,omitempty" json:"name,omitempty"`
-	Permissions []string           `bson:"permissions,omitempty" json:"permissions,omitempty"` //Chua quyet dinh se lam sao
+	Name        string             `bson:"name,omitempty" json:"name,omitempty"`               // role template, see AdminRoleTemplates
+	Permissions []Permission       `bson:"permissions,omitempty" json:"permissions,omitempty"` // granted on top of the template
 	CreateAt    *time.Time         `bson:"update_at,omitempty" json:"update_at,omitempty"`
 	CreateBy    primitive.ObjectID `bson:"create_by,omitempty" json:"create_by,omitempty"`
+
+	RequireTwoFactor bool `bson:"require_two_factor,omitempty" json:"require_two_factor,omitempty"` // admin tools stay locked until 2FA is on
+}
+
+// TwoFactor holds the user's TOTP setup. Secrets and recovery codes never leave the server.
+type TwoFactor struct {
+	Enabled       bool       `bson:"enabled" json:"enabled"`
+	Secret        string     `bson:"secret,omitempty" json:"-"`
+	PendingSecret string     `bson:"pending_secret,omitempty" json:"-"` // enrolled but not confirmed with a code yet
+	RecoveryCodes []string   `bson:"recovery_codes,omitempty" json:"-"` // SHA-256 hashes, removed once used
+	LastUsedStep  int64      `bson:"last_used_step,omitempty" json:"-"` // TOTP time step of the last accepted code
+	EnabledAt     *time.Time `bson:"enabled_at,omitempty" json:"enabled_at,omitempty"`
+}
+
+// IsSuspended reports whether a site-wide suspension is in effect at the given time.
+// Suspensions lift on their own once BanEnd has passed.
+func (u *User) IsSuspended(now time.Time) bool {
+	content := u.RoleContent.User
+	if content == nil || content.BanStart == nil {
+		return false
+	}
+	return content.BanEnd == nil || now.Before(*content.BanEnd)
+}
+
+// IsEmailVerified reports whether the user confirmed their current email address.
+// Changing the address takes the verification away; admins are trusted without one.
+func (u *User) IsEmailVerified() bool {
+	if u.Role == AdminRole {
+		return true
+	}
+	return u.EmailVerifiedAt != nil && strings.EqualFold(u.VerifiedEmail, u.Email)
+}
+
+// TwoFactorEnabled reports whether logging in takes a second factor
+func (u *User) TwoFactorEnabled() bool {
+	return u.TwoFactor != nil && u.TwoFactor.Enabled
+}
+
+// TwoFactorRequired reports whether the user's admin role demands two-factor authentication
+func (u *User) TwoFactorRequired() bool {
+	return u.Role == AdminRole && u.RoleContent.Admin != nil && u.RoleContent.Admin.RequireTwoFactor
+}
+
+// AdminPermissions returns the permissions granted to the user, none for regular users
+func (u *User) AdminPermissions() []Permission {
+	if u.Role != AdminRole {
+		return nil
+	}
+	return u.RoleContent.Admin.EffectivePermissions()
 }
 
 type UserStat struct {
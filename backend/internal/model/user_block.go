@@ -0,0 +1,14 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type UserBlock struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID        primitive.ObjectID `bson:"user_id" json:"user_id"`
+	BlockedUserID primitive.ObjectID `bson:"blocked_user_id" json:"blocked_user_id"`
+	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`
+}
@@ -0,0 +1,169 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/giakiet05/lkforum/internal/config"
+	"github.com/giakiet05/lkforum/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AppealFilter narrows down a review queue; zero values are ignored
+type AppealFilter struct {
+	CommunityID  string
+	Status       model.AppealStatus
+	ExcludeActor string // leave out appeals of actions taken by this user, they cannot review them
+}
+
+type AppealRepo interface {
+	Create(ctx context.Context, appeal *model.Appeal) (*model.Appeal, error)
+	GetByID(ctx context.Context, id string) (*model.Appeal, error)
+	ExistsForModLog(ctx context.Context, modLogID primitive.ObjectID) (bool, error)
+	GetByUserPaginated(ctx context.Context, userID string, page int, pageSize int) ([]model.Appeal, int64, error)
+	GetPaginated(ctx context.Context, filter AppealFilter, page int, pageSize int) ([]model.Appeal, int64, error)
+	Resolve(ctx context.Context, id primitive.ObjectID, status model.AppealStatus, reviewerID primitive.ObjectID, note string) (*model.Appeal, error)
+	Reopen(ctx context.Context, id primitive.ObjectID, status model.AppealStatus) error
+}
+
+type appealRepo struct {
+	appealCollection *mongo.Collection
+}
+
+func NewAppealRepo(db *mongo.Database) AppealRepo {
+	// Decode action snapshots as plain documents, like the mod log they are copied from
+	opts := options.Collection().SetBSONOptions(&options.BSONOptions{DefaultDocumentM: true})
+	return &appealRepo{appealCollection: db.Collection(config.AppealColName, opts)}
+}
+
+func (r *appealRepo) Create(ctx context.Context, appeal *model.Appeal) (*model.Appeal, error) {
+	result, err := r.appealCollection.InsertOne(ctx, appeal)
+	if err != nil {
+		return nil, err
+	}
+
+	if oid, ok := result.InsertedID.(primitive.ObjectID); ok {
+		appeal.ID = oid
+	}
+
+	return appeal, nil
+}
+
+func (r *appealRepo) GetByID(ctx context.Context, id string) (*model.Appeal, error) {
+	appealObjectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var appeal model.Appeal
+	if err := r.appealCollection.FindOne(ctx, bson.M{"_id": appealObjectID}).Decode(&appeal); err != nil {
+		return nil, err
+	}
+
+	return &appeal, nil
+}
+
+func (r *appealRepo) ExistsForModLog(ctx context.Context, modLogID primitive.ObjectID) (bool, error) {
+	count, err := r.appealCollection.CountDocuments(ctx, bson.M{"mod_log_id": modLogID})
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *appealRepo) GetByUserPaginated(ctx context.Context, userID string, page int, pageSize int) ([]model.Appeal, int64, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return r.findAppeals(ctx, bson.M{"user_id": userObjectID}, page, pageSize)
+}
+
+func (r *appealRepo) GetPaginated(ctx context.Context, filter AppealFilter, page int, pageSize int) ([]model.Appeal, int64, error) {
+	query := bson.M{}
+	if filter.CommunityID != "" {
+		communityObjectID, err := primitive.ObjectIDFromHex(filter.CommunityID)
+		if err != nil {
+			return nil, 0, err
+		}
+		query["community_id"] = communityObjectID
+	}
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+	if filter.ExcludeActor != "" {
+		actorObjectID, err := primitive.ObjectIDFromHex(filter.ExcludeActor)
+		if err != nil {
+			return nil, 0, err
+		}
+		query["acted_by"] = bson.M{"$ne": actorObjectID}
+	}
+
+	return r.findAppeals(ctx, query, page, pageSize)
+}
+
+func (r *appealRepo) findAppeals(ctx context.Context, filter bson.M, page int, pageSize int) ([]model.Appeal, int64, error) {
+	skip := (page - 1) * pageSize
+	opts := options.Find().SetSkip(int64(skip)).SetLimit(int64(pageSize)).SetSort(bson.M{"created_at": -1})
+
+	cursor, err := r.appealCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var appeals []model.Appeal
+	if err := cursor.All(ctx, &appeals); err != nil {
+		return nil, 0, err
+	}
+
+	count, err := r.appealCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return appeals, count, nil
+}
+
+// Resolve records the outcome of a pending appeal. It returns mongo.ErrNoDocuments if the appeal
+// was already reviewed, so two reviewers cannot both decide it.
+func (r *appealRepo) Resolve(ctx context.Context, id primitive.ObjectID, status model.AppealStatus, reviewerID primitive.ObjectID, note string) (*model.Appeal, error) {
+	filter := bson.M{"_id": id, "status": model.AppealStatusPending}
+	update := bson.M{"$set": bson.M{
+		"status":      status,
+		"reviewed_by": reviewerID,
+		"review_note": note,
+		"reviewed_at": time.Now(),
+	}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var appeal model.Appeal
+	if err := r.appealCollection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&appeal); err != nil {
+		return nil, err
+	}
+
+	return &appeal, nil
+}
+
+// Reopen puts an appeal decided with the given status back to pending, e.g. when the decision could
+// not be carried out
+func (r *appealRepo) Reopen(ctx context.Context, id primitive.ObjectID, status model.AppealStatus) error {
+	filter := bson.M{"_id": id, "status": status}
+	update := bson.M{
+		"$set":   bson.M{"status": model.AppealStatusPending},
+		"$unset": bson.M{"reviewed_by": "", "review_note": "", "reviewed_at": ""},
+	}
+
+	res, err := r.appealCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
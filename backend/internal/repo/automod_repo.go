@@ -0,0 +1,116 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/giakiet05/lkforum/internal/config"
+	"github.com/giakiet05/lkforum/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type AutoModRepo interface {
+	GetByCommunity(ctx context.Context, communityID string) (*model.AutoModConfig, error)
+	Upsert(ctx context.Context, autoModConfig *model.AutoModConfig) (*model.AutoModConfig, error)
+
+	GetPost(ctx context.Context, postID primitive.ObjectID) (*model.Post, error)
+	UpdatePost(ctx context.Context, postID primitive.ObjectID, updates bson.M) error
+	UpdateComment(ctx context.Context, commentID primitive.ObjectID, updates bson.M) error
+	CreateComment(ctx context.Context, comment *model.Comment) (*model.Comment, error)
+}
+
+type autoModRepo struct {
+	autoModCollection *mongo.Collection
+	postCollection    *mongo.Collection
+	commentCollection *mongo.Collection
+}
+
+func NewAutoModRepo(db *mongo.Database) AutoModRepo {
+	return &autoModRepo{
+		autoModCollection: db.Collection(config.AutoModColName),
+		postCollection:    db.Collection(config.PostColName),
+		commentCollection: db.Collection(config.CommentColName),
+	}
+}
+
+func (r *autoModRepo) GetByCommunity(ctx context.Context, communityID string) (*model.AutoModConfig, error) {
+	communityObjectID, err := primitive.ObjectIDFromHex(communityID)
+	if err != nil {
+		return nil, err
+	}
+
+	var autoModConfig model.AutoModConfig
+	err = r.autoModCollection.FindOne(ctx, bson.M{"community_id": communityObjectID}).Decode(&autoModConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return &autoModConfig, nil
+}
+
+// Upsert replaces the rules of the community, creating its config on first save
+func (r *autoModRepo) Upsert(ctx context.Context, autoModConfig *model.AutoModConfig) (*model.AutoModConfig, error) {
+	filter := bson.M{"community_id": autoModConfig.CommunityID}
+	update := bson.M{"$set": bson.M{
+		"rules":      autoModConfig.Rules,
+		"updated_by": autoModConfig.UpdatedBy,
+		"updated_at": autoModConfig.UpdatedAt,
+	}}
+
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var saved model.AutoModConfig
+	err := r.autoModCollection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&saved)
+	if err != nil {
+		return nil, err
+	}
+
+	return &saved, nil
+}
+
+func (r *autoModRepo) GetPost(ctx context.Context, postID primitive.ObjectID) (*model.Post, error) {
+	var post model.Post
+	err := r.postCollection.FindOne(ctx, bson.M{"_id": postID, "is_deleted": bson.M{"$ne": true}}).Decode(&post)
+	if err != nil {
+		return nil, err
+	}
+
+	return &post, nil
+}
+
+func (r *autoModRepo) UpdatePost(ctx context.Context, postID primitive.ObjectID, updates bson.M) error {
+	result, err := r.postCollection.UpdateOne(ctx, bson.M{"_id": postID}, bson.M{"$set": updates})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *autoModRepo) UpdateComment(ctx context.Context, commentID primitive.ObjectID, updates bson.M) error {
+	result, err := r.commentCollection.UpdateOne(ctx, bson.M{"_id": commentID}, bson.M{"$set": updates})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *autoModRepo) CreateComment(ctx context.Context, comment *model.Comment) (*model.Comment, error) {
+	result, err := r.commentCollection.InsertOne(ctx, comment)
+	if err != nil {
+		return nil, err
+	}
+
+	if oid, ok := result.InsertedID.(primitive.ObjectID); ok {
+		comment.ID = oid
+	}
+
+	return comment, nil
+}
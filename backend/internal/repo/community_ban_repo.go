@@ -0,0 +1,153 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/giakiet05/lkforum/internal/config"
+	"github.com/giakiet05/lkforum/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CommunityBanRepo interface {
+	Upsert(ctx context.Context, ban *model.CommunityBan) (*model.CommunityBan, error)
+	GetActive(ctx context.Context, communityID string, userID string) ([]model.CommunityBan, error)
+	GetActivePaginated(ctx context.Context, communityID string, banType model.CommunityBanType, page int, pageSize int) ([]model.CommunityBan, int64, error)
+	Delete(ctx context.Context, communityID string, userID string, banType model.CommunityBanType) (*model.CommunityBan, error)
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+type communityBanRepo struct {
+	communityBanCollection *mongo.Collection
+}
+
+func NewCommunityBanRepo(db *mongo.Database) CommunityBanRepo {
+	return &communityBanRepo{communityBanCollection: db.Collection(config.CommunityBanColName)}
+}
+
+// activeBanFilter matches bans that are permanent or have not expired yet
+func activeBanFilter(now time.Time) bson.M {
+	return bson.M{"$or": []bson.M{
+		{"expires_at": bson.M{"$exists": false}},
+		{"expires_at": bson.M{"$gt": now}},
+	}}
+}
+
+// Upsert stores the ban, replacing any earlier ban of the same type on the same user
+func (r *communityBanRepo) Upsert(ctx context.Context, ban *model.CommunityBan) (*model.CommunityBan, error) {
+	filter := bson.M{
+		"community_id": ban.CommunityID,
+		"user_id":      ban.UserID,
+		"type":         ban.Type,
+	}
+
+	opts := options.FindOneAndReplace().SetUpsert(true).SetReturnDocument(options.After)
+
+	var saved model.CommunityBan
+	err := r.communityBanCollection.FindOneAndReplace(ctx, filter, ban, opts).Decode(&saved)
+	if err != nil {
+		return nil, err
+	}
+
+	return &saved, nil
+}
+
+func (r *communityBanRepo) GetActive(ctx context.Context, communityID string, userID string) ([]model.CommunityBan, error) {
+	communityObjectID, err := primitive.ObjectIDFromHex(communityID)
+	if err != nil {
+		return nil, err
+	}
+
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := activeBanFilter(time.Now())
+	filter["community_id"] = communityObjectID
+	filter["user_id"] = userObjectID
+
+	cursor, err := r.communityBanCollection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var bans []model.CommunityBan
+	if err := cursor.All(ctx, &bans); err != nil {
+		return nil, err
+	}
+
+	return bans, nil
+}
+
+func (r *communityBanRepo) GetActivePaginated(ctx context.Context, communityID string, banType model.CommunityBanType, page int, pageSize int) ([]model.CommunityBan, int64, error) {
+	communityObjectID, err := primitive.ObjectIDFromHex(communityID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	filter := activeBanFilter(time.Now())
+	filter["community_id"] = communityObjectID
+	if banType != "" {
+		filter["type"] = banType
+	}
+
+	skip := (page - 1) * pageSize
+	opts := options.Find().SetSkip(int64(skip)).SetLimit(int64(pageSize)).SetSort(bson.M{"created_at": -1})
+
+	cursor, err := r.communityBanCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var bans []model.CommunityBan
+	if err := cursor.All(ctx, &bans); err != nil {
+		return nil, 0, err
+	}
+
+	count, err := r.communityBanCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return bans, count, nil
+}
+
+func (r *communityBanRepo) Delete(ctx context.Context, communityID string, userID string, banType model.CommunityBanType) (*model.CommunityBan, error) {
+	communityObjectID, err := primitive.ObjectIDFromHex(communityID)
+	if err != nil {
+		return nil, err
+	}
+
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := activeBanFilter(time.Now())
+	filter["community_id"] = communityObjectID
+	filter["user_id"] = userObjectID
+	filter["type"] = banType
+
+	var deleted model.CommunityBan
+	err = r.communityBanCollection.FindOneAndDelete(ctx, filter).Decode(&deleted)
+	if err != nil {
+		return nil, err
+	}
+
+	return &deleted, nil
+}
+
+func (r *communityBanRepo) DeleteExpired(ctx context.Context) (int64, error) {
+	res, err := r.communityBanCollection.DeleteMany(ctx, bson.M{"expires_at": bson.M{"$lte": time.Now()}})
+	if err != nil {
+		return 0, err
+	}
+
+	return res.DeletedCount, nil
+}
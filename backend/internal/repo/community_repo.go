@@ -21,9 +21,16 @@ type CommunityRepo interface {
 	GetAllPaginated(ctx context.Context, page int, pageSize int) ([]model.Community, int64, error)
 	Update(ctx context.Context, communityID string, updates bson.M) (*model.Community, error)
 	Replace(ctx context.Context, community *model.Community) error
+	AddModerator(ctx context.Context, communityID primitive.ObjectID, moderator model.Moderator) error
+	RemoveModerators(ctx context.Context, communityID primitive.ObjectID, userIDs []primitive.ObjectID) error
+	SetModeratorPermissions(ctx context.Context, communityID primitive.ObjectID, userID primitive.ObjectID, permissions []model.ModPermission) error
+	SetPendingOwner(ctx context.Context, communityID primitive.ObjectID, ownerID primitive.ObjectID, pendingOwnerID primitive.ObjectID) error
+	ClearPendingOwner(ctx context.Context, communityID primitive.ObjectID, pendingOwnerID primitive.ObjectID) error
+	TransferOwnership(ctx context.Context, communityID primitive.ObjectID, previousOwnerID primitive.ObjectID, newOwner model.Moderator) error
 	Delete(ctx context.Context, communityID string) error
 
 	IsUserExist(ctx context.Context, userID string) (bool, error)
+	GetUsername(ctx context.Context, userID string) (string, error)
 }
 
 type communityRepo struct {
@@ -74,7 +81,8 @@ func (c *communityRepo) GetFilter(
 	page int,
 	pageSize int,
 ) ([]model.Community, int64, error) {
-	filter := bson.M{}
+	// Banned communities are hidden from the listings
+	filter := bson.M{"is_banned": bson.M{"$ne": true}}
 	if name != "" {
 		// case-insensitive regex match
 		filter["name"] = bson.M{"$regex": name, "$options": "i"}
@@ -121,7 +129,7 @@ func (c *communityRepo) GetByModeratorIDPaginated(
 	}
 
 	skip := (page - 1) * pageSize
-	filter := bson.M{"moderators.user_id": modObjectID}
+	filter := bson.M{"moderators.user_id": modObjectID, "is_banned": bson.M{"$ne": true}}
 
 	cursor, err := c.communityCollection.Find(ctx, filter, options.Find().SetSkip(int64(skip)), options.Find().SetLimit(int64(pageSize)))
 	if err != nil {
@@ -205,6 +213,121 @@ func (c *communityRepo) Replace(ctx context.Context, community *model.Community)
 	return nil
 }
 
+// AddModerator appends the moderator to the bottom of the list in one step. It returns
+// mongo.ErrNoDocuments when the community is gone or the user already moderates it.
+func (c *communityRepo) AddModerator(ctx context.Context, communityID primitive.ObjectID, moderator model.Moderator) error {
+	filter := bson.M{"_id": communityID, "moderators.user_id": bson.M{"$ne": moderator.UserID}}
+	update := bson.M{"$push": bson.M{"moderators": moderator}}
+
+	res, err := c.communityCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// RemoveModerators takes the users off the moderator list, and withdraws an ownership offer made to
+// one of them
+func (c *communityRepo) RemoveModerators(ctx context.Context, communityID primitive.ObjectID, userIDs []primitive.ObjectID) error {
+	update := bson.M{"$pull": bson.M{"moderators": bson.M{"user_id": bson.M{"$in": userIDs}}}}
+	res, err := c.communityCollection.UpdateOne(ctx, bson.M{"_id": communityID}, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	filter := bson.M{"_id": communityID, "pending_owner_id": bson.M{"$in": userIDs}}
+	_, err = c.communityCollection.UpdateOne(ctx, filter, bson.M{"$unset": bson.M{"pending_owner_id": ""}})
+	return err
+}
+
+// SetModeratorPermissions replaces the permissions of one moderator. It returns mongo.ErrNoDocuments
+// when the user no longer moderates the community.
+func (c *communityRepo) SetModeratorPermissions(ctx context.Context, communityID primitive.ObjectID, userID primitive.ObjectID, permissions []model.ModPermission) error {
+	filter := bson.M{"_id": communityID, "moderators.user_id": userID}
+	update := bson.M{"$set": bson.M{"moderators.$.permissions": permissions}}
+
+	res, err := c.communityCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// SetPendingOwner records an ownership offer. It returns mongo.ErrNoDocuments when the owner changed
+// or the user no longer moderates the community.
+func (c *communityRepo) SetPendingOwner(ctx context.Context, communityID primitive.ObjectID, ownerID primitive.ObjectID, pendingOwnerID primitive.ObjectID) error {
+	filter := bson.M{"_id": communityID, "create_by_id": ownerID, "moderators.user_id": pendingOwnerID}
+	update := bson.M{"$set": bson.M{"pending_owner_id": pendingOwnerID}}
+
+	res, err := c.communityCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// ClearPendingOwner withdraws the offer made to the user. It returns mongo.ErrNoDocuments when there
+// is no such offer.
+func (c *communityRepo) ClearPendingOwner(ctx context.Context, communityID primitive.ObjectID, pendingOwnerID primitive.ObjectID) error {
+	filter := bson.M{"_id": communityID, "pending_owner_id": pendingOwnerID}
+	update := bson.M{"$unset": bson.M{"pending_owner_id": ""}}
+
+	res, err := c.communityCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// TransferOwnership moves the pending owner to the head of the moderator list, followed by the previous
+// owner and then everyone else in their order. The list is rebuilt by the server in one step, so
+// moderators added meanwhile are kept. It returns mongo.ErrNoDocuments when the offer is gone.
+func (c *communityRepo) TransferOwnership(ctx context.Context, communityID primitive.ObjectID, previousOwnerID primitive.ObjectID, newOwner model.Moderator) error {
+	filter := bson.M{
+		"_id":                communityID,
+		"create_by_id":       previousOwnerID,
+		"pending_owner_id":   newOwner.UserID,
+		"moderators.user_id": newOwner.UserID,
+	}
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"moderators": bson.M{"$concatArrays": bson.A{
+				bson.A{bson.M{"$literal": newOwner}},
+				bson.M{"$filter": bson.M{"input": "$moderators", "cond": bson.M{"$eq": bson.A{"$$this.user_id", previousOwnerID}}}},
+				bson.M{"$filter": bson.M{"input": "$moderators", "cond": bson.M{"$not": bson.A{bson.M{"$in": bson.A{"$$this.user_id", bson.A{newOwner.UserID, previousOwnerID}}}}}}},
+			}},
+			"create_by_id":     newOwner.UserID,
+			"create_by_name":   bson.M{"$literal": newOwner.Username},
+			"create_by_avatar": "",
+		}}},
+		{{Key: "$unset", Value: "pending_owner_id"}},
+	}
+
+	res, err := c.communityCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
 func (c *communityRepo) Delete(ctx context.Context, communityID string) error {
 	communityObjectID, err := primitive.ObjectIDFromHex(communityID)
 	if err != nil {
@@ -239,3 +362,21 @@ func (c *communityRepo) IsUserExist(ctx context.Context, userID string) (bool, e
 
 	return true, nil
 }
+
+// GetUsername looks up the username of an active user, so moderator names never come from the client
+func (c *communityRepo) GetUsername(ctx context.Context, userID string) (string, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return "", err
+	}
+
+	filter := bson.M{"_id": userObjectID, "deleted_at": bson.M{"$exists": false}}
+	opts := options.FindOne().SetProjection(bson.M{"username": 1})
+
+	var user model.User
+	if err := c.userCollection.FindOne(ctx, filter, opts).Decode(&user); err != nil {
+		return "", err
+	}
+
+	return user.Username, nil
+}
@@ -0,0 +1,59 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/giakiet05/lkforum/internal/config"
+	"github.com/giakiet05/lkforum/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ContentFilterRepo interface {
+	GetByCommunity(ctx context.Context, communityID string) (*model.ContentFilter, error)
+	Upsert(ctx context.Context, filter *model.ContentFilter) (*model.ContentFilter, error)
+}
+
+type contentFilterRepo struct {
+	contentFilterCollection *mongo.Collection
+}
+
+func NewContentFilterRepo(db *mongo.Database) ContentFilterRepo {
+	return &contentFilterRepo{contentFilterCollection: db.Collection(config.ContentFilterColName)}
+}
+
+func (r *contentFilterRepo) GetByCommunity(ctx context.Context, communityID string) (*model.ContentFilter, error) {
+	communityObjectID, err := primitive.ObjectIDFromHex(communityID)
+	if err != nil {
+		return nil, err
+	}
+
+	var filter model.ContentFilter
+	err = r.contentFilterCollection.FindOne(ctx, bson.M{"community_id": communityObjectID}).Decode(&filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &filter, nil
+}
+
+// Upsert replaces the entries of the community's filter, creating it on first save
+func (r *contentFilterRepo) Upsert(ctx context.Context, filter *model.ContentFilter) (*model.ContentFilter, error) {
+	update := bson.M{"$set": bson.M{
+		"entries":    filter.Entries,
+		"updated_by": filter.UpdatedBy,
+		"updated_at": filter.UpdatedAt,
+	}}
+
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var saved model.ContentFilter
+	err := r.contentFilterCollection.FindOneAndUpdate(ctx, bson.M{"community_id": filter.CommunityID}, update, opts).Decode(&saved)
+	if err != nil {
+		return nil, err
+	}
+
+	return &saved, nil
+}
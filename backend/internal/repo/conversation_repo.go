@@ -0,0 +1,318 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/giakiet05/lkforum/internal/config"
+	"github.com/giakiet05/lkforum/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ConversationRepo interface {
+	Create(ctx context.Context, conversation *model.Conversation) (*model.Conversation, error)
+	GetByID(ctx context.Context, id string) (*model.Conversation, error)
+	GetDirectBetween(ctx context.Context, userID string, otherUserID string) (*model.Conversation, error)
+	GetInboxPaginated(ctx context.Context, userID string, page int, pageSize int) ([]model.Conversation, int64, error)
+	GetRequestsPaginated(ctx context.Context, userID string, page int, pageSize int) ([]model.Conversation, int64, error)
+	UpdateStatus(ctx context.Context, conversationID string, status model.ConversationStatus) error
+	UpdateLastMessageAt(ctx context.Context, conversationID string, at time.Time) error
+
+	CreateMessage(ctx context.Context, message *model.Message) (*model.Message, error)
+	GetMessagesPaginated(ctx context.Context, conversationID string, page int, pageSize int) ([]model.Message, int64, error)
+	MarkMessagesRead(ctx context.Context, conversationID string, userID string) error
+
+	CreateBlock(ctx context.Context, block *model.UserBlock) (*model.UserBlock, error)
+	DeleteBlock(ctx context.Context, userID string, blockedUserID string) error
+	IsBlocked(ctx context.Context, userID string, blockedUserID string) (bool, error)
+	IsUserExist(ctx context.Context, userID string) (bool, error)
+}
+
+type conversationRepo struct {
+	conversationCollection *mongo.Collection
+	messageCollection      *mongo.Collection
+	blockCollection        *mongo.Collection
+	userCollection         *mongo.Collection
+}
+
+func NewConversationRepo(db *mongo.Database) ConversationRepo {
+	return &conversationRepo{
+		conversationCollection: db.Collection(config.ConversationColName),
+		messageCollection:      db.Collection(config.MessageColName),
+		blockCollection:        db.Collection(config.UserBlockColName),
+		userCollection:         db.Collection(config.UserColName),
+	}
+}
+
+func (r *conversationRepo) Create(ctx context.Context, conversation *model.Conversation) (*model.Conversation, error) {
+	result, err := r.conversationCollection.InsertOne(ctx, conversation)
+	if err != nil {
+		return nil, err
+	}
+
+	if oid, ok := result.InsertedID.(primitive.ObjectID); ok {
+		conversation.ID = oid
+	}
+
+	return conversation, nil
+}
+
+func (r *conversationRepo) GetByID(ctx context.Context, id string) (*model.Conversation, error) {
+	conversationObjectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var conversation model.Conversation
+	err = r.conversationCollection.FindOne(ctx, bson.M{"_id": conversationObjectID}).Decode(&conversation)
+	if err != nil {
+		return nil, err
+	}
+
+	return &conversation, nil
+}
+
+func (r *conversationRepo) GetDirectBetween(ctx context.Context, userID string, otherUserID string) (*model.Conversation, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	otherUserObjectID, err := primitive.ObjectIDFromHex(otherUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := bson.M{
+		"type":    model.ConversationTypeDirect,
+		"members": bson.M{"$all": []primitive.ObjectID{userObjectID, otherUserObjectID}, "$size": 2},
+	}
+
+	var conversation model.Conversation
+	err = r.conversationCollection.FindOne(ctx, filter).Decode(&conversation)
+	if err != nil {
+		return nil, err
+	}
+
+	return &conversation, nil
+}
+
+func (r *conversationRepo) GetInboxPaginated(ctx context.Context, userID string, page int, pageSize int) ([]model.Conversation, int64, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Requests addressed to the user live in the requests inbox until they are accepted
+	filter := bson.M{
+		"members": userObjectID,
+		"$nor": []bson.M{{
+			"requested_to": userObjectID,
+			"status":       bson.M{"$in": []model.ConversationStatus{model.ConversationStatusRequest, model.ConversationStatusDeclined}},
+		}},
+	}
+
+	return r.findConversations(ctx, filter, page, pageSize)
+}
+
+func (r *conversationRepo) GetRequestsPaginated(ctx context.Context, userID string, page int, pageSize int) ([]model.Conversation, int64, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	filter := bson.M{
+		"requested_to": userObjectID,
+		"status":       model.ConversationStatusRequest,
+	}
+
+	return r.findConversations(ctx, filter, page, pageSize)
+}
+
+func (r *conversationRepo) findConversations(ctx context.Context, filter bson.M, page int, pageSize int) ([]model.Conversation, int64, error) {
+	skip := (page - 1) * pageSize
+	opts := options.Find().SetSkip(int64(skip)).SetLimit(int64(pageSize)).SetSort(bson.M{"last_message_at": -1})
+
+	cursor, err := r.conversationCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var conversations []model.Conversation
+	if err := cursor.All(ctx, &conversations); err != nil {
+		return nil, 0, err
+	}
+
+	count, err := r.conversationCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return conversations, count, nil
+}
+
+func (r *conversationRepo) UpdateStatus(ctx context.Context, conversationID string, status model.ConversationStatus) error {
+	conversationObjectID, err := primitive.ObjectIDFromHex(conversationID)
+	if err != nil {
+		return err
+	}
+
+	res, err := r.conversationCollection.UpdateOne(ctx, bson.M{"_id": conversationObjectID}, bson.M{"$set": bson.M{"status": status}})
+	if err != nil {
+		return err
+	}
+
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+func (r *conversationRepo) UpdateLastMessageAt(ctx context.Context, conversationID string, at time.Time) error {
+	conversationObjectID, err := primitive.ObjectIDFromHex(conversationID)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.conversationCollection.UpdateOne(ctx, bson.M{"_id": conversationObjectID}, bson.M{"$set": bson.M{"last_message_at": at}})
+	return err
+}
+
+func (r *conversationRepo) CreateMessage(ctx context.Context, message *model.Message) (*model.Message, error) {
+	result, err := r.messageCollection.InsertOne(ctx, message)
+	if err != nil {
+		return nil, err
+	}
+
+	if oid, ok := result.InsertedID.(primitive.ObjectID); ok {
+		message.ID = oid
+	}
+
+	return message, nil
+}
+
+func (r *conversationRepo) GetMessagesPaginated(ctx context.Context, conversationID string, page int, pageSize int) ([]model.Message, int64, error) {
+	conversationObjectID, err := primitive.ObjectIDFromHex(conversationID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	skip := (page - 1) * pageSize
+	filter := bson.M{"conversation_id": conversationObjectID}
+	opts := options.Find().SetSkip(int64(skip)).SetLimit(int64(pageSize)).SetSort(bson.M{"create_at": -1})
+
+	cursor, err := r.messageCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var messages []model.Message
+	if err := cursor.All(ctx, &messages); err != nil {
+		return nil, 0, err
+	}
+
+	count, err := r.messageCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return messages, count, nil
+}
+
+func (r *conversationRepo) MarkMessagesRead(ctx context.Context, conversationID string, userID string) error {
+	conversationObjectID, err := primitive.ObjectIDFromHex(conversationID)
+	if err != nil {
+		return err
+	}
+
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{"conversation_id": conversationObjectID, "read_by": bson.M{"$ne": userObjectID}}
+	update := bson.M{"$addToSet": bson.M{"read_by": userObjectID}}
+
+	_, err = r.messageCollection.UpdateMany(ctx, filter, update)
+	return err
+}
+
+func (r *conversationRepo) CreateBlock(ctx context.Context, block *model.UserBlock) (*model.UserBlock, error) {
+	filter := bson.M{"user_id": block.UserID, "blocked_user_id": block.BlockedUserID}
+	update := bson.M{"$setOnInsert": block}
+
+	_, err := r.blockCollection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.blockCollection.FindOne(ctx, filter).Decode(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return block, nil
+}
+
+func (r *conversationRepo) DeleteBlock(ctx context.Context, userID string, blockedUserID string) error {
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return err
+	}
+
+	blockedUserObjectID, err := primitive.ObjectIDFromHex(blockedUserID)
+	if err != nil {
+		return err
+	}
+
+	res, err := r.blockCollection.DeleteOne(ctx, bson.M{"user_id": userObjectID, "blocked_user_id": blockedUserObjectID})
+	if err != nil {
+		return err
+	}
+
+	if res.DeletedCount == 0 {
+		return fmt.Errorf("no block found for user %v", blockedUserID)
+	}
+
+	return nil
+}
+
+func (r *conversationRepo) IsBlocked(ctx context.Context, userID string, blockedUserID string) (bool, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return false, err
+	}
+
+	blockedUserObjectID, err := primitive.ObjectIDFromHex(blockedUserID)
+	if err != nil {
+		return false, err
+	}
+
+	count, err := r.blockCollection.CountDocuments(ctx, bson.M{"user_id": userObjectID, "blocked_user_id": blockedUserObjectID})
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (r *conversationRepo) IsUserExist(ctx context.Context, userID string) (bool, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return false, err
+	}
+
+	count, err := r.userCollection.CountDocuments(ctx, bson.M{"_id": userObjectID, "deleted_at": bson.M{"$exists": false}})
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
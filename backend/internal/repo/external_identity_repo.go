@@ -0,0 +1,85 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/giakiet05/lkforum/internal/config"
+	"github.com/giakiet05/lkforum/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ExternalIdentityRepo interface {
+	Create(ctx context.Context, identity *model.ExternalIdentity) (*model.ExternalIdentity, error)
+	GetByProviderSubject(ctx context.Context, provider string, subject string) (*model.ExternalIdentity, error)
+	GetByUser(ctx context.Context, userID string) ([]model.ExternalIdentity, error)
+	DeleteByUserAndProvider(ctx context.Context, userID string, provider string) error
+}
+
+type externalIdentityRepo struct {
+	collection *mongo.Collection
+}
+
+func NewExternalIdentityRepo(db *mongo.Database) ExternalIdentityRepo {
+	return &externalIdentityRepo{
+		collection: db.Collection(config.ExternalIdentityColName),
+	}
+}
+
+func (r *externalIdentityRepo) Create(ctx context.Context, identity *model.ExternalIdentity) (*model.ExternalIdentity, error) {
+	result, err := r.collection.InsertOne(ctx, identity)
+	if err != nil {
+		return nil, err
+	}
+
+	if oid, ok := result.InsertedID.(primitive.ObjectID); ok {
+		identity.ID = oid
+	}
+
+	return identity, nil
+}
+
+func (r *externalIdentityRepo) GetByProviderSubject(ctx context.Context, provider string, subject string) (*model.ExternalIdentity, error) {
+	var identity model.ExternalIdentity
+	if err := r.collection.FindOne(ctx, bson.M{"provider": provider, "subject": subject}).Decode(&identity); err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+func (r *externalIdentityRepo) GetByUser(ctx context.Context, userID string) ([]model.ExternalIdentity, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := r.collection.Find(ctx, bson.M{"user_id": userObjectID}, options.Find().SetSort(bson.M{"created_at": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	identities := []model.ExternalIdentity{}
+	if err := cursor.All(ctx, &identities); err != nil {
+		return nil, err
+	}
+	return identities, nil
+}
+
+func (r *externalIdentityRepo) DeleteByUserAndProvider(ctx context.Context, userID string, provider string) error {
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return err
+	}
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"user_id": userObjectID, "provider": provider})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
@@ -25,6 +25,7 @@ type MembershipRepo interface {
 
 	IsUserExist(ctx context.Context, userID string) (bool, error)
 	IsCommunityExist(ctx context.Context, communityID string) (bool, error)
+	ShareCommunity(ctx context.Context, userID string, otherUserID string) (bool, error)
 }
 
 type membershipRepo struct {
@@ -222,7 +223,8 @@ func (m *membershipRepo) IsCommunityExist(ctx context.Context, communityID strin
 		return false, err
 	}
 
-	count, err := m.communityCollection.CountDocuments(ctx, bson.M{"_id": communityObjectID})
+	// Banned communities are hidden from everyone but admins
+	count, err := m.communityCollection.CountDocuments(ctx, bson.M{"_id": communityObjectID, "is_banned": bson.M{"$ne": true}})
 	if err != nil {
 		return false, err
 	}
@@ -233,3 +235,34 @@ func (m *membershipRepo) IsCommunityExist(ctx context.Context, communityID strin
 
 	return true, nil
 }
+
+func (m *membershipRepo) ShareCommunity(ctx context.Context, userID string, otherUserID string) (bool, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return false, err
+	}
+
+	otherUserObjectID, err := primitive.ObjectIDFromHex(otherUserID)
+	if err != nil {
+		return false, err
+	}
+
+	communityIDs, err := m.membershipCollection.Distinct(ctx, "community_id", bson.M{"user_id": userObjectID})
+	if err != nil {
+		return false, err
+	}
+	if len(communityIDs) == 0 {
+		return false, nil
+	}
+
+	filter := bson.M{
+		"user_id":      otherUserObjectID,
+		"community_id": bson.M{"$in": communityIDs},
+	}
+	count, err := m.membershipCollection.CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
@@ -0,0 +1,40 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/giakiet05/lkforum/internal/config"
+	"github.com/giakiet05/lkforum/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type MigrationRepo interface {
+	IsApplied(ctx context.Context, name string) (bool, error)
+	MarkApplied(ctx context.Context, name string, at time.Time) error
+}
+
+type migrationRepo struct {
+	collection *mongo.Collection
+}
+
+func NewMigrationRepo(db *mongo.Database) MigrationRepo {
+	return &migrationRepo{
+		collection: db.Collection(config.MigrationColName),
+	}
+}
+
+func (r *migrationRepo) IsApplied(ctx context.Context, name string) (bool, error) {
+	err := r.collection.FindOne(ctx, bson.M{"_id": name}).Err()
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (r *migrationRepo) MarkApplied(ctx context.Context, name string, at time.Time) error {
+	_, err := r.collection.InsertOne(ctx, &model.Migration{Name: name, AppliedAt: at})
+	return err
+}
@@ -0,0 +1,146 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/giakiet05/lkforum/internal/config"
+	"github.com/giakiet05/lkforum/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ModLogFilter narrows down a mod log listing; zero values are ignored
+type ModLogFilter struct {
+	CommunityID string
+	ActorID     string
+	ActorRole   model.ModLogActorRole
+	Action      model.ModAction
+	TargetType  model.ModTargetType
+	TargetID    string
+	From        time.Time
+	To          time.Time
+}
+
+// ModLogRepo is append-only: entries can be created and listed but never changed
+type ModLogRepo interface {
+	Create(ctx context.Context, entry *model.ModLog) (*model.ModLog, error)
+	GetByID(ctx context.Context, id string) (*model.ModLog, error)
+	GetPaginated(ctx context.Context, filter ModLogFilter, page int, pageSize int) ([]model.ModLog, int64, error)
+}
+
+type modLogRepo struct {
+	modLogCollection *mongo.Collection
+}
+
+func NewModLogRepo(db *mongo.Database) ModLogRepo {
+	// Snapshots are free-form documents, decode them as maps so they serialize cleanly to JSON
+	opts := options.Collection().SetBSONOptions(&options.BSONOptions{DefaultDocumentM: true})
+	return &modLogRepo{modLogCollection: db.Collection(config.ModLogColName, opts)}
+}
+
+func (r *modLogRepo) Create(ctx context.Context, entry *model.ModLog) (*model.ModLog, error) {
+	result, err := r.modLogCollection.InsertOne(ctx, entry)
+	if err != nil {
+		return nil, err
+	}
+
+	if oid, ok := result.InsertedID.(primitive.ObjectID); ok {
+		entry.ID = oid
+	}
+
+	return entry, nil
+}
+
+func (r *modLogRepo) GetByID(ctx context.Context, id string) (*model.ModLog, error) {
+	entryObjectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var entry model.ModLog
+	err = r.modLogCollection.FindOne(ctx, bson.M{"_id": entryObjectID}).Decode(&entry)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+func (r *modLogRepo) GetPaginated(ctx context.Context, filter ModLogFilter, page int, pageSize int) ([]model.ModLog, int64, error) {
+	query, err := filter.toBSON()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	skip := (page - 1) * pageSize
+	opts := options.Find().SetSkip(int64(skip)).SetLimit(int64(pageSize)).SetSort(bson.M{"created_at": -1})
+
+	cursor, err := r.modLogCollection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var entries []model.ModLog
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, 0, err
+	}
+
+	count, err := r.modLogCollection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return entries, count, nil
+}
+
+func (f ModLogFilter) toBSON() (bson.M, error) {
+	query := bson.M{}
+
+	if f.CommunityID != "" {
+		communityObjectID, err := primitive.ObjectIDFromHex(f.CommunityID)
+		if err != nil {
+			return nil, err
+		}
+		query["community_id"] = communityObjectID
+	}
+	if f.ActorID != "" {
+		actorObjectID, err := primitive.ObjectIDFromHex(f.ActorID)
+		if err != nil {
+			return nil, err
+		}
+		query["actor_id"] = actorObjectID
+	}
+	if f.TargetID != "" {
+		targetObjectID, err := primitive.ObjectIDFromHex(f.TargetID)
+		if err != nil {
+			return nil, err
+		}
+		query["target_id"] = targetObjectID
+	}
+	if f.ActorRole != "" {
+		query["actor_role"] = f.ActorRole
+	}
+	if f.Action != "" {
+		query["action"] = f.Action
+	}
+	if f.TargetType != "" {
+		query["target_type"] = f.TargetType
+	}
+
+	createdAt := bson.M{}
+	if !f.From.IsZero() {
+		createdAt["$gte"] = f.From
+	}
+	if !f.To.IsZero() {
+		createdAt["$lte"] = f.To
+	}
+	if len(createdAt) > 0 {
+		query["created_at"] = createdAt
+	}
+
+	return query, nil
+}
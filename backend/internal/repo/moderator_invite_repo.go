@@ -0,0 +1,143 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/giakiet05/lkforum/internal/config"
+	"github.com/giakiet05/lkforum/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ModeratorInviteRepo interface {
+	Create(ctx context.Context, invite *model.ModeratorInvite) (*model.ModeratorInvite, error)
+	GetPendingByID(ctx context.Context, id string) (*model.ModeratorInvite, error)
+	HasPending(ctx context.Context, communityID primitive.ObjectID, inviteeID primitive.ObjectID) (bool, error)
+	GetPendingByInviteePaginated(ctx context.Context, inviteeID string, page int, pageSize int) ([]model.ModeratorInvite, int64, error)
+	GetPendingByCommunityPaginated(ctx context.Context, communityID string, page int, pageSize int) ([]model.ModeratorInvite, int64, error)
+	Resolve(ctx context.Context, id primitive.ObjectID, status model.ModeratorInviteStatus) (*model.ModeratorInvite, error)
+}
+
+type moderatorInviteRepo struct {
+	moderatorInviteCollection *mongo.Collection
+}
+
+func NewModeratorInviteRepo(db *mongo.Database) ModeratorInviteRepo {
+	return &moderatorInviteRepo{moderatorInviteCollection: db.Collection(config.ModeratorInviteColName)}
+}
+
+// pendingInviteFilter matches invitations that are still waiting for an answer and have not expired
+func pendingInviteFilter(now time.Time) bson.M {
+	return bson.M{
+		"status":     model.ModeratorInviteStatusPending,
+		"expires_at": bson.M{"$gt": now},
+	}
+}
+
+func (r *moderatorInviteRepo) Create(ctx context.Context, invite *model.ModeratorInvite) (*model.ModeratorInvite, error) {
+	result, err := r.moderatorInviteCollection.InsertOne(ctx, invite)
+	if err != nil {
+		return nil, err
+	}
+
+	if oid, ok := result.InsertedID.(primitive.ObjectID); ok {
+		invite.ID = oid
+	}
+
+	return invite, nil
+}
+
+// GetPendingByID returns the invitation while it is still pending, expired or not
+func (r *moderatorInviteRepo) GetPendingByID(ctx context.Context, id string) (*model.ModeratorInvite, error) {
+	inviteObjectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := bson.M{"_id": inviteObjectID, "status": model.ModeratorInviteStatusPending}
+
+	var invite model.ModeratorInvite
+	if err := r.moderatorInviteCollection.FindOne(ctx, filter).Decode(&invite); err != nil {
+		return nil, err
+	}
+
+	return &invite, nil
+}
+
+func (r *moderatorInviteRepo) HasPending(ctx context.Context, communityID primitive.ObjectID, inviteeID primitive.ObjectID) (bool, error) {
+	filter := pendingInviteFilter(time.Now())
+	filter["community_id"] = communityID
+	filter["invitee_id"] = inviteeID
+
+	count, err := r.moderatorInviteCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (r *moderatorInviteRepo) GetPendingByInviteePaginated(ctx context.Context, inviteeID string, page int, pageSize int) ([]model.ModeratorInvite, int64, error) {
+	inviteeObjectID, err := primitive.ObjectIDFromHex(inviteeID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	filter := pendingInviteFilter(time.Now())
+	filter["invitee_id"] = inviteeObjectID
+
+	return r.getPaginated(ctx, filter, page, pageSize)
+}
+
+func (r *moderatorInviteRepo) GetPendingByCommunityPaginated(ctx context.Context, communityID string, page int, pageSize int) ([]model.ModeratorInvite, int64, error) {
+	communityObjectID, err := primitive.ObjectIDFromHex(communityID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	filter := pendingInviteFilter(time.Now())
+	filter["community_id"] = communityObjectID
+
+	return r.getPaginated(ctx, filter, page, pageSize)
+}
+
+// Resolve closes a pending invitation with the given status. It fails with mongo.ErrNoDocuments
+// if the invitation was already answered, so an invitation can only be resolved once.
+func (r *moderatorInviteRepo) Resolve(ctx context.Context, id primitive.ObjectID, status model.ModeratorInviteStatus) (*model.ModeratorInvite, error) {
+	filter := bson.M{"_id": id, "status": model.ModeratorInviteStatusPending}
+	update := bson.M{"$set": bson.M{"status": status, "responded_at": time.Now()}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var invite model.ModeratorInvite
+	if err := r.moderatorInviteCollection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&invite); err != nil {
+		return nil, err
+	}
+
+	return &invite, nil
+}
+
+func (r *moderatorInviteRepo) getPaginated(ctx context.Context, filter bson.M, page int, pageSize int) ([]model.ModeratorInvite, int64, error) {
+	skip := (page - 1) * pageSize
+	opts := options.Find().SetSkip(int64(skip)).SetLimit(int64(pageSize)).SetSort(bson.M{"created_at": -1})
+
+	cursor, err := r.moderatorInviteCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var invites []model.ModeratorInvite
+	if err := cursor.All(ctx, &invites); err != nil {
+		return nil, 0, err
+	}
+
+	count, err := r.moderatorInviteCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return invites, count, nil
+}
@@ -0,0 +1,170 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/giakiet05/lkforum/internal/config"
+	"github.com/giakiet05/lkforum/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ModmailRepo interface {
+	CreateThread(ctx context.Context, thread *model.ModmailThread) (*model.ModmailThread, error)
+	GetThreadByID(ctx context.Context, threadID string) (*model.ModmailThread, error)
+	GetThreadsByUserPaginated(ctx context.Context, userID string, page int, pageSize int) ([]model.ModmailThread, int64, error)
+	GetThreadsByCommunityPaginated(ctx context.Context, communityID string, status model.ModmailThreadStatus, highlightedOnly bool, page int, pageSize int) ([]model.ModmailThread, int64, error)
+	UpdateThread(ctx context.Context, threadID primitive.ObjectID, update bson.M) error
+
+	CreateMessage(ctx context.Context, message *model.ModmailMessage) (*model.ModmailMessage, error)
+	GetMessagesPaginated(ctx context.Context, threadID string, includeInternal bool, page int, pageSize int) ([]model.ModmailMessage, int64, error)
+}
+
+type modmailRepo struct {
+	threadCollection  *mongo.Collection
+	messageCollection *mongo.Collection
+}
+
+func NewModmailRepo(db *mongo.Database) ModmailRepo {
+	return &modmailRepo{
+		threadCollection:  db.Collection(config.ModmailThreadColName),
+		messageCollection: db.Collection(config.ModmailMessageColName),
+	}
+}
+
+func (r *modmailRepo) CreateThread(ctx context.Context, thread *model.ModmailThread) (*model.ModmailThread, error) {
+	result, err := r.threadCollection.InsertOne(ctx, thread)
+	if err != nil {
+		return nil, err
+	}
+
+	if oid, ok := result.InsertedID.(primitive.ObjectID); ok {
+		thread.ID = oid
+	}
+
+	return thread, nil
+}
+
+func (r *modmailRepo) GetThreadByID(ctx context.Context, threadID string) (*model.ModmailThread, error) {
+	threadObjectID, err := primitive.ObjectIDFromHex(threadID)
+	if err != nil {
+		return nil, err
+	}
+
+	var thread model.ModmailThread
+	if err := r.threadCollection.FindOne(ctx, bson.M{"_id": threadObjectID}).Decode(&thread); err != nil {
+		return nil, err
+	}
+
+	return &thread, nil
+}
+
+func (r *modmailRepo) GetThreadsByUserPaginated(ctx context.Context, userID string, page int, pageSize int) ([]model.ModmailThread, int64, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return r.findThreads(ctx, bson.M{"user_id": userObjectID}, page, pageSize)
+}
+
+// GetThreadsByCommunityPaginated lists the team inbox. An empty status returns threads of every status.
+func (r *modmailRepo) GetThreadsByCommunityPaginated(ctx context.Context, communityID string, status model.ModmailThreadStatus, highlightedOnly bool, page int, pageSize int) ([]model.ModmailThread, int64, error) {
+	communityObjectID, err := primitive.ObjectIDFromHex(communityID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	filter := bson.M{"community_id": communityObjectID}
+	if status != "" {
+		filter["status"] = status
+	}
+	if highlightedOnly {
+		filter["is_highlighted"] = true
+	}
+
+	return r.findThreads(ctx, filter, page, pageSize)
+}
+
+func (r *modmailRepo) findThreads(ctx context.Context, filter bson.M, page int, pageSize int) ([]model.ModmailThread, int64, error) {
+	skip := (page - 1) * pageSize
+	opts := options.Find().SetSkip(int64(skip)).SetLimit(int64(pageSize)).SetSort(bson.M{"last_message_at": -1})
+
+	cursor, err := r.threadCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var threads []model.ModmailThread
+	if err := cursor.All(ctx, &threads); err != nil {
+		return nil, 0, err
+	}
+
+	count, err := r.threadCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return threads, count, nil
+}
+
+func (r *modmailRepo) UpdateThread(ctx context.Context, threadID primitive.ObjectID, update bson.M) error {
+	result, err := r.threadCollection.UpdateOne(ctx, bson.M{"_id": threadID}, bson.M{"$set": update})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *modmailRepo) CreateMessage(ctx context.Context, message *model.ModmailMessage) (*model.ModmailMessage, error) {
+	result, err := r.messageCollection.InsertOne(ctx, message)
+	if err != nil {
+		return nil, err
+	}
+
+	if oid, ok := result.InsertedID.(primitive.ObjectID); ok {
+		message.ID = oid
+	}
+
+	return message, nil
+}
+
+// GetMessagesPaginated returns the newest messages first. Internal notes are left out unless includeInternal is set.
+func (r *modmailRepo) GetMessagesPaginated(ctx context.Context, threadID string, includeInternal bool, page int, pageSize int) ([]model.ModmailMessage, int64, error) {
+	threadObjectID, err := primitive.ObjectIDFromHex(threadID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	filter := bson.M{"thread_id": threadObjectID}
+	if !includeInternal {
+		filter["is_internal"] = false
+	}
+
+	skip := (page - 1) * pageSize
+	opts := options.Find().SetSkip(int64(skip)).SetLimit(int64(pageSize)).SetSort(bson.M{"created_at": -1})
+
+	cursor, err := r.messageCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var messages []model.ModmailMessage
+	if err := cursor.All(ctx, &messages); err != nil {
+		return nil, 0, err
+	}
+
+	count, err := r.messageCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return messages, count, nil
+}
@@ -0,0 +1,82 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/giakiet05/lkforum/internal/config"
+	"github.com/giakiet05/lkforum/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type NotificationRepo interface {
+	Create(ctx context.Context, notification *model.Notification) (*model.Notification, error)
+	CreateMany(ctx context.Context, notifications []model.Notification) error
+	GetByUserIDPaginated(ctx context.Context, userID string, page int, pageSize int) ([]model.Notification, int64, error)
+}
+
+type notificationRepo struct {
+	notificationCollection *mongo.Collection
+}
+
+func NewNotificationRepo(db *mongo.Database) NotificationRepo {
+	return &notificationRepo{notificationCollection: db.Collection(config.NotificationColName)}
+}
+
+func (r *notificationRepo) Create(ctx context.Context, notification *model.Notification) (*model.Notification, error) {
+	result, err := r.notificationCollection.InsertOne(ctx, notification)
+	if err != nil {
+		return nil, err
+	}
+
+	if oid, ok := result.InsertedID.(primitive.ObjectID); ok {
+		notification.ID = oid
+	}
+
+	return notification, nil
+}
+
+func (r *notificationRepo) CreateMany(ctx context.Context, notifications []model.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+
+	docs := make([]interface{}, 0, len(notifications))
+	for _, n := range notifications {
+		docs = append(docs, n)
+	}
+
+	_, err := r.notificationCollection.InsertMany(ctx, docs)
+	return err
+}
+
+func (r *notificationRepo) GetByUserIDPaginated(ctx context.Context, userID string, page int, pageSize int) ([]model.Notification, int64, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	skip := (page - 1) * pageSize
+	filter := bson.M{"user_id": userObjectID}
+	opts := options.Find().SetSkip(int64(skip)).SetLimit(int64(pageSize)).SetSort(bson.M{"created_at": -1})
+
+	cursor, err := r.notificationCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var notifications []model.Notification
+	if err := cursor.All(ctx, &notifications); err != nil {
+		return nil, 0, err
+	}
+
+	count, err := r.notificationCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return notifications, count, nil
+}
@@ -0,0 +1,108 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/giakiet05/lkforum/internal/config"
+	"github.com/giakiet05/lkforum/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type PersonalAccessTokenRepo interface {
+	Create(ctx context.Context, token *model.PersonalAccessToken) (*model.PersonalAccessToken, error)
+	GetByHash(ctx context.Context, tokenHash string) (*model.PersonalAccessToken, error)
+	GetByUser(ctx context.Context, userID string) ([]model.PersonalAccessToken, error)
+	CountActiveByUser(ctx context.Context, userID string) (int64, error)
+	TouchLastUsed(ctx context.Context, tokenID primitive.ObjectID, at time.Time) error
+	Delete(ctx context.Context, userID string, tokenID string) error
+}
+
+type personalAccessTokenRepo struct {
+	collection *mongo.Collection
+}
+
+func NewPersonalAccessTokenRepo(db *mongo.Database) PersonalAccessTokenRepo {
+	return &personalAccessTokenRepo{
+		collection: db.Collection(config.PersonalAccessTokenColName),
+	}
+}
+
+func (r *personalAccessTokenRepo) Create(ctx context.Context, token *model.PersonalAccessToken) (*model.PersonalAccessToken, error) {
+	result, err := r.collection.InsertOne(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	if oid, ok := result.InsertedID.(primitive.ObjectID); ok {
+		token.ID = oid
+	}
+
+	return token, nil
+}
+
+func (r *personalAccessTokenRepo) GetByHash(ctx context.Context, tokenHash string) (*model.PersonalAccessToken, error) {
+	var token model.PersonalAccessToken
+	if err := r.collection.FindOne(ctx, bson.M{"token_hash": tokenHash}).Decode(&token); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// GetByUser lists the user's tokens, expired ones included, newest first
+func (r *personalAccessTokenRepo) GetByUser(ctx context.Context, userID string) ([]model.PersonalAccessToken, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := r.collection.Find(ctx, bson.M{"user_id": userObjectID}, options.Find().SetSort(bson.M{"created_at": -1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	tokens := []model.PersonalAccessToken{}
+	if err := cursor.All(ctx, &tokens); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+func (r *personalAccessTokenRepo) CountActiveByUser(ctx context.Context, userID string) (int64, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return 0, err
+	}
+
+	return r.collection.CountDocuments(ctx, bson.M{"user_id": userObjectID, "expires_at": bson.M{"$gt": time.Now()}})
+}
+
+func (r *personalAccessTokenRepo) TouchLastUsed(ctx context.Context, tokenID primitive.ObjectID, at time.Time) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": tokenID}, bson.M{"$set": bson.M{"last_used_at": at}})
+	return err
+}
+
+// Delete revokes the token, it fails with ErrNoDocuments when the user has no such token
+func (r *personalAccessTokenRepo) Delete(ctx context.Context, userID string, tokenID string) error {
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return err
+	}
+	tokenObjectID, err := primitive.ObjectIDFromHex(tokenID)
+	if err != nil {
+		return err
+	}
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": tokenObjectID, "user_id": userObjectID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/giakiet05/lkforum/internal/config"
+	"github.com/giakiet05/lkforum/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// RemovalRepo stores the removal reason templates of communities and sets the moderation status
+// of the posts and comments moderators remove
+type RemovalRepo interface {
+	CreateReason(ctx context.Context, reason *model.RemovalReason) (*model.RemovalReason, error)
+	GetReason(ctx context.Context, communityID primitive.ObjectID, reasonID string) (*model.RemovalReason, error)
+	GetReasonsByCommunity(ctx context.Context, communityID string) ([]model.RemovalReason, error)
+	UpdateReason(ctx context.Context, reasonID primitive.ObjectID, updates bson.M) (*model.RemovalReason, error)
+	DeleteReason(ctx context.Context, reasonID primitive.ObjectID) error
+
+	GetPost(ctx context.Context, postID string) (*model.Post, error)
+	GetComment(ctx context.Context, commentID string) (*model.Comment, error)
+	SetPostModerationStatus(ctx context.Context, postID primitive.ObjectID, status model.ModerationStatus) error
+	SetCommentModerationStatus(ctx context.Context, commentID primitive.ObjectID, status model.ModerationStatus) error
+}
+
+type removalRepo struct {
+	removalReasonCollection *mongo.Collection
+	postCollection          *mongo.Collection
+	commentCollection       *mongo.Collection
+}
+
+func NewRemovalRepo(db *mongo.Database) RemovalRepo {
+	return &removalRepo{
+		removalReasonCollection: db.Collection(config.RemovalReasonColName),
+		postCollection:          db.Collection(config.PostColName),
+		commentCollection:       db.Collection(config.CommentColName),
+	}
+}
+
+func (r *removalRepo) CreateReason(ctx context.Context, reason *model.RemovalReason) (*model.RemovalReason, error) {
+	result, err := r.removalReasonCollection.InsertOne(ctx, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	if oid, ok := result.InsertedID.(primitive.ObjectID); ok {
+		reason.ID = oid
+	}
+
+	return reason, nil
+}
+
+// GetReason only finds the template within the given community's library
+func (r *removalRepo) GetReason(ctx context.Context, communityID primitive.ObjectID, reasonID string) (*model.RemovalReason, error) {
+	reasonObjectID, err := primitive.ObjectIDFromHex(reasonID)
+	if err != nil {
+		return nil, err
+	}
+
+	var reason model.RemovalReason
+	err = r.removalReasonCollection.FindOne(ctx, bson.M{"_id": reasonObjectID, "community_id": communityID}).Decode(&reason)
+	if err != nil {
+		return nil, err
+	}
+
+	return &reason, nil
+}
+
+func (r *removalRepo) GetReasonsByCommunity(ctx context.Context, communityID string) ([]model.RemovalReason, error) {
+	communityObjectID, err := primitive.ObjectIDFromHex(communityID)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := options.Find().SetSort(bson.M{"created_at": 1})
+	cursor, err := r.removalReasonCollection.Find(ctx, bson.M{"community_id": communityObjectID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	reasons := []model.RemovalReason{}
+	if err := cursor.All(ctx, &reasons); err != nil {
+		return nil, err
+	}
+
+	return reasons, nil
+}
+
+func (r *removalRepo) UpdateReason(ctx context.Context, reasonID primitive.ObjectID, updates bson.M) (*model.RemovalReason, error) {
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var reason model.RemovalReason
+	err := r.removalReasonCollection.FindOneAndUpdate(ctx, bson.M{"_id": reasonID}, bson.M{"$set": updates}, opts).Decode(&reason)
+	if err != nil {
+		return nil, err
+	}
+
+	return &reason, nil
+}
+
+func (r *removalRepo) DeleteReason(ctx context.Context, reasonID primitive.ObjectID) error {
+	result, err := r.removalReasonCollection.DeleteOne(ctx, bson.M{"_id": reasonID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *removalRepo) GetPost(ctx context.Context, postID string) (*model.Post, error) {
+	postObjectID, err := primitive.ObjectIDFromHex(postID)
+	if err != nil {
+		return nil, err
+	}
+
+	var post model.Post
+	err = r.postCollection.FindOne(ctx, bson.M{"_id": postObjectID, "is_deleted": bson.M{"$ne": true}}).Decode(&post)
+	if err != nil {
+		return nil, err
+	}
+
+	return &post, nil
+}
+
+func (r *removalRepo) GetComment(ctx context.Context, commentID string) (*model.Comment, error) {
+	commentObjectID, err := primitive.ObjectIDFromHex(commentID)
+	if err != nil {
+		return nil, err
+	}
+
+	var comment model.Comment
+	err = r.commentCollection.FindOne(ctx, bson.M{"_id": commentObjectID, "is_deleted": bson.M{"$ne": true}}).Decode(&comment)
+	if err != nil {
+		return nil, err
+	}
+
+	return &comment, nil
+}
+
+func (r *removalRepo) SetPostModerationStatus(ctx context.Context, postID primitive.ObjectID, status model.ModerationStatus) error {
+	result, err := r.postCollection.UpdateOne(ctx, bson.M{"_id": postID}, bson.M{"$set": bson.M{"moderation_status": status}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *removalRepo) SetCommentModerationStatus(ctx context.Context, commentID primitive.ObjectID, status model.ModerationStatus) error {
+	result, err := r.commentCollection.UpdateOne(ctx, bson.M{"_id": commentID}, bson.M{"$set": bson.M{"moderation_status": status}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
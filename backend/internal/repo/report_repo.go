@@ -0,0 +1,262 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/giakiet05/lkforum/internal/config"
+	"github.com/giakiet05/lkforum/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// unresolvedReportStatuses are the statuses under which new reports on the same target are merged
+var unresolvedReportStatuses = []model.ReportStatus{model.ReportStatusOpen, model.ReportStatusInReview}
+
+type ReportRepo interface {
+	AddEntry(ctx context.Context, targetType model.ReportTargetType, targetID primitive.ObjectID, communityID *primitive.ObjectID, entry model.ReportEntry) (*model.Report, error)
+	HasReported(ctx context.Context, targetType model.ReportTargetType, targetID primitive.ObjectID, reporterID primitive.ObjectID) (bool, error)
+	GetByID(ctx context.Context, id string) (*model.Report, error)
+	GetPaginated(ctx context.Context, communityID string, targetType model.ReportTargetType, status model.ReportStatus, page int, pageSize int) ([]model.Report, int64, error)
+	Update(ctx context.Context, reportID string, updates bson.M) (*model.Report, error)
+	CountUnresolvedByTarget(ctx context.Context, targetType model.ReportTargetType, targetID string) (int, error)
+
+	GetPostCommunityID(ctx context.Context, postID string) (primitive.ObjectID, error)
+	GetCommentCommunityID(ctx context.Context, commentID string) (primitive.ObjectID, error)
+	IsUserExist(ctx context.Context, userID string) (bool, error)
+}
+
+type reportRepo struct {
+	reportCollection  *mongo.Collection
+	postCollection    *mongo.Collection
+	commentCollection *mongo.Collection
+	userCollection    *mongo.Collection
+}
+
+func NewReportRepo(db *mongo.Database) ReportRepo {
+	return &reportRepo{
+		reportCollection:  db.Collection(config.ReportColName),
+		postCollection:    db.Collection(config.PostColName),
+		commentCollection: db.Collection(config.CommentColName),
+		userCollection:    db.Collection(config.UserColName),
+	}
+}
+
+// AddEntry merges a report into the unresolved report of the same target, creating it if there is none.
+// A unique index allows one unresolved report per target: when a concurrent first report wins the
+// insert, the entry is merged into it by a second try. The reporter's earlier entry keeps the filter
+// from matching, so reporting the same target twice fails with a duplicate key error.
+func (r *reportRepo) AddEntry(
+	ctx context.Context,
+	targetType model.ReportTargetType,
+	targetID primitive.ObjectID,
+	communityID *primitive.ObjectID,
+	entry model.ReportEntry,
+) (*model.Report, error) {
+	filter := bson.M{
+		"target_type":         targetType,
+		"target_id":           targetID,
+		"status":              bson.M{"$in": unresolvedReportStatuses},
+		"entries.reporter_id": bson.M{"$ne": entry.ReporterID},
+	}
+
+	onInsert := bson.M{
+		"reporter_id": entry.ReporterID,
+		"reason":      entry.Reason,
+		"status":      model.ReportStatusOpen,
+		"created_at":  entry.CreatedAt,
+	}
+	if entry.Description != nil {
+		onInsert["description"] = entry.Description
+	}
+	if communityID != nil {
+		onInsert["community_id"] = communityID
+	}
+
+	update := bson.M{
+		"$push":        bson.M{"entries": entry},
+		"$inc":         bson.M{"report_count": 1},
+		"$set":         bson.M{"updated_at": entry.CreatedAt},
+		"$setOnInsert": onInsert,
+	}
+
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var report model.Report
+	err := r.reportCollection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&report)
+	if mongo.IsDuplicateKeyError(err) {
+		err = r.reportCollection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&report)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &report, nil
+}
+
+func (r *reportRepo) HasReported(ctx context.Context, targetType model.ReportTargetType, targetID primitive.ObjectID, reporterID primitive.ObjectID) (bool, error) {
+	filter := bson.M{
+		"target_type":         targetType,
+		"target_id":           targetID,
+		"status":              bson.M{"$in": unresolvedReportStatuses},
+		"entries.reporter_id": reporterID,
+	}
+
+	count, err := r.reportCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (r *reportRepo) GetByID(ctx context.Context, id string) (*model.Report, error) {
+	reportObjectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var report model.Report
+	err = r.reportCollection.FindOne(ctx, bson.M{"_id": reportObjectID}).Decode(&report)
+	if err != nil {
+		return nil, err
+	}
+
+	return &report, nil
+}
+
+func (r *reportRepo) GetPaginated(
+	ctx context.Context,
+	communityID string,
+	targetType model.ReportTargetType,
+	status model.ReportStatus,
+	page int,
+	pageSize int,
+) ([]model.Report, int64, error) {
+	filter := bson.M{}
+	if communityID != "" {
+		communityObjectID, err := primitive.ObjectIDFromHex(communityID)
+		if err != nil {
+			return nil, 0, err
+		}
+		filter["community_id"] = communityObjectID
+	}
+	if targetType != "" {
+		filter["target_type"] = targetType
+	}
+	if status != "" {
+		filter["status"] = status
+	}
+
+	skip := (page - 1) * pageSize
+	opts := options.Find().SetSkip(int64(skip)).SetLimit(int64(pageSize)).SetSort(bson.D{{Key: "report_count", Value: -1}, {Key: "created_at", Value: 1}})
+
+	cursor, err := r.reportCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var reports []model.Report
+	if err := cursor.All(ctx, &reports); err != nil {
+		return nil, 0, err
+	}
+
+	count, err := r.reportCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return reports, count, nil
+}
+
+func (r *reportRepo) Update(ctx context.Context, reportID string, updates bson.M) (*model.Report, error) {
+	reportObjectID, err := primitive.ObjectIDFromHex(reportID)
+	if err != nil {
+		return nil, err
+	}
+
+	updates["updated_at"] = time.Now()
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var updated model.Report
+	err = r.reportCollection.FindOneAndUpdate(ctx, bson.M{"_id": reportObjectID}, bson.M{"$set": updates}, opts).Decode(&updated)
+	if err != nil {
+		return nil, err
+	}
+
+	return &updated, nil
+}
+
+func (r *reportRepo) CountUnresolvedByTarget(ctx context.Context, targetType model.ReportTargetType, targetID string) (int, error) {
+	targetObjectID, err := primitive.ObjectIDFromHex(targetID)
+	if err != nil {
+		return 0, err
+	}
+
+	filter := bson.M{
+		"target_type": targetType,
+		"target_id":   targetObjectID,
+		"status":      bson.M{"$in": unresolvedReportStatuses},
+	}
+
+	var report model.Report
+	err = r.reportCollection.FindOne(ctx, filter).Decode(&report)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	return report.ReportCount, nil
+}
+
+func (r *reportRepo) GetPostCommunityID(ctx context.Context, postID string) (primitive.ObjectID, error) {
+	postObjectID, err := primitive.ObjectIDFromHex(postID)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	var post model.Post
+	filter := bson.M{"_id": postObjectID, "is_deleted": bson.M{"$ne": true}}
+	opts := options.FindOne().SetProjection(bson.M{"community_id": 1})
+	if err := r.postCollection.FindOne(ctx, filter, opts).Decode(&post); err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	return post.CommunityID, nil
+}
+
+func (r *reportRepo) GetCommentCommunityID(ctx context.Context, commentID string) (primitive.ObjectID, error) {
+	commentObjectID, err := primitive.ObjectIDFromHex(commentID)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	var comment model.Comment
+	filter := bson.M{"_id": commentObjectID, "is_deleted": bson.M{"$ne": true}}
+	opts := options.FindOne().SetProjection(bson.M{"post_id": 1})
+	if err := r.commentCollection.FindOne(ctx, filter, opts).Decode(&comment); err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	return r.GetPostCommunityID(ctx, comment.PostID.Hex())
+}
+
+func (r *reportRepo) IsUserExist(ctx context.Context, userID string) (bool, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return false, err
+	}
+
+	count, err := r.userCollection.CountDocuments(ctx, bson.M{"_id": userObjectID, "deleted_at": bson.M{"$exists": false}})
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
@@ -0,0 +1,106 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/giakiet05/lkforum/internal/config"
+	"github.com/giakiet05/lkforum/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SecurityEventFilter narrows down a security event listing; zero values are ignored
+type SecurityEventFilter struct {
+	UserID string
+	Type   model.SecurityEventType
+	From   time.Time
+	To     time.Time
+}
+
+// SecurityEventRepo is append-only: events can be created and listed but never changed
+type SecurityEventRepo interface {
+	Create(ctx context.Context, event *model.SecurityEvent) (*model.SecurityEvent, error)
+	GetPaginated(ctx context.Context, filter SecurityEventFilter, page int, pageSize int) ([]model.SecurityEvent, int64, error)
+}
+
+type securityEventRepo struct {
+	collection *mongo.Collection
+}
+
+func NewSecurityEventRepo(db *mongo.Database) SecurityEventRepo {
+	return &securityEventRepo{
+		collection: db.Collection(config.SecurityEventColName),
+	}
+}
+
+func (r *securityEventRepo) Create(ctx context.Context, event *model.SecurityEvent) (*model.SecurityEvent, error) {
+	result, err := r.collection.InsertOne(ctx, event)
+	if err != nil {
+		return nil, err
+	}
+
+	if oid, ok := result.InsertedID.(primitive.ObjectID); ok {
+		event.ID = oid
+	}
+
+	return event, nil
+}
+
+func (r *securityEventRepo) GetPaginated(ctx context.Context, filter SecurityEventFilter, page int, pageSize int) ([]model.SecurityEvent, int64, error) {
+	query, err := filter.toBSON()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	skip := (page - 1) * pageSize
+	opts := options.Find().SetSkip(int64(skip)).SetLimit(int64(pageSize)).SetSort(bson.M{"created_at": -1})
+
+	cursor, err := r.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	events := []model.SecurityEvent{}
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, 0, err
+	}
+
+	count, err := r.collection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return events, count, nil
+}
+
+func (f SecurityEventFilter) toBSON() (bson.M, error) {
+	query := bson.M{}
+
+	if f.UserID != "" {
+		userObjectID, err := primitive.ObjectIDFromHex(f.UserID)
+		if err != nil {
+			return nil, err
+		}
+		query["user_id"] = userObjectID
+	}
+	if f.Type != "" {
+		query["type"] = f.Type
+	}
+
+	createdAt := bson.M{}
+	if !f.From.IsZero() {
+		createdAt["$gte"] = f.From
+	}
+	if !f.To.IsZero() {
+		createdAt["$lte"] = f.To
+	}
+	if len(createdAt) > 0 {
+		query["created_at"] = createdAt
+	}
+
+	return query, nil
+}
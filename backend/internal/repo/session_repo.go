@@ -0,0 +1,173 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/giakiet05/lkforum/internal/config"
+	"github.com/giakiet05/lkforum/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type SessionRepo interface {
+	Create(ctx context.Context, session *model.Session) (*model.Session, error)
+	GetByID(ctx context.Context, sessionID string) (*model.Session, error)
+	GetActiveByUser(ctx context.Context, userID string) ([]model.Session, error)
+	Rotate(ctx context.Context, sessionID primitive.ObjectID, previousTokenID string, update bson.M) error
+	Revoke(ctx context.Context, userID string, sessionID string) error
+	RevokeAllExcept(ctx context.Context, userID string, exceptSessionID string) ([]string, error)
+}
+
+type sessionRepo struct {
+	collection *mongo.Collection
+}
+
+func NewSessionRepo(db *mongo.Database) SessionRepo {
+	return &sessionRepo{
+		collection: db.Collection(config.SessionColName),
+	}
+}
+
+func (r *sessionRepo) Create(ctx context.Context, session *model.Session) (*model.Session, error) {
+	result, err := r.collection.InsertOne(ctx, session)
+	if err != nil {
+		return nil, err
+	}
+
+	if oid, ok := result.InsertedID.(primitive.ObjectID); ok {
+		session.ID = oid
+	}
+
+	return session, nil
+}
+
+func (r *sessionRepo) GetByID(ctx context.Context, sessionID string) (*model.Session, error) {
+	sessionObjectID, err := primitive.ObjectIDFromHex(sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	var session model.Session
+	if err := r.collection.FindOne(ctx, bson.M{"_id": sessionObjectID}).Decode(&session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// GetActiveByUser lists sessions that are neither revoked nor expired, most recently used first
+func (r *sessionRepo) GetActiveByUser(ctx context.Context, userID string) ([]model.Session, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := activeSessionFilter(userObjectID)
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetSort(bson.M{"last_used_at": -1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	sessions := []model.Session{}
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// Rotate records a refresh, but only if the session still expects the refresh token being used.
+// It fails with ErrNoDocuments when the session was revoked or has moved on to a newer token.
+func (r *sessionRepo) Rotate(ctx context.Context, sessionID primitive.ObjectID, previousTokenID string, update bson.M) error {
+	filter := bson.M{
+		"_id":              sessionID,
+		"refresh_token_id": previousTokenID,
+		"revoked_at":       bson.M{"$exists": false},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": update})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *sessionRepo) Revoke(ctx context.Context, userID string, sessionID string) error {
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return err
+	}
+	sessionObjectID, err := primitive.ObjectIDFromHex(sessionID)
+	if err != nil {
+		return err
+	}
+
+	filter := activeSessionFilter(userObjectID)
+	filter["_id"] = sessionObjectID
+
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"revoked_at": time.Now()}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// RevokeAllExcept revokes every active session of the user but one, an empty exceptSessionID revokes all.
+// It returns the IDs of the sessions it revoked.
+func (r *sessionRepo) RevokeAllExcept(ctx context.Context, userID string, exceptSessionID string) ([]string, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := activeSessionFilter(userObjectID)
+	if exceptSessionID != "" {
+		exceptObjectID, err := primitive.ObjectIDFromHex(exceptSessionID)
+		if err != nil {
+			return nil, err
+		}
+		filter["_id"] = bson.M{"$ne": exceptObjectID}
+	}
+
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var sessions []model.Session
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return nil, err
+	}
+	if len(sessions) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(sessions))
+	revoked := make([]string, 0, len(sessions))
+	for _, session := range sessions {
+		ids = append(ids, session.ID)
+		revoked = append(revoked, session.ID.Hex())
+	}
+
+	if _, err := r.collection.UpdateMany(ctx, bson.M{"_id": bson.M{"$in": ids}}, bson.M{"$set": bson.M{"revoked_at": time.Now()}}); err != nil {
+		return nil, err
+	}
+	return revoked, nil
+}
+
+func activeSessionFilter(userID primitive.ObjectID) bson.M {
+	return bson.M{
+		"user_id":    userID,
+		"revoked_at": bson.M{"$exists": false},
+		"expires_at": bson.M{"$gt": time.Now()},
+	}
+}
@@ -0,0 +1,79 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/giakiet05/lkforum/internal/config"
+	"github.com/giakiet05/lkforum/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type SigningKeyRepo interface {
+	Create(ctx context.Context, key *model.SigningKey) (*model.SigningKey, error)
+	GetUnexpired(ctx context.Context) ([]model.SigningKey, error)
+	DeleteExpired(ctx context.Context) (int64, error)
+	UpdatePrivateKey(ctx context.Context, id primitive.ObjectID, privateKey string) error
+}
+
+type signingKeyRepo struct {
+	collection *mongo.Collection
+}
+
+func NewSigningKeyRepo(db *mongo.Database) SigningKeyRepo {
+	return &signingKeyRepo{
+		collection: db.Collection(config.SigningKeyColName),
+	}
+}
+
+func (r *signingKeyRepo) Create(ctx context.Context, key *model.SigningKey) (*model.SigningKey, error) {
+	result, err := r.collection.InsertOne(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if oid, ok := result.InsertedID.(primitive.ObjectID); ok {
+		key.ID = oid
+	}
+
+	return key, nil
+}
+
+// GetUnexpired lists the keys that still verify tokens, oldest activation first
+func (r *signingKeyRepo) GetUnexpired(ctx context.Context) ([]model.SigningKey, error) {
+	filter := bson.M{"expires_at": bson.M{"$gt": time.Now()}}
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "activates_at", Value: 1}, {Key: "kid", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	keys := []model.SigningKey{}
+	if err := cursor.All(ctx, &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (r *signingKeyRepo) DeleteExpired(ctx context.Context) (int64, error) {
+	res, err := r.collection.DeleteMany(ctx, bson.M{"expires_at": bson.M{"$lte": time.Now()}})
+	if err != nil {
+		return 0, err
+	}
+
+	return res.DeletedCount, nil
+}
+
+func (r *signingKeyRepo) UpdatePrivateKey(ctx context.Context, id primitive.ObjectID, privateKey string) error {
+	res, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"private_key": privateKey}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"regexp"
 	"time"
 
 	"github.com/giakiet05/lkforum/internal/config"
@@ -12,6 +13,13 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// UserSearchFilter narrows down an admin user search; zero values are ignored
+type UserSearchFilter struct {
+	Query          string // case-insensitive match on username or email
+	Role           model.Role
+	IncludeDeleted bool
+}
+
 type UserRepo interface {
 	Create(ctx context.Context, user *model.User) (*model.User, error)
 	Update(ctx context.Context, user *model.User) (*model.User, error)
@@ -22,14 +30,39 @@ type UserRepo interface {
 	GetByEmail(ctx context.Context, email string) (*model.User, error)
 	GetAll(ctx context.Context) ([]*model.User, error)
 	GetPaginated(ctx context.Context, page, pageSize int) ([]*model.User, int64, error)
+
+	Search(ctx context.Context, filter UserSearchFilter, page, pageSize int) ([]*model.User, int64, error)
+	GetDeletedByID(ctx context.Context, id string) (*model.User, error)
+	Restore(ctx context.Context, id string) error
+
+	SetSuspension(ctx context.Context, id string, start time.Time, end *time.Time, reason string) (*model.User, error)
+	ClearSuspension(ctx context.Context, id string) (*model.User, error)
+
+	MarkEmailVerified(ctx context.Context, id string, email string, at time.Time) error
+	MarkAllEmailsVerified(ctx context.Context, at time.Time) (int64, error)
+
+	SetBot(ctx context.Context, id string, isBot bool) error
+	AssignTemplateToUnscopedAdmins(ctx context.Context, template string) (int64, error)
+
+	SetTwoFactor(ctx context.Context, id string, twoFactor *model.TwoFactor) error
+	ClaimTOTPStep(ctx context.Context, id string, step int64) error
+	ConsumeRecoveryCode(ctx context.Context, id string, codeHash string) error
+
+	GetKarma(ctx context.Context, id string) (int, error)
 }
 
 type userRepo struct {
-	userCollection *mongo.Collection
+	userCollection    *mongo.Collection
+	postCollection    *mongo.Collection
+	commentCollection *mongo.Collection
 }
 
 func NewUserRepo(db *mongo.Database) UserRepo {
-	return &userRepo{userCollection: db.Collection(config.UserColName)}
+	return &userRepo{
+		userCollection:    db.Collection(config.UserColName),
+		postCollection:    db.Collection(config.PostColName),
+		commentCollection: db.Collection(config.CommentColName),
+	}
 }
 
 func (r *userRepo) GetAll(ctx context.Context) ([]*model.User, error) {
@@ -150,3 +183,305 @@ func (r *userRepo) GetPaginated(ctx context.Context, page, pageSize int) ([]*mod
 
 	return users, count, nil
 }
+
+// Search lists users for admins, optionally including soft-deleted accounts
+func (r *userRepo) Search(ctx context.Context, filter UserSearchFilter, page, pageSize int) ([]*model.User, int64, error) {
+	query := bson.M{}
+	if !filter.IncludeDeleted {
+		query["deleted_at"] = bson.M{"$exists": false}
+	}
+	if filter.Role != "" {
+		query["role"] = filter.Role
+	}
+	if filter.Query != "" {
+		pattern := primitive.Regex{Pattern: regexp.QuoteMeta(filter.Query), Options: "i"}
+		query["$or"] = []bson.M{
+			{"username": pattern},
+			{"email": pattern},
+		}
+	}
+
+	skip := (page - 1) * pageSize
+	opts := options.Find().SetSkip(int64(skip)).SetLimit(int64(pageSize)).SetSort(bson.M{"create_at": -1})
+
+	cursor, err := r.userCollection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer func() {
+		_ = cursor.Close(ctx)
+	}()
+
+	var users []*model.User
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, 0, err
+	}
+
+	count, err := r.userCollection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return users, count, nil
+}
+
+func (r *userRepo) GetDeletedByID(ctx context.Context, id string) (*model.User, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	filter := bson.M{"_id": objectID, "deleted_at": bson.M{"$exists": true}}
+	var user model.User
+	err = r.userCollection.FindOne(ctx, filter).Decode(&user)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Restore undoes a soft delete
+func (r *userRepo) Restore(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+	filter := bson.M{"_id": objectID, "deleted_at": bson.M{"$exists": true}}
+	update := bson.M{"$unset": bson.M{"deleted_at": ""}}
+	result, err := r.userCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// SetSuspension stores a site-wide suspension on the user; a nil end suspends permanently
+func (r *userRepo) SetSuspension(ctx context.Context, id string, start time.Time, end *time.Time, reason string) (*model.User, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	set := bson.M{
+		"role_content.user.ban_start":  start,
+		"role_content.user.ban_reason": reason,
+	}
+	update := bson.M{"$set": set}
+	if end != nil {
+		set["role_content.user.ban_end"] = *end
+	} else {
+		update["$unset"] = bson.M{"role_content.user.ban_end": ""}
+	}
+
+	filter := bson.M{"_id": objectID, "deleted_at": bson.M{"$exists": false}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var user model.User
+	if err := r.userCollection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *userRepo) ClearSuspension(ctx context.Context, id string) (*model.User, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := bson.M{"_id": objectID, "deleted_at": bson.M{"$exists": false}}
+	update := bson.M{"$unset": bson.M{
+		"role_content.user.ban_start":  "",
+		"role_content.user.ban_end":    "",
+		"role_content.user.ban_reason": "",
+	}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var user model.User
+	if err := r.userCollection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// MarkEmailVerified confirms the address, as long as the user has not changed it since the link was sent
+func (r *userRepo) MarkEmailVerified(ctx context.Context, id string, email string, at time.Time) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{"_id": objectID, "email": email, "deleted_at": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{"verified_email": email, "email_verified_at": at}}
+
+	result, err := r.userCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// AssignTemplateToUnscopedAdmins sets the template of every admin that has neither a template nor
+// individual permissions, and returns how many were updated
+func (r *userRepo) AssignTemplateToUnscopedAdmins(ctx context.Context, template string) (int64, error) {
+	filter := bson.M{
+		"role":                    model.AdminRole,
+		"role_content.admin.name": bson.M{"$in": bson.A{nil, ""}},
+		"$or": bson.A{
+			bson.M{"role_content.admin.permissions": bson.M{"$exists": false}},
+			bson.M{"role_content.admin.permissions": bson.M{"$size": 0}},
+		},
+	}
+	update := bson.M{"$set": bson.M{"role_content.admin.name": template}}
+
+	result, err := r.userCollection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+// MarkAllEmailsVerified confirms the current address of every user that has one and never verified
+// it, and returns how many were updated
+func (r *userRepo) MarkAllEmailsVerified(ctx context.Context, at time.Time) (int64, error) {
+	filter := bson.M{
+		"email":             bson.M{"$nin": bson.A{nil, ""}},
+		"email_verified_at": bson.M{"$exists": false},
+	}
+	update := mongo.Pipeline{{{Key: "$set", Value: bson.M{"verified_email": "$email", "email_verified_at": at}}}}
+
+	result, err := r.userCollection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+// SetBot flags or unflags the account as a bot, and its posts and comments with it so they are badged
+func (r *userRepo) SetBot(ctx context.Context, id string, isBot bool) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	update := bson.M{"$set": bson.M{"is_bot": true}}
+	authorUpdate := bson.M{"$set": bson.M{"author_is_bot": true}}
+	if !isBot {
+		update = bson.M{"$unset": bson.M{"is_bot": ""}}
+		authorUpdate = bson.M{"$unset": bson.M{"author_is_bot": ""}}
+	}
+
+	result, err := r.userCollection.UpdateOne(ctx, bson.M{"_id": objectID, "deleted_at": bson.M{"$exists": false}}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	if _, err := r.postCollection.UpdateMany(ctx, bson.M{"author_id": objectID}, authorUpdate); err != nil {
+		return err
+	}
+	_, err = r.commentCollection.UpdateMany(ctx, bson.M{"author_id": objectID}, authorUpdate)
+	return err
+}
+
+// SetTwoFactor replaces the user's two-factor setup, a nil setup removes it
+func (r *userRepo) SetTwoFactor(ctx context.Context, id string, twoFactor *model.TwoFactor) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	update := bson.M{"$set": bson.M{"two_factor": twoFactor}}
+	if twoFactor == nil {
+		update = bson.M{"$unset": bson.M{"two_factor": ""}}
+	}
+
+	result, err := r.userCollection.UpdateOne(ctx, bson.M{"_id": objectID, "deleted_at": bson.M{"$exists": false}}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// ClaimTOTPStep records the time step of an accepted code. It fails with ErrNoDocuments when the step
+// is not newer than the last one claimed, which stops the same code from being used twice.
+func (r *userRepo) ClaimTOTPStep(ctx context.Context, id string, step int64) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{"_id": objectID, "two_factor.last_used_step": bson.M{"$not": bson.M{"$gte": step}}}
+	result, err := r.userCollection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"two_factor.last_used_step": step}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// ConsumeRecoveryCode removes a recovery code hash, failing with ErrNoDocuments if the user does not have it
+func (r *userRepo) ConsumeRecoveryCode(ctx context.Context, id string, codeHash string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{"_id": objectID, "two_factor.recovery_codes": codeHash}
+	result, err := r.userCollection.UpdateOne(ctx, filter, bson.M{"$pull": bson.M{"two_factor.recovery_codes": codeHash}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// GetKarma sums the upvotes minus downvotes of the user's posts
+func (r *userRepo) GetKarma(ctx context.Context, id string) (int, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return 0, err
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"author_id": objectID, "is_deleted": bson.M{"$ne": true}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   nil,
+			"karma": bson.M{"$sum": bson.M{"$subtract": bson.A{"$votes_count.up", "$votes_count.down"}}},
+		}}},
+	}
+
+	cursor, err := r.postCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = cursor.Close(ctx)
+	}()
+
+	var results []struct {
+		Karma int `bson:"karma"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return 0, err
+	}
+	if len(results) == 0 {
+		return 0, nil
+	}
+
+	return results[0].Karma, nil
+}
@@ -0,0 +1,18 @@
+package route
+
+import (
+	"github.com/giakiet05/lkforum/internal/controller"
+	"github.com/giakiet05/lkforum/internal/middleware"
+	"github.com/giakiet05/lkforum/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterAdminAppealRoutes(rg *gin.RouterGroup, c *controller.AppealController) {
+	appeals := rg.Group("/appeals")
+
+	// Admin routes (require authentication and admin role)
+	appeals.Use(middleware.AuthMiddleware(), middleware.RequireAdmin())
+	{
+		appeals.GET("", middleware.RequirePermission(model.PermissionReportsView), c.GetAppeals)
+	}
+}
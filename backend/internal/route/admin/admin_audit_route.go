@@ -0,0 +1,26 @@
+package route
+
+import (
+	"github.com/giakiet05/lkforum/internal/controller"
+	"github.com/giakiet05/lkforum/internal/middleware"
+	"github.com/giakiet05/lkforum/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterAdminAuditRoutes(rg *gin.RouterGroup, c *controller.AdminController) {
+	audit := rg.Group("/audit_log")
+
+	// Admin routes (require authentication and admin role)
+	audit.Use(middleware.AuthMiddleware(), middleware.RequireAdmin())
+	{
+		audit.GET("", middleware.RequirePermission(model.PermissionAuditView), c.GetAuditLog)
+	}
+
+	securityEvents := rg.Group("/security_events")
+
+	// Admin routes (require authentication and admin role)
+	securityEvents.Use(middleware.AuthMiddleware(), middleware.RequireAdmin())
+	{
+		securityEvents.GET("", middleware.RequirePermission(model.PermissionAuditView), c.GetSecurityEvents)
+	}
+}
@@ -0,0 +1,19 @@
+package route
+
+import (
+	"github.com/giakiet05/lkforum/internal/controller"
+	"github.com/giakiet05/lkforum/internal/middleware"
+	"github.com/giakiet05/lkforum/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterAdminCommunityRoutes(rg *gin.RouterGroup, c *controller.AdminController) {
+	communities := rg.Group("/communities")
+
+	// Admin routes (require authentication and admin role)
+	communities.Use(middleware.AuthMiddleware(), middleware.RequireAdmin())
+	{
+		communities.POST("/:community_id/ban", middleware.RequirePermission(model.PermissionCommunitiesBan), c.BanCommunity)
+		communities.DELETE("/:community_id/ban", middleware.RequirePermission(model.PermissionCommunitiesBan), c.UnbanCommunity)
+	}
+}
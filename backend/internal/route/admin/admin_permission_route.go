@@ -0,0 +1,17 @@
+package route
+
+import (
+	"github.com/giakiet05/lkforum/internal/controller"
+	"github.com/giakiet05/lkforum/internal/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterAdminPermissionRoutes(rg *gin.RouterGroup, c *controller.AdminController) {
+	permissions := rg.Group("/permissions")
+
+	// Admin routes (require authentication and admin role)
+	permissions.Use(middleware.AuthMiddleware(), middleware.RequireAdmin())
+	{
+		permissions.GET("", c.GetPermissionCatalog)
+	}
+}
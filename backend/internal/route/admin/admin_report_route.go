@@ -0,0 +1,18 @@
+package route
+
+import (
+	"github.com/giakiet05/lkforum/internal/controller"
+	"github.com/giakiet05/lkforum/internal/middleware"
+	"github.com/giakiet05/lkforum/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterAdminReportRoutes(rg *gin.RouterGroup, c *controller.AdminController) {
+	reports := rg.Group("/reports")
+
+	// Admin routes (require authentication and admin role)
+	reports.Use(middleware.AuthMiddleware(), middleware.RequireAdmin())
+	{
+		reports.GET("", middleware.RequirePermission(model.PermissionReportsView), c.GetReports)
+	}
+}
@@ -1 +1,26 @@
 package route
+
+import (
+	"github.com/giakiet05/lkforum/internal/controller"
+	"github.com/giakiet05/lkforum/internal/middleware"
+	"github.com/giakiet05/lkforum/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterAdminUserRoutes(rg *gin.RouterGroup, c *controller.AdminController) {
+	users := rg.Group("/users")
+
+	// Admin routes (require authentication and admin role)
+	users.Use(middleware.AuthMiddleware(), middleware.RequireAdmin())
+	{
+		users.GET("", middleware.RequirePermission(model.PermissionUsersView), c.SearchUsers)
+		users.PUT("/:user_id/role", middleware.RequirePermission(model.PermissionAdminsManage), c.ChangeUserRole)
+		users.PUT("/:user_id/permissions", middleware.RequirePermission(model.PermissionAdminsManage), c.UpdateAdminPermissions)
+		users.POST("/:user_id/reset_password", middleware.RequirePermission(model.PermissionUsersResetPassword), c.ResetUserPassword)
+		users.POST("/:user_id/unlock", middleware.RequirePermission(model.PermissionUsersResetPassword), c.UnlockUser)
+		users.PUT("/:user_id/bot", middleware.RequirePermission(model.PermissionUsersBan), c.SetBot)
+		users.POST("/:user_id/restore", middleware.RequirePermission(model.PermissionUsersRestore), c.RestoreUser)
+		users.POST("/:user_id/suspend", middleware.RequirePermission(model.PermissionUsersBan), c.SuspendUser)
+		users.DELETE("/:user_id/suspend", middleware.RequirePermission(model.PermissionUsersBan), c.UnsuspendUser)
+	}
+}
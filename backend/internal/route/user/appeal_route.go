@@ -0,0 +1,33 @@
+package route
+
+import (
+	"github.com/giakiet05/lkforum/internal/controller"
+	"github.com/giakiet05/lkforum/internal/middleware"
+	"github.com/giakiet05/lkforum/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterAppealRoutes(rg *gin.RouterGroup, c *controller.AppealController) {
+	appeals := rg.Group("/appeals")
+
+	// Public routes: suspended users cannot log in, they appeal with their password
+	appeals.POST("/suspension", middleware.RateLimit(middleware.RateLimitLogin), c.FileSuspensionAppeal)
+
+	// Protected routes (require authentication)
+	protected := appeals.Group("")
+	protected.Use(middleware.AuthMiddleware())
+	{
+		protected.POST("", middleware.RateLimit(middleware.RateLimitCreateContent), c.FileAppeal)
+		protected.GET("", c.GetMyAppeals)
+		protected.GET("/:appeal_id", c.GetAppealByID)
+		protected.PUT("/:appeal_id/review", middleware.RequireScope(model.TokenScopeModerate), c.ReviewAppeal)
+	}
+
+	communities := rg.Group("/communities")
+
+	// Protected routes (require authentication)
+	communities.Use(middleware.AuthMiddleware())
+	{
+		communities.GET("/:community_id/appeals", middleware.RequireScope(model.TokenScopeModerate), c.GetCommunityAppeals)
+	}
+}
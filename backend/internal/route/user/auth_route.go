@@ -2,12 +2,18 @@ package route
 
 import (
 	"github.com/giakiet05/lkforum/internal/controller"
+	"github.com/giakiet05/lkforum/internal/middleware"
 	"github.com/gin-gonic/gin"
 )
 
 func RegisterAuthRoutes(rg *gin.RouterGroup, c *controller.UserController) {
 	auth := rg.Group("/auth")
-	auth.POST("/register", c.RegisterUser)
-	auth.POST("/login", c.Login)
+	auth.POST("/register", middleware.RateLimit(middleware.RateLimitRegister), c.RegisterUser)
+	auth.POST("/login", middleware.RateLimit(middleware.RateLimitLogin), c.Login)
 	auth.POST("/refresh", c.RefreshToken)
+	auth.POST("/logout", middleware.AuthMiddleware(), middleware.RequireSessionLogin(), c.Logout)
+	auth.POST("/verify-email", c.VerifyEmail)
+	auth.POST("/forgot-password", middleware.RateLimit(middleware.RateLimitPasswordReset), c.ForgotPassword)
+	auth.POST("/reset-password", middleware.RateLimit(middleware.RateLimitPasswordReset), c.ResetPassword)
+	auth.POST("/verify-email/resend", middleware.AuthMiddleware(), middleware.RateLimit(middleware.RateLimitVerifyEmail), c.ResendVerificationEmail)
 }
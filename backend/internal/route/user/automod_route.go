@@ -0,0 +1,20 @@
+package route
+
+import (
+	"github.com/giakiet05/lkforum/internal/controller"
+	"github.com/giakiet05/lkforum/internal/middleware"
+	"github.com/giakiet05/lkforum/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterAutoModRoutes(rg *gin.RouterGroup, c *controller.AutoModController) {
+	automod := rg.Group("/communities/:community_id/automod")
+
+	// Protected routes (require authentication)
+	automod.Use(middleware.AuthMiddleware(), middleware.RequireScope(model.TokenScopeModerate))
+	{
+		automod.GET("", c.GetRules)
+		automod.PUT("", c.UpdateRules)
+		automod.POST("/test", c.TestRules)
+	}
+}
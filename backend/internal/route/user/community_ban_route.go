@@ -0,0 +1,20 @@
+package route
+
+import (
+	"github.com/giakiet05/lkforum/internal/controller"
+	"github.com/giakiet05/lkforum/internal/middleware"
+	"github.com/giakiet05/lkforum/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterCommunityBanRoutes(rg *gin.RouterGroup, c *controller.CommunityBanController) {
+	bans := rg.Group("/communities/:community_id/bans")
+
+	// Protected routes (require authentication)
+	bans.Use(middleware.AuthMiddleware(), middleware.RequireScope(model.TokenScopeModerate))
+	{
+		bans.POST("", c.BanUser)
+		bans.GET("", c.GetCommunityBans)
+		bans.DELETE("/:user_id", c.LiftBan)
+	}
+}
@@ -3,6 +3,7 @@ package route
 import (
 	"github.com/giakiet05/lkforum/internal/controller"
 	"github.com/giakiet05/lkforum/internal/middleware"
+	"github.com/giakiet05/lkforum/internal/model"
 	"github.com/gin-gonic/gin"
 )
 
@@ -12,14 +13,18 @@ func RegisterCommunityRoutes(rg *gin.RouterGroup, c *controller.CommunityControl
 	// Protected routes (require authentication)
 	communities.Use(middleware.AuthMiddleware())
 	{
-		communities.POST("", c.CreateCommunity)
+		communities.POST("", middleware.RequireVerifiedEmail(), middleware.RateLimit(middleware.RateLimitCreateCommunity), c.CreateCommunity)
 		communities.GET(":community_id", c.GetCommunityByID)
 		communities.GET("filter", c.GetCommunitiesFilter)
 		communities.GET("moderator/:moderator_id", c.GetCommunityByModeratorID)
 		communities.GET("", c.GetAllCommunities)
-		communities.PUT("", c.UpdateCommunity)
-		communities.PUT("/add_moderator", c.AddModerator)
-		communities.PUT("/remove_moderator", c.RemoveModerator)
-		communities.DELETE("/:community_id", c.DeleteCommunityByID)
+		communities.PUT("", middleware.RequireScope(model.TokenScopeModerate), c.UpdateCommunity)
+		communities.PUT("/add_moderator", middleware.RequireScope(model.TokenScopeModerate), c.AddModerator)
+		communities.PUT("/remove_moderator", middleware.RequireScope(model.TokenScopeModerate), c.RemoveModerator)
+		communities.PUT("/moderator_permissions", middleware.RequireScope(model.TokenScopeModerate), c.UpdateModeratorPermissions)
+		communities.POST("/:community_id/transfer", middleware.RequireScope(model.TokenScopeModerate), c.OfferOwnership)
+		communities.POST("/:community_id/transfer/accept", c.AcceptOwnership)
+		communities.DELETE("/:community_id/transfer", middleware.RequireScope(model.TokenScopeModerate), c.CancelOwnershipTransfer)
+		communities.DELETE("/:community_id", middleware.RequireScope(model.TokenScopeModerate), c.DeleteCommunityByID)
 	}
 }
@@ -0,0 +1,20 @@
+package route
+
+import (
+	"github.com/giakiet05/lkforum/internal/controller"
+	"github.com/giakiet05/lkforum/internal/middleware"
+	"github.com/giakiet05/lkforum/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterContentFilterRoutes(rg *gin.RouterGroup, c *controller.ContentFilterController) {
+	filters := rg.Group("/communities/:community_id/filters")
+
+	// Protected routes (require authentication)
+	filters.Use(middleware.AuthMiddleware(), middleware.RequireScope(model.TokenScopeModerate))
+	{
+		filters.GET("", c.GetFilter)
+		filters.PUT("", c.UpdateFilter)
+		filters.POST("/test", c.TestContent)
+	}
+}
@@ -0,0 +1,25 @@
+package route
+
+import (
+	"github.com/giakiet05/lkforum/internal/controller"
+	"github.com/giakiet05/lkforum/internal/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterConversationRoutes(rg *gin.RouterGroup, c *controller.ConversationController) {
+	conversations := rg.Group("/conversations")
+
+	// Protected routes (require authentication)
+	conversations.Use(middleware.AuthMiddleware())
+	{
+		conversations.GET("", c.GetInbox)
+		conversations.GET("/requests", c.GetMessageRequests)
+		conversations.POST("/direct", middleware.RequireVerifiedEmail(), middleware.RateLimit(middleware.RateLimitCreateContent), c.SendDirectMessage)
+		conversations.GET("/:conversation_id/messages", c.GetMessages)
+		conversations.PUT("/:conversation_id/read", c.MarkAsRead)
+		conversations.PUT("/:conversation_id/accept", c.AcceptRequest)
+		conversations.PUT("/:conversation_id/decline", c.DeclineRequest)
+		conversations.PUT("/:conversation_id/block", c.BlockRequest)
+		conversations.DELETE("/blocks/:user_id", c.UnblockUser)
+	}
+}
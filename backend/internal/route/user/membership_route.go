@@ -12,7 +12,7 @@ func RegisterMembershipRoutes(rg *gin.RouterGroup, c *controller.MembershipContr
 	// Protected routes (require authentication)
 	memberships.Use(middleware.AuthMiddleware())
 	{
-		memberships.POST("", c.CreateMembership)
+		memberships.POST("", middleware.RateLimit(middleware.RateLimitMembership), c.CreateMembership)
 		memberships.GET("", c.GetAllMemberships)
 		memberships.GET("/user/:user_id", c.GetMembershipByUserID)
 		memberships.GET("/community/:community_id", c.GetMembershipByCommunityID)
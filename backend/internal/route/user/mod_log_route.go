@@ -0,0 +1,23 @@
+package route
+
+import (
+	"github.com/giakiet05/lkforum/internal/controller"
+	"github.com/giakiet05/lkforum/internal/middleware"
+	"github.com/giakiet05/lkforum/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterModLogRoutes(rg *gin.RouterGroup, c *controller.ModLogController) {
+	modLogs := rg.Group("/modlog")
+
+	// Public routes
+	modLogs.GET("/community/:community_id/public", c.GetPublicCommunityModLogs)
+
+	// Protected routes (require authentication)
+	protected := modLogs.Group("")
+	protected.Use(middleware.AuthMiddleware())
+	{
+		protected.GET("", middleware.RequirePermission(model.PermissionAuditView), c.GetModLogs)
+		protected.GET("/community/:community_id", middleware.RequireScope(model.TokenScopeModerate), c.GetCommunityModLogs)
+	}
+}
@@ -0,0 +1,22 @@
+package route
+
+import (
+	"github.com/giakiet05/lkforum/internal/controller"
+	"github.com/giakiet05/lkforum/internal/middleware"
+	"github.com/giakiet05/lkforum/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterModeratorInviteRoutes(rg *gin.RouterGroup, c *controller.ModeratorInviteController) {
+	communities := rg.Group("/communities")
+
+	// Protected routes (require authentication)
+	communities.Use(middleware.AuthMiddleware())
+	{
+		communities.GET("/moderator_invites", c.GetMyInvites)
+		communities.POST("/moderator_invites/:invite_id/accept", c.AcceptInvite)
+		communities.POST("/moderator_invites/:invite_id/decline", c.DeclineInvite)
+		communities.DELETE("/moderator_invites/:invite_id", middleware.RequireScope(model.TokenScopeModerate), c.CancelInvite)
+		communities.GET("/:community_id/moderator_invites", middleware.RequireScope(model.TokenScopeModerate), c.GetCommunityInvites)
+	}
+}
@@ -0,0 +1,34 @@
+package route
+
+import (
+	"github.com/giakiet05/lkforum/internal/controller"
+	"github.com/giakiet05/lkforum/internal/middleware"
+	"github.com/giakiet05/lkforum/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterModmailRoutes(rg *gin.RouterGroup, c *controller.ModmailController) {
+	modmail := rg.Group("/modmail")
+
+	// Protected routes (require authentication)
+	modmail.Use(middleware.AuthMiddleware())
+	{
+		modmail.POST("", middleware.RequireVerifiedEmail(), middleware.RateLimit(middleware.RateLimitCreateContent), c.CreateThread)
+		modmail.GET("", c.GetMyThreads)
+		modmail.GET("/:thread_id", c.GetThread)
+		modmail.GET("/:thread_id/messages", c.GetMessages)
+		modmail.POST("/:thread_id/messages", middleware.RateLimit(middleware.RateLimitCreateContent), c.Reply)
+		modmail.PUT("/:thread_id/archive", c.ArchiveThread)
+		modmail.PUT("/:thread_id/unarchive", c.UnarchiveThread)
+		modmail.PUT("/:thread_id/highlight", c.HighlightThread)
+		modmail.PUT("/:thread_id/unhighlight", c.UnhighlightThread)
+	}
+
+	communities := rg.Group("/communities")
+
+	// Protected routes (require authentication)
+	communities.Use(middleware.AuthMiddleware())
+	{
+		communities.GET("/:community_id/modmail", middleware.RequireScope(model.TokenScopeModerate), c.GetCommunityThreads)
+	}
+}
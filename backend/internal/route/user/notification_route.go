@@ -0,0 +1,17 @@
+package route
+
+import (
+	"github.com/giakiet05/lkforum/internal/controller"
+	"github.com/giakiet05/lkforum/internal/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterNotificationRoutes(rg *gin.RouterGroup, c *controller.NotificationController) {
+	notifications := rg.Group("/notifications")
+
+	// Protected routes (require authentication)
+	notifications.Use(middleware.AuthMiddleware())
+	{
+		notifications.GET("", c.GetMyNotifications)
+	}
+}
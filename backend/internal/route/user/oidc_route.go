@@ -0,0 +1,25 @@
+package route
+
+import (
+	"github.com/giakiet05/lkforum/internal/controller"
+	"github.com/giakiet05/lkforum/internal/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterOIDCRoutes(rg *gin.RouterGroup, c *controller.OIDCController) {
+	oidc := rg.Group("/auth/oidc")
+	oidc.GET("/providers", c.GetProviders)
+	oidc.GET("/:provider/login", c.StartLogin)
+	oidc.POST("/:provider/callback", middleware.RateLimit(middleware.RateLimitLogin), c.Callback)
+	oidc.POST("/signup", middleware.RateLimit(middleware.RateLimitRegister), c.CompleteSignup)
+	oidc.POST("/:provider/link", middleware.AuthMiddleware(), middleware.RequireSessionLogin(), c.StartLink)
+
+	identities := rg.Group("/auth/identities")
+
+	// Protected routes (require authentication)
+	identities.Use(middleware.AuthMiddleware(), middleware.RequireSessionLogin())
+	{
+		identities.GET("", c.GetIdentities)
+		identities.DELETE("/:provider", c.Unlink)
+	}
+}
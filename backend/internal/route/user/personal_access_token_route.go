@@ -0,0 +1,19 @@
+package route
+
+import (
+	"github.com/giakiet05/lkforum/internal/controller"
+	"github.com/giakiet05/lkforum/internal/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterPersonalAccessTokenRoutes(rg *gin.RouterGroup, c *controller.PersonalAccessTokenController) {
+	tokens := rg.Group("/auth/tokens")
+
+	// Protected routes (require a signed in session, a token cannot create more tokens)
+	tokens.Use(middleware.AuthMiddleware(), middleware.RequireSessionLogin())
+	{
+		tokens.POST("", c.CreateToken)
+		tokens.GET("", c.GetTokens)
+		tokens.DELETE("/:token_id", c.RevokeToken)
+	}
+}
@@ -0,0 +1,26 @@
+package route
+
+import (
+	"github.com/giakiet05/lkforum/internal/controller"
+	"github.com/giakiet05/lkforum/internal/middleware"
+	"github.com/giakiet05/lkforum/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRemovalRoutes(rg *gin.RouterGroup, c *controller.RemovalController) {
+	community := rg.Group("/communities/:community_id")
+
+	// Protected routes (require authentication)
+	community.Use(middleware.AuthMiddleware(), middleware.RequireScope(model.TokenScopeModerate))
+	{
+		community.GET("/removal_reasons", c.GetReasons)
+		community.POST("/removal_reasons", c.CreateReason)
+		community.PUT("/removal_reasons/:reason_id", c.UpdateReason)
+		community.DELETE("/removal_reasons/:reason_id", c.DeleteReason)
+
+		community.PUT("/posts/:post_id/remove", c.RemovePost)
+		community.PUT("/posts/:post_id/restore", c.RestorePost)
+		community.PUT("/comments/:comment_id/remove", c.RemoveComment)
+		community.PUT("/comments/:comment_id/restore", c.RestoreComment)
+	}
+}
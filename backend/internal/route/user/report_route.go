@@ -0,0 +1,23 @@
+package route
+
+import (
+	"github.com/giakiet05/lkforum/internal/controller"
+	"github.com/giakiet05/lkforum/internal/middleware"
+	"github.com/giakiet05/lkforum/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterReportRoutes(rg *gin.RouterGroup, c *controller.ReportController) {
+	reports := rg.Group("/reports")
+
+	// Protected routes (require authentication)
+	reports.Use(middleware.AuthMiddleware())
+	{
+		reports.POST("", middleware.RateLimit(middleware.RateLimitCreateContent), c.CreateReport)
+		reports.GET("/reasons", c.GetReportReasons)
+		reports.GET("/community/:community_id", middleware.RequireScope(model.TokenScopeModerate), c.GetCommunityReports)
+		reports.GET("/users", middleware.RequirePermission(model.PermissionReportsView), c.GetUserReports)
+		reports.GET("/:report_id", c.GetReportByID)
+		reports.PUT("/:report_id/status", middleware.RequireScope(model.TokenScopeModerate), c.UpdateReportStatus)
+	}
+}
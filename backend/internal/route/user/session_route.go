@@ -0,0 +1,19 @@
+package route
+
+import (
+	"github.com/giakiet05/lkforum/internal/controller"
+	"github.com/giakiet05/lkforum/internal/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterSessionRoutes(rg *gin.RouterGroup, c *controller.SessionController) {
+	sessions := rg.Group("/auth/sessions")
+
+	// Protected routes (require authentication)
+	sessions.Use(middleware.AuthMiddleware(), middleware.RequireSessionLogin())
+	{
+		sessions.GET("", c.GetSessions)
+		sessions.DELETE("", c.RevokeOtherSessions)
+		sessions.DELETE("/:session_id", c.RevokeSession)
+	}
+}
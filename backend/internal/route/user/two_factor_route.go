@@ -0,0 +1,22 @@
+package route
+
+import (
+	"github.com/giakiet05/lkforum/internal/controller"
+	"github.com/giakiet05/lkforum/internal/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterTwoFactorRoutes(rg *gin.RouterGroup, c *controller.TwoFactorController) {
+	rg.POST("/auth/login/2fa", middleware.RateLimit(middleware.RateLimitLogin), c.VerifyLogin)
+
+	twoFactor := rg.Group("/auth/2fa")
+
+	// Protected routes (require authentication)
+	twoFactor.Use(middleware.AuthMiddleware(), middleware.RequireSessionLogin())
+	{
+		twoFactor.POST("/enroll", c.Enroll)
+		twoFactor.POST("/confirm", middleware.RateLimit(middleware.RateLimitLogin), c.Confirm)
+		twoFactor.POST("/disable", middleware.RateLimit(middleware.RateLimitLogin), c.Disable)
+		twoFactor.POST("/recovery_codes", middleware.RateLimit(middleware.RateLimitLogin), c.RegenerateRecoveryCodes)
+	}
+}
@@ -14,8 +14,8 @@ func RegisterUserRoutes(rg *gin.RouterGroup, c *controller.UserController) {
 	{
 		users.GET("", c.GetUsers)
 		users.GET(":id", c.GetUserByID)
-		users.PUT(":id", c.UpdateUser)
-		users.PUT(":id/change-password", c.ChangePassword)
-		users.DELETE(":id", c.DeleteUser)
+		users.PUT(":id", middleware.RequireSessionLogin(), c.UpdateUser)
+		users.PUT(":id/change-password", middleware.RequireSessionLogin(), c.ChangePassword)
+		users.DELETE(":id", middleware.RequireSessionLogin(), c.DeleteUser)
 	}
 }
@@ -0,0 +1,11 @@
+package route
+
+import (
+	"github.com/giakiet05/lkforum/internal/controller"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterWellKnownRoutes(rg *gin.RouterGroup, c *controller.JWKSController) {
+	wellKnown := rg.Group("/.well-known")
+	wellKnown.GET("/jwks.json", c.GetJWKS)
+}
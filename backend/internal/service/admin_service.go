@@ -0,0 +1,610 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/giakiet05/lkforum/internal/apperror"
+	"github.com/giakiet05/lkforum/internal/auth"
+	"github.com/giakiet05/lkforum/internal/dto"
+	"github.com/giakiet05/lkforum/internal/mail"
+	"github.com/giakiet05/lkforum/internal/model"
+	"github.com/giakiet05/lkforum/internal/repo"
+	"github.com/giakiet05/lkforum/internal/util"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// AdminService holds site-wide administrative actions. Every action is recorded in the mod log.
+type AdminService interface {
+	SearchUsers(filter repo.UserSearchFilter, page int, pageSize int) (*dto.PaginatedAdminUsersResponse, error)
+	ChangeUserRole(userID string, req *dto.ChangeRoleRequest, adminID string) (*dto.AdminUserResponse, error)
+	UpdateAdminPermissions(userID string, req *dto.UpdateAdminPermissionsRequest, adminID string) (*dto.AdminUserResponse, error)
+	GetPermissionCatalog() *dto.PermissionCatalogResponse
+	ResetUserPassword(userID string, adminID string) (*dto.PasswordResetLinkResponse, error)
+	RestoreUser(userID string, adminID string) error
+	SuspendUser(userID string, req *dto.SuspendUserRequest, adminID string) (*dto.SuspensionResponse, error)
+	UnsuspendUser(userID string, adminID string) error
+	UnlockUser(userID string, adminID string, client dto.ClientInfo) error
+	SetBot(userID string, isBot bool, adminID string) (*dto.AdminUserResponse, error)
+
+	BanCommunity(communityID string, req *dto.BanCommunityRequest, adminID string) (*model.Community, error)
+	UnbanCommunity(communityID string, adminID string) (*model.Community, error)
+
+	GetReports(communityID string, targetType model.ReportTargetType, status model.ReportStatus, page int, pageSize int) (*dto.PaginatedReportsResponse, error)
+	GetAuditLog(filter repo.ModLogFilter, page int, pageSize int) (*dto.PaginatedModLogsResponse, error)
+	GetSecurityEvents(filter repo.SecurityEventFilter, page int, pageSize int) (*dto.PaginatedSecurityEventsResponse, error)
+}
+
+type adminService struct {
+	userRepo             repo.UserRepo
+	communityRepo        repo.CommunityRepo
+	reportRepo           repo.ReportRepo
+	modLogService        ModLogService
+	securityEventService SecurityEventService
+	loginAttemptService  LoginAttemptService
+	mailer               mail.Mailer
+}
+
+func NewAdminService(
+	userRepo repo.UserRepo,
+	communityRepo repo.CommunityRepo,
+	reportRepo repo.ReportRepo,
+	modLogService ModLogService,
+	securityEventService SecurityEventService,
+	loginAttemptService LoginAttemptService,
+	mailer mail.Mailer,
+) AdminService {
+	return &adminService{
+		userRepo:             userRepo,
+		communityRepo:        communityRepo,
+		reportRepo:           reportRepo,
+		modLogService:        modLogService,
+		securityEventService: securityEventService,
+		loginAttemptService:  loginAttemptService,
+		mailer:               mailer,
+	}
+}
+
+func (s *adminService) SearchUsers(filter repo.UserSearchFilter, page int, pageSize int) (*dto.PaginatedAdminUsersResponse, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	if filter.Role != "" && !isValidRole(filter.Role) {
+		return nil, apperror.ErrInvalidRole
+	}
+
+	users, total, err := s.userRepo.Search(ctx, filter, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.PaginatedAdminUsersResponse{
+		Users: dto.FromAdminUsers(users),
+		Pagination: dto.Pagination{
+			Page:     page,
+			PageSize: pageSize,
+			Total:    total,
+		},
+	}, nil
+}
+
+// ChangeUserRole moves a user between the user and admin roles. Existing tokens are revoked
+// because they carry the old role and permissions.
+func (s *adminService) ChangeUserRole(userID string, req *dto.ChangeRoleRequest, adminID string) (*dto.AdminUserResponse, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	role := req.Role
+	if !isValidRole(role) {
+		return nil, apperror.ErrInvalidRole
+	}
+	if userID == adminID {
+		return nil, apperror.ErrForbidden
+	}
+	if role == model.AdminRole {
+		if err := validateAdminGrant(req.Template, req.Permissions); err != nil {
+			return nil, err
+		}
+	}
+
+	user, err := s.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	oldRole := user.Role
+	if oldRole == role {
+		response := dto.FromAdminUser(user)
+		return &response, nil
+	}
+
+	adminObjectID, err := primitive.ObjectIDFromHex(adminID)
+	if err != nil {
+		return nil, apperror.ErrInvalidID
+	}
+
+	user.Role = role
+	if role == model.AdminRole {
+		now := time.Now()
+		user.RoleContent.Admin = &model.AdminRoleContent{
+			Name:             req.Template,
+			Permissions:      req.Permissions,
+			CreateAt:         &now,
+			CreateBy:         adminObjectID,
+			RequireTwoFactor: req.RequireTwoFactor,
+		}
+	} else {
+		user.RoleContent.Admin = nil
+	}
+
+	user, err = s.userRepo.Update(ctx, user)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, apperror.ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	s.revokeTokens(userID)
+	s.recordAdminAction(adminID, model.ModActionChangeRole, user.ID, "",
+		map[string]interface{}{"role": oldRole},
+		map[string]interface{}{"role": role, "permissions": user.AdminPermissions()},
+	)
+
+	response := dto.FromAdminUser(user)
+	return &response, nil
+}
+
+// UpdateAdminPermissions changes the template and extra permissions of another admin
+func (s *adminService) UpdateAdminPermissions(userID string, req *dto.UpdateAdminPermissionsRequest, adminID string) (*dto.AdminUserResponse, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	if userID == adminID {
+		return nil, apperror.ErrForbidden
+	}
+	if err := validateAdminGrant(req.Template, req.Permissions); err != nil {
+		return nil, err
+	}
+
+	user, err := s.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Role != model.AdminRole {
+		return nil, apperror.ErrInvalidRole
+	}
+
+	before := user.AdminPermissions()
+	requiredBefore := user.TwoFactorRequired()
+	if user.RoleContent.Admin == nil {
+		user.RoleContent.Admin = &model.AdminRoleContent{}
+	}
+	user.RoleContent.Admin.Name = req.Template
+	user.RoleContent.Admin.Permissions = req.Permissions
+	user.RoleContent.Admin.RequireTwoFactor = req.RequireTwoFactor
+
+	user, err = s.userRepo.Update(ctx, user)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, apperror.ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	s.revokeTokens(userID)
+	s.recordAdminAction(adminID, model.ModActionUpdatePermissions, user.ID, "",
+		map[string]interface{}{"permissions": before, "require_two_factor": requiredBefore},
+		map[string]interface{}{"template": req.Template, "permissions": user.AdminPermissions(), "require_two_factor": req.RequireTwoFactor},
+	)
+
+	response := dto.FromAdminUser(user)
+	return &response, nil
+}
+
+func (s *adminService) GetPermissionCatalog() *dto.PermissionCatalogResponse {
+	return &dto.PermissionCatalogResponse{
+		Permissions: model.Permissions,
+		Templates:   model.AdminRoleTemplates,
+	}
+}
+
+// ResetUserPassword replaces the password with a random one nobody knows, signs the user out
+// everywhere and emails them a single-use link to choose a new one. The admin never sees a password.
+func (s *adminService) ResetUserPassword(userID string, adminID string) (*dto.PasswordResetLinkResponse, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	user, err := s.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Email == "" {
+		return nil, apperror.ErrBadRequest.WithMessage("This user has no email address to send a reset link to")
+	}
+
+	unusablePassword, err := util.RandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(unusablePassword), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	user.Password = string(hashedPassword)
+	if _, err := s.userRepo.Update(ctx, user); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, apperror.ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	s.revokeTokens(userID)
+	s.recordAdminAction(adminID, model.ModActionResetPassword, user.ID, "", nil, nil)
+
+	link, expMinutes, err := issuePasswordResetLink(user)
+	if err != nil {
+		return nil, err
+	}
+	body := fmt.Sprintf("Hi %s,\n\nAn administrator reset the password of your account and signed it out everywhere. "+
+		"Open the link below to choose a new one:\n\n%s\n\nThe link expires in %d minutes and works once. "+
+		"If it expires, use \"Forgot password\" on the login page to get a new one.\n",
+		user.Username, link, expMinutes)
+	if err := s.mailer.Send(user.Email, "Your password was reset", body); err != nil {
+		return nil, err
+	}
+
+	return &dto.PasswordResetLinkResponse{UserID: userID, ExpiresInMinutes: expMinutes}, nil
+}
+
+// RestoreUser undoes a soft delete, as long as nobody has taken the username or email since
+func (s *adminService) RestoreUser(userID string, adminID string) error {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	if !primitive.IsValidObjectID(userID) {
+		return apperror.ErrInvalidID
+	}
+
+	user, err := s.userRepo.GetDeletedByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return apperror.ErrUserNotFound
+		}
+		return err
+	}
+
+	if existing, err := s.userRepo.GetByUsername(ctx, user.Username); err == nil && existing != nil {
+		return apperror.ErrUsernameExists
+	}
+	if user.Email != "" {
+		if existing, err := s.userRepo.GetByEmail(ctx, user.Email); err == nil && existing != nil {
+			return apperror.ErrEmailExists
+		}
+	}
+
+	if err := s.userRepo.Restore(ctx, userID); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return apperror.ErrUserNotFound
+		}
+		return err
+	}
+
+	s.recordAdminAction(adminID, model.ModActionRestoreUser, user.ID, "",
+		map[string]interface{}{"deleted_at": user.DeletedAt},
+		map[string]interface{}{"deleted_at": nil},
+	)
+
+	return nil
+}
+
+func (s *adminService) SuspendUser(userID string, req *dto.SuspendUserRequest, adminID string) (*dto.SuspensionResponse, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	target, err := s.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if target.Role == model.AdminRole {
+		return nil, apperror.ErrCannotSuspend
+	}
+
+	start := time.Now()
+	var end *time.Time
+	if req.DurationMinutes > 0 {
+		e := start.Add(time.Duration(req.DurationMinutes) * time.Minute)
+		end = &e
+	}
+
+	updated, err := s.userRepo.SetSuspension(ctx, userID, start, end, req.Reason)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, apperror.ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	if auth.TokenSvc != nil {
+		if err := auth.TokenSvc.SuspendUser(ctx, userID, end); err != nil {
+			// The suspension is still enforced at login and refresh, only existing access tokens survive
+			log.Printf("failed to revoke tokens of suspended user %s: %v", userID, err)
+		}
+	}
+
+	s.recordAdminAction(adminID, model.ModActionSuspendUser, target.ID, req.Reason, suspensionSnapshot(target), suspensionSnapshot(updated))
+
+	return &dto.SuspensionResponse{
+		UserID:   userID,
+		Reason:   req.Reason,
+		BanStart: &start,
+		BanEnd:   end,
+	}, nil
+}
+
+func (s *adminService) UnsuspendUser(userID string, adminID string) error {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	target, err := s.getUser(userID)
+	if err != nil {
+		return err
+	}
+
+	updated, err := s.userRepo.ClearSuspension(ctx, userID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return apperror.ErrUserNotFound
+		}
+		return err
+	}
+
+	if auth.TokenSvc != nil {
+		if err := auth.TokenSvc.UnsuspendUser(ctx, userID); err != nil {
+			return err
+		}
+	}
+
+	s.recordAdminAction(adminID, model.ModActionUnsuspendUser, target.ID, "", suspensionSnapshot(target), suspensionSnapshot(updated))
+
+	return nil
+}
+
+// UnlockUser lifts a login lockout before it runs out, for users who cannot wait or reset their password
+func (s *adminService) UnlockUser(userID string, adminID string, client dto.ClientInfo) error {
+	target, err := s.getUser(userID)
+	if err != nil {
+		return err
+	}
+
+	unlocked, err := s.loginAttemptService.Unlock(userID, "unlocked by an admin", client)
+	if err != nil {
+		return err
+	}
+	if !unlocked {
+		return apperror.ErrAccountNotLocked
+	}
+
+	s.recordAdminAction(adminID, model.ModActionUnlockUser, target.ID, "", nil, nil)
+	return nil
+}
+
+// SetBot flags or unflags an account as a bot. Its existing posts and comments follow.
+func (s *adminService) SetBot(userID string, isBot bool, adminID string) (*dto.AdminUserResponse, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	user, err := s.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.IsBot == isBot {
+		response := dto.FromAdminUser(user)
+		return &response, nil
+	}
+
+	if err := s.userRepo.SetBot(ctx, userID, isBot); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, apperror.ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	s.recordAdminAction(adminID, model.ModActionSetBot, user.ID, "",
+		map[string]interface{}{"is_bot": user.IsBot},
+		map[string]interface{}{"is_bot": isBot},
+	)
+
+	user.IsBot = isBot
+	response := dto.FromAdminUser(user)
+	return &response, nil
+}
+
+func (s *adminService) BanCommunity(communityID string, req *dto.BanCommunityRequest, adminID string) (*model.Community, error) {
+	return s.setCommunityBanned(communityID, true, req.Reason, adminID)
+}
+
+func (s *adminService) UnbanCommunity(communityID string, adminID string) (*model.Community, error) {
+	return s.setCommunityBanned(communityID, false, "", adminID)
+}
+
+func (s *adminService) setCommunityBanned(communityID string, banned bool, reason string, adminID string) (*model.Community, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	if !primitive.IsValidObjectID(communityID) {
+		return nil, apperror.ErrInvalidID
+	}
+
+	community, err := s.communityRepo.GetByID(ctx, communityID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, apperror.ErrCommunityNotFound
+		}
+		return nil, err
+	}
+	if community.IsBanned == banned {
+		return community, nil
+	}
+
+	updated, err := s.communityRepo.Update(ctx, communityID, bson.M{"is_banned": banned})
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, apperror.ErrCommunityNotFound
+		}
+		return nil, err
+	}
+
+	action := model.ModActionBanCommunity
+	if !banned {
+		action = model.ModActionUnbanCommunity
+	}
+	adminObjectID, err := primitive.ObjectIDFromHex(adminID)
+	if err == nil {
+		s.modLogService.Record(&model.ModLog{
+			CommunityID: &community.ID,
+			ActorID:     adminObjectID,
+			ActorRole:   model.ModLogActorAdmin,
+			Action:      action,
+			TargetType:  model.ModTargetCommunity,
+			TargetID:    community.ID,
+			Reason:      reason,
+			Before:      map[string]interface{}{"is_banned": community.IsBanned},
+			After:       map[string]interface{}{"is_banned": updated.IsBanned},
+		})
+	}
+
+	return updated, nil
+}
+
+// GetReports lists reports across the whole site
+func (s *adminService) GetReports(communityID string, targetType model.ReportTargetType, status model.ReportStatus, page int, pageSize int) (*dto.PaginatedReportsResponse, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	if communityID != "" && !primitive.IsValidObjectID(communityID) {
+		return nil, apperror.ErrInvalidID
+	}
+
+	reports, total, err := s.reportRepo.GetPaginated(ctx, communityID, targetType, status, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.PaginatedReportsResponse{
+		Reports: reports,
+		Pagination: dto.Pagination{
+			Page:     page,
+			PageSize: pageSize,
+			Total:    total,
+		},
+	}, nil
+}
+
+// GetAuditLog returns the trail of actions taken by admins
+func (s *adminService) GetAuditLog(filter repo.ModLogFilter, page int, pageSize int) (*dto.PaginatedModLogsResponse, error) {
+	filter.ActorRole = model.ModLogActorAdmin
+	return s.modLogService.GetModLogs(filter, page, pageSize)
+}
+
+// GetSecurityEvents returns the security log across all users
+func (s *adminService) GetSecurityEvents(filter repo.SecurityEventFilter, page int, pageSize int) (*dto.PaginatedSecurityEventsResponse, error) {
+	return s.securityEventService.GetSecurityEvents(filter, page, pageSize)
+}
+
+// revokeTokens signs the user out of every session. Failures are logged, the admin action still stands.
+func (s *adminService) revokeTokens(userID string) {
+	if auth.TokenSvc == nil {
+		return
+	}
+
+	ctx, cancel := util.NewDefaultRedisContext()
+	defer cancel()
+
+	if err := auth.TokenSvc.RevokeTokensIssuedBefore(ctx, userID, time.Now()); err != nil {
+		log.Printf("failed to revoke tokens of user %s: %v", userID, err)
+	}
+}
+
+func (s *adminService) getUser(userID string) (*model.User, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	if !primitive.IsValidObjectID(userID) {
+		return nil, apperror.ErrInvalidID
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, apperror.ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// recordAdminAction appends a site-wide admin action to the mod log
+func (s *adminService) recordAdminAction(
+	adminID string,
+	action model.ModAction,
+	targetID primitive.ObjectID,
+	reason string,
+	before interface{},
+	after interface{},
+) {
+	adminObjectID, err := primitive.ObjectIDFromHex(adminID)
+	if err != nil {
+		return
+	}
+
+	s.modLogService.Record(&model.ModLog{
+		ActorID:    adminObjectID,
+		ActorRole:  model.ModLogActorAdmin,
+		Action:     action,
+		TargetType: model.ModTargetUser,
+		TargetID:   targetID,
+		Reason:     reason,
+		Before:     before,
+		After:      after,
+	})
+}
+
+func isValidRole(role model.Role) bool {
+	return role == model.UserRole || role == model.AdminRole
+}
+
+// validateAdminGrant checks the template and permissions against the catalog. An admin needs at
+// least one of them, otherwise they could not use any admin tool.
+func validateAdminGrant(template string, permissions []model.Permission) error {
+	if template == "" && len(permissions) == 0 {
+		return apperror.ErrInvalidPermission
+	}
+	if _, ok := model.AdminRoleTemplates[template]; template != "" && !ok {
+		return apperror.ErrInvalidPermission
+	}
+	for _, p := range permissions {
+		if !model.IsValidPermission(p) {
+			return apperror.ErrInvalidPermission
+		}
+	}
+	return nil
+}
+
+// suspensionSnapshot captures the suspension state of a user for the mod log
+func suspensionSnapshot(user *model.User) map[string]interface{} {
+	snapshot := map[string]interface{}{"suspended": user.IsSuspended(time.Now())}
+	if content := user.RoleContent.User; content != nil {
+		snapshot["ban_start"] = content.BanStart
+		snapshot["ban_end"] = content.BanEnd
+		snapshot["ban_reason"] = content.BanReason
+	}
+	return snapshot
+}
@@ -0,0 +1,533 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/giakiet05/lkforum/internal/apperror"
+	"github.com/giakiet05/lkforum/internal/dto"
+	"github.com/giakiet05/lkforum/internal/mail"
+	"github.com/giakiet05/lkforum/internal/model"
+	"github.com/giakiet05/lkforum/internal/repo"
+	"github.com/giakiet05/lkforum/internal/util"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// AppealService lets users appeal bans, mutes, content removals and site-wide suspensions once per
+// action. Community appeals wait in the community's queue and the admin queue, suspension appeals in
+// the admin queue only; whoever took the action can never review its appeal. Granting an appeal
+// reverses the action.
+type AppealService interface {
+	FileAppeal(req *dto.CreateAppealRequest, userID string) (*model.Appeal, error)
+	FileSuspensionAppeal(req *dto.CreateSuspensionAppealRequest, client dto.ClientInfo) (*model.Appeal, error)
+	GetMyAppeals(userID string, page int, pageSize int) (*dto.PaginatedAppealsResponse, error)
+	GetAppealByID(appealID string, userID string, isAdmin bool) (*model.Appeal, error)
+	GetCommunityAppeals(communityID string, userID string, status model.AppealStatus, page int, pageSize int) (*dto.PaginatedAppealsResponse, error)
+	GetAppeals(communityID string, status model.AppealStatus, adminID string, page int, pageSize int) (*dto.PaginatedAppealsResponse, error)
+	ReviewAppeal(appealID string, req *dto.ReviewAppealRequest, reviewerID string, isAdmin bool) (*model.Appeal, error)
+}
+
+type appealService struct {
+	appealRepo          repo.AppealRepo
+	modLogRepo          repo.ModLogRepo
+	communityRepo       repo.CommunityRepo
+	userRepo            repo.UserRepo
+	communityBanService CommunityBanService
+	removalService      RemovalService
+	adminService        AdminService
+	loginAttemptService LoginAttemptService
+	notificationService NotificationService
+	modLogService       ModLogService
+	mailer              mail.Mailer
+}
+
+func NewAppealService(
+	appealRepo repo.AppealRepo,
+	modLogRepo repo.ModLogRepo,
+	communityRepo repo.CommunityRepo,
+	userRepo repo.UserRepo,
+	communityBanService CommunityBanService,
+	removalService RemovalService,
+	adminService AdminService,
+	loginAttemptService LoginAttemptService,
+	notificationService NotificationService,
+	modLogService ModLogService,
+	mailer mail.Mailer,
+) AppealService {
+	return &appealService{
+		appealRepo:          appealRepo,
+		modLogRepo:          modLogRepo,
+		communityRepo:       communityRepo,
+		userRepo:            userRepo,
+		communityBanService: communityBanService,
+		removalService:      removalService,
+		adminService:        adminService,
+		loginAttemptService: loginAttemptService,
+		notificationService: notificationService,
+		modLogService:       modLogService,
+		mailer:              mailer,
+	}
+}
+
+func (s *appealService) FileAppeal(req *dto.CreateAppealRequest, userID string) (*model.Appeal, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, apperror.ErrInvalidID
+	}
+	if !primitive.IsValidObjectID(req.ModLogID) {
+		return nil, apperror.ErrInvalidID
+	}
+
+	// Actions that do not exist or were taken against someone else look the same as unappealable ones
+	entry, err := s.modLogRepo.GetByID(ctx, req.ModLogID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, apperror.ErrNotAppealable
+		}
+		return nil, err
+	}
+	// Site-wide suspensions have no community and are appealed through FileSuspensionAppeal
+	if !model.IsAppealableAction(entry.Action) || entry.CommunityID == nil ||
+		entry.AffectedUserID == nil || *entry.AffectedUserID != userObjectID {
+		return nil, apperror.ErrNotAppealable
+	}
+
+	community, err := s.getCommunity(entry.CommunityID.Hex())
+	if err != nil {
+		return nil, err
+	}
+
+	appeal, err := s.createAppeal(entry, userObjectID, req.Message)
+	if err != nil {
+		return nil, err
+	}
+
+	s.notifyReviewers(community, appeal)
+	return appeal, nil
+}
+
+// FileSuspensionAppeal appeals the user's current site-wide suspension. Suspended users cannot log in,
+// so the password is checked here, throttled like a login. A second factor is not asked for: the
+// appeal only queues a review, and the outcome is emailed to the account's address.
+func (s *appealService) FileSuspensionAppeal(req *dto.CreateSuspensionAppealRequest, client dto.ClientInfo) (*model.Appeal, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	var user *model.User
+	var err error
+	if isEmail(req.Identifier) {
+		user, err = s.userRepo.GetByEmail(ctx, req.Identifier)
+	} else {
+		user, err = s.userRepo.GetByUsername(ctx, req.Identifier)
+	}
+	if err != nil {
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, err
+		}
+		user = nil
+	}
+
+	accountKey := loginAccountKey(user, req.Identifier)
+	if err := s.loginAttemptService.Check(accountKey, client.IP); err != nil {
+		return nil, err
+	}
+	if !checkPassword(user, req.Password) {
+		s.loginAttemptService.RecordFailure(accountKey, user, client)
+		return nil, apperror.ErrInvalidCredentials
+	}
+	s.loginAttemptService.RecordSuccess(accountKey, user, client)
+
+	if !user.IsSuspended(time.Now()) {
+		return nil, apperror.ErrNotAppealable
+	}
+
+	// The suspension in effect is the one recorded last
+	entries, _, err := s.modLogRepo.GetPaginated(ctx, repo.ModLogFilter{
+		Action:     model.ModActionSuspendUser,
+		TargetType: model.ModTargetUser,
+		TargetID:   user.ID.Hex(),
+	}, 1, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, apperror.ErrNotAppealable
+	}
+
+	return s.createAppeal(&entries[0], user.ID, req.Message)
+}
+
+// createAppeal files the appeal of the mod log entry, once per entry
+func (s *appealService) createAppeal(entry *model.ModLog, userID primitive.ObjectID, message string) (*model.Appeal, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	appealed, err := s.appealRepo.ExistsForModLog(ctx, entry.ID)
+	if err != nil {
+		return nil, err
+	}
+	if appealed {
+		return nil, apperror.ErrAlreadyAppealed
+	}
+
+	appeal, err := s.appealRepo.Create(ctx, &model.Appeal{
+		ModLogID:      entry.ID,
+		CommunityID:   entry.CommunityID,
+		UserID:        userID,
+		Action:        entry.Action,
+		TargetType:    entry.TargetType,
+		TargetID:      entry.TargetID,
+		ActedBy:       entry.ActorID,
+		ActionReason:  entry.Reason,
+		ActionDetails: entry.After,
+		Message:       message,
+		Status:        model.AppealStatusPending,
+		CreatedAt:     time.Now(),
+	})
+	if err != nil {
+		// A concurrent filing for the same action won the unique index
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, apperror.ErrAlreadyAppealed
+		}
+		return nil, err
+	}
+	return appeal, nil
+}
+
+func (s *appealService) GetMyAppeals(userID string, page int, pageSize int) (*dto.PaginatedAppealsResponse, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	if !primitive.IsValidObjectID(userID) {
+		return nil, apperror.ErrInvalidID
+	}
+
+	appeals, total, err := s.appealRepo.GetByUserPaginated(ctx, userID, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return newPaginatedAppeals(appeals, total, page, pageSize), nil
+}
+
+// GetAppealByID shows the appeal to the user who filed it, to admins and to moderators who could review it
+func (s *appealService) GetAppealByID(appealID string, userID string, isAdmin bool) (*model.Appeal, error) {
+	appeal, err := s.getAppeal(appealID)
+	if err != nil {
+		return nil, err
+	}
+
+	if isAdmin || appeal.UserID.Hex() == userID {
+		return appeal, nil
+	}
+	if appeal.CommunityID == nil {
+		return nil, apperror.ErrAppealNotFound
+	}
+
+	community, err := s.getCommunity(appeal.CommunityID.Hex())
+	if err != nil {
+		return nil, err
+	}
+	if err := requireModPermission(community, userID, appealPermission(appeal.Action)); err != nil {
+		return nil, apperror.ErrAppealNotFound
+	}
+
+	return appeal, nil
+}
+
+// GetCommunityAppeals is the community's review queue. Appeals of the moderator's own actions are left out.
+func (s *appealService) GetCommunityAppeals(communityID string, userID string, status model.AppealStatus, page int, pageSize int) (*dto.PaginatedAppealsResponse, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	if status != "" && !model.IsValidAppealStatus(status) {
+		return nil, apperror.ErrInvalidAppealStatus
+	}
+
+	community, err := s.getCommunity(communityID)
+	if err != nil {
+		return nil, err
+	}
+
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, apperror.ErrInvalidID
+	}
+	if !hasModPermission(community, userObjectID, model.ModPermissionPosts) && !hasModPermission(community, userObjectID, model.ModPermissionUsers) {
+		return nil, apperror.ErrForbidden
+	}
+
+	appeals, total, err := s.appealRepo.GetPaginated(ctx, repo.AppealFilter{
+		CommunityID:  communityID,
+		Status:       status,
+		ExcludeActor: userID,
+	}, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return newPaginatedAppeals(appeals, total, page, pageSize), nil
+}
+
+// GetAppeals is the site-wide queue for admins, optionally narrowed to one community
+func (s *appealService) GetAppeals(communityID string, status model.AppealStatus, adminID string, page int, pageSize int) (*dto.PaginatedAppealsResponse, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	if status != "" && !model.IsValidAppealStatus(status) {
+		return nil, apperror.ErrInvalidAppealStatus
+	}
+	if communityID != "" && !primitive.IsValidObjectID(communityID) {
+		return nil, apperror.ErrInvalidID
+	}
+
+	appeals, total, err := s.appealRepo.GetPaginated(ctx, repo.AppealFilter{
+		CommunityID:  communityID,
+		Status:       status,
+		ExcludeActor: adminID,
+	}, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return newPaginatedAppeals(appeals, total, page, pageSize), nil
+}
+
+// ReviewAppeal decides a pending appeal. Moderators need the permission the original action required;
+// admins can review any appeal and are the only ones to review suspensions. Granting lifts the ban or
+// suspension, or restores the content.
+func (s *appealService) ReviewAppeal(appealID string, req *dto.ReviewAppealRequest, reviewerID string, isAdmin bool) (*model.Appeal, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	if req.Status != model.AppealStatusGranted && req.Status != model.AppealStatusDenied {
+		return nil, apperror.ErrInvalidAppealStatus
+	}
+
+	reviewerObjectID, err := primitive.ObjectIDFromHex(reviewerID)
+	if err != nil {
+		return nil, apperror.ErrInvalidID
+	}
+
+	appeal, err := s.getAppeal(appealID)
+	if err != nil {
+		return nil, err
+	}
+	if appeal.Status != model.AppealStatusPending {
+		return nil, apperror.ErrAppealAlreadyResolved
+	}
+	if appeal.ActedBy == reviewerObjectID {
+		return nil, apperror.ErrCannotReviewOwnAction
+	}
+
+	var community *model.Community
+	if appeal.CommunityID != nil {
+		if community, err = s.getCommunity(appeal.CommunityID.Hex()); err != nil {
+			return nil, err
+		}
+	}
+	if !isAdmin {
+		if community == nil {
+			return nil, apperror.ErrForbidden
+		}
+		if err := requireModPermission(community, reviewerID, appealPermission(appeal.Action)); err != nil {
+			return nil, err
+		}
+	}
+
+	// Claim the appeal first so two reviewers cannot both decide it
+	reviewed, err := s.appealRepo.Resolve(ctx, appeal.ID, req.Status, reviewerObjectID, req.Note)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, apperror.ErrAppealAlreadyResolved
+		}
+		return nil, err
+	}
+
+	// A grant that cannot be carried out goes back to pending, so it is not shown as granted while the
+	// action stays in place
+	if reviewed.Status == model.AppealStatusGranted {
+		if err := s.reverseAction(reviewed, reviewerID, isAdmin); err != nil {
+			if reopenErr := s.appealRepo.Reopen(ctx, reviewed.ID, model.AppealStatusGranted); reopenErr != nil {
+				log.Printf("Failed to reopen appeal %s after its reversal failed: %v\n", reviewed.ID.Hex(), reopenErr)
+			}
+			return nil, err
+		}
+	}
+
+	s.modLogService.Record(&model.ModLog{
+		CommunityID: reviewed.CommunityID,
+		ActorID:     reviewerObjectID,
+		ActorRole:   modLogActorRole(isAdmin),
+		Action:      model.ModActionReviewAppeal,
+		TargetType:  model.ModTargetAppeal,
+		TargetID:    reviewed.ID,
+		Reason:      req.Note,
+		Before:      map[string]interface{}{"status": appeal.Status},
+		After:       map[string]interface{}{"status": reviewed.Status, "mod_log_id": reviewed.ModLogID},
+	})
+
+	s.notifyAppellant(community, reviewed)
+	return reviewed, nil
+}
+
+// reverseAction undoes the appealed action. Bans that already expired or were lifted, users and
+// content deleted since, have nothing left to undo.
+func (s *appealService) reverseAction(appeal *model.Appeal, reviewerID string, isAdmin bool) error {
+	if appeal.Action == model.ModActionSuspendUser {
+		err := s.adminService.UnsuspendUser(appeal.UserID.Hex(), reviewerID)
+		if errors.Is(err, apperror.ErrUserNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	communityID := appeal.CommunityID.Hex()
+
+	var err error
+	switch appeal.Action {
+	case model.ModActionBanUser:
+		err = s.communityBanService.LiftBan(communityID, appeal.UserID.Hex(), model.CommunityBanTypeBan, reviewerID, isAdmin)
+	case model.ModActionMuteUser:
+		err = s.communityBanService.LiftBan(communityID, appeal.UserID.Hex(), model.CommunityBanTypeMute, reviewerID, isAdmin)
+	case model.ModActionRemovePost:
+		err = s.removalService.RestorePost(communityID, appeal.TargetID.Hex(), reviewerID, isAdmin)
+	case model.ModActionRemoveComment:
+		err = s.removalService.RestoreComment(communityID, appeal.TargetID.Hex(), reviewerID, isAdmin)
+	}
+
+	if errors.Is(err, apperror.ErrBanNotFound) || errors.Is(err, apperror.ErrPostNotFound) || errors.Is(err, apperror.ErrCommentNotFound) {
+		return nil
+	}
+	return err
+}
+
+func (s *appealService) getAppeal(appealID string) (*model.Appeal, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	if !primitive.IsValidObjectID(appealID) {
+		return nil, apperror.ErrInvalidID
+	}
+
+	appeal, err := s.appealRepo.GetByID(ctx, appealID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, apperror.ErrAppealNotFound
+		}
+		return nil, err
+	}
+
+	return appeal, nil
+}
+
+func (s *appealService) getCommunity(communityID string) (*model.Community, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	if !primitive.IsValidObjectID(communityID) {
+		return nil, apperror.ErrInvalidID
+	}
+
+	community, err := s.communityRepo.GetByID(ctx, communityID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, apperror.ErrCommunityNotFound
+		}
+		return nil, err
+	}
+
+	return community, nil
+}
+
+// notifyReviewers tells the moderators who can review the appeal about it, leaving out whoever took the action
+func (s *appealService) notifyReviewers(community *model.Community, appeal *model.Appeal) {
+	permission := appealPermission(appeal.Action)
+
+	var reviewerIDs []primitive.ObjectID
+	if community.CreateByID != appeal.ActedBy {
+		reviewerIDs = append(reviewerIDs, community.CreateByID)
+	}
+	for _, mod := range community.Moderators {
+		if mod.UserID != appeal.ActedBy && mod.UserID != community.CreateByID && mod.HasPermission(permission) {
+			reviewerIDs = append(reviewerIDs, mod.UserID)
+		}
+	}
+	if len(reviewerIDs) == 0 {
+		return
+	}
+
+	message := fmt.Sprintf("A new appeal against %s is waiting for review in %s", appeal.Action, community.Name)
+	metadata := map[string]interface{}{
+		"appeal_id":    appeal.ID.Hex(),
+		"community_id": community.ID.Hex(),
+	}
+	if err := s.notificationService.NotifyMany(reviewerIDs, model.NotificationTypeAppeal, message, metadata); err != nil {
+		log.Printf("failed to notify moderators of community %s about appeal: %v", community.ID.Hex(), err)
+	}
+}
+
+// notifyAppellant tells the user how their appeal was decided. community is nil for suspension
+// appeals, which are emailed as well since a user who is still suspended cannot read notifications.
+func (s *appealService) notifyAppellant(community *model.Community, appeal *model.Appeal) {
+	message := fmt.Sprintf("Your appeal of your suspension was %s", appeal.Status)
+	metadata := map[string]interface{}{
+		"appeal_id": appeal.ID.Hex(),
+		"status":    appeal.Status,
+	}
+	if community != nil {
+		message = fmt.Sprintf("Your appeal in %s was %s", community.Name, appeal.Status)
+		metadata["community_id"] = community.ID.Hex()
+	}
+	if appeal.ReviewNote != "" {
+		message += ". Note from the reviewer: " + appeal.ReviewNote
+	}
+
+	if err := s.notificationService.Notify(appeal.UserID, model.NotificationTypeAppeal, message, metadata); err != nil {
+		log.Printf("failed to notify user %s of appeal outcome: %v", appeal.UserID.Hex(), err)
+	}
+
+	if community == nil {
+		go s.mailAppellant(appeal.UserID.Hex(), message)
+	}
+}
+
+func (s *appealService) mailAppellant(userID string, message string) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil || user.Email == "" {
+		return
+	}
+
+	body := fmt.Sprintf("Hi %s,\n\n%s.\n", user.Username, message)
+	if err := s.mailer.Send(user.Email, "Your appeal was reviewed", body); err != nil {
+		log.Printf("failed to email user %s the appeal outcome: %v", userID, err)
+	}
+}
+
+// appealPermission is the moderator permission the appealed action needed, which reviewing it needs too
+func appealPermission(action model.ModAction) model.ModPermission {
+	if action == model.ModActionBanUser || action == model.ModActionMuteUser {
+		return model.ModPermissionUsers
+	}
+	return model.ModPermissionPosts
+}
+
+func newPaginatedAppeals(appeals []model.Appeal, total int64, page int, pageSize int) *dto.PaginatedAppealsResponse {
+	return &dto.PaginatedAppealsResponse{
+		Appeals: appeals,
+		Pagination: dto.Pagination{
+			Page:     page,
+			PageSize: pageSize,
+			Total:    total,
+		},
+	}
+}
@@ -0,0 +1,677 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/giakiet05/lkforum/internal/apperror"
+	"github.com/giakiet05/lkforum/internal/config"
+	"github.com/giakiet05/lkforum/internal/dto"
+	"github.com/giakiet05/lkforum/internal/model"
+	"github.com/giakiet05/lkforum/internal/repo"
+	"github.com/giakiet05/lkforum/internal/util"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const maxAutoModRules = 100
+
+var (
+	autoModRegexCache sync.Map // pattern -> *regexp.Regexp
+	autoModLinkRegex  = regexp.MustCompile(`(?i)\bhttps?://[^\s<>"')\]]+`)
+)
+
+// AutoModService runs the AutoMod rules of a community. RunOnPost and RunOnComment are meant to be
+// called right after content is created or edited; the outcome is written back to the content and
+// reflected on the struct passed in. There is no post or comment service yet, so nothing calls them
+// and rules only take effect through the TestRules dry run until those services exist.
+type AutoModService interface {
+	GetRules(communityID string, userID string) (*model.AutoModConfig, error)
+	UpdateRules(communityID string, req *dto.UpdateAutoModRulesRequest, userID string) (*model.AutoModConfig, error)
+	TestRules(communityID string, req *dto.TestAutoModRequest, userID string) (*dto.AutoModTestResponse, error)
+
+	RunOnPost(post *model.Post, trigger model.AutoModTrigger) ([]dto.AutoModMatch, error)
+	RunOnComment(comment *model.Comment, trigger model.AutoModTrigger) ([]dto.AutoModMatch, error)
+}
+
+type autoModService struct {
+	autoModRepo         repo.AutoModRepo
+	communityRepo       repo.CommunityRepo
+	userRepo            repo.UserRepo
+	reportRepo          repo.ReportRepo
+	notificationService NotificationService
+	modLogService       ModLogService
+}
+
+func NewAutoModService(
+	autoModRepo repo.AutoModRepo,
+	communityRepo repo.CommunityRepo,
+	userRepo repo.UserRepo,
+	reportRepo repo.ReportRepo,
+	notificationService NotificationService,
+	modLogService ModLogService,
+) AutoModService {
+	return &autoModService{
+		autoModRepo:         autoModRepo,
+		communityRepo:       communityRepo,
+		userRepo:            userRepo,
+		reportRepo:          reportRepo,
+		notificationService: notificationService,
+		modLogService:       modLogService,
+	}
+}
+
+// autoModSubject is the content rules are evaluated against
+type autoModSubject struct {
+	target      model.AutoModTarget
+	title       string
+	body        string
+	postType    model.PostType
+	links       []string
+	accountAge  time.Duration
+	karma       int
+	reportCount int
+}
+
+// autoModOutcome folds the actions of every matched rule together
+type autoModOutcome struct {
+	status  model.ModerationStatus
+	lock    bool
+	flair   string
+	replies []string
+	notices []string
+}
+
+func (s *autoModService) GetRules(communityID string, userID string) (*model.AutoModConfig, error) {
+	community, err := s.getCommunity(communityID)
+	if err != nil {
+		return nil, err
+	}
+
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, apperror.ErrInvalidID
+	}
+	if !canModerate(community, userObjectID) {
+		return nil, apperror.ErrForbidden
+	}
+
+	return s.getConfig(community.ID)
+}
+
+func (s *autoModService) UpdateRules(communityID string, req *dto.UpdateAutoModRulesRequest, userID string) (*model.AutoModConfig, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	community, err := s.getCommunity(communityID)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireModPermission(community, userID, model.ModPermissionSettings); err != nil {
+		return nil, err
+	}
+
+	if err := validateAutoModRules(req.Rules); err != nil {
+		return nil, err
+	}
+
+	before, err := s.getConfig(community.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	userObjectID, _ := primitive.ObjectIDFromHex(userID)
+	rules := req.Rules
+	if rules == nil {
+		rules = []model.AutoModRule{}
+	}
+
+	saved, err := s.autoModRepo.Upsert(ctx, &model.AutoModConfig{
+		CommunityID: community.ID,
+		Rules:       rules,
+		UpdatedBy:   userObjectID,
+		UpdatedAt:   time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.modLogService.Record(&model.ModLog{
+		CommunityID: &community.ID,
+		ActorID:     userObjectID,
+		ActorRole:   model.ModLogActorModerator,
+		Action:      model.ModActionUpdateAutoMod,
+		TargetType:  model.ModTargetCommunity,
+		TargetID:    community.ID,
+		Before:      before.Rules,
+		After:       saved.Rules,
+	})
+
+	return saved, nil
+}
+
+// TestRules is a dry run: it reports which rules match the sample content and what they would do
+func (s *autoModService) TestRules(communityID string, req *dto.TestAutoModRequest, userID string) (*dto.AutoModTestResponse, error) {
+	community, err := s.getCommunity(communityID)
+	if err != nil {
+		return nil, err
+	}
+
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, apperror.ErrInvalidID
+	}
+	if !canModerate(community, userObjectID) {
+		return nil, apperror.ErrForbidden
+	}
+
+	rules := req.Rules
+	if len(rules) == 0 {
+		saved, err := s.getConfig(community.ID)
+		if err != nil {
+			return nil, err
+		}
+		rules = saved.Rules
+	}
+	if err := validateAutoModRules(rules); err != nil {
+		return nil, err
+	}
+
+	trigger := req.Trigger
+	if trigger == "" {
+		trigger = model.AutoModTriggerCreate
+	}
+	if !isValidAutoModTarget(req.Target) || !isValidAutoModTrigger(trigger) {
+		return nil, apperror.ErrInvalidAutoModRule
+	}
+
+	subject := &autoModSubject{
+		target:      req.Target,
+		title:       req.Title,
+		body:        req.Body,
+		postType:    req.PostType,
+		links:       append(extractLinks(req.Body), req.Links...),
+		accountAge:  time.Duration(req.AccountAgeDays) * 24 * time.Hour,
+		karma:       req.Karma,
+		reportCount: req.ReportCount,
+	}
+
+	matches, err := evaluateAutoModRules(rules, subject, trigger)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.AutoModTestResponse{Matches: matches}, nil
+}
+
+// RunOnPost applies the rules to a post that was just created or edited. Not called yet, see AutoModService.
+func (s *autoModService) RunOnPost(post *model.Post, trigger model.AutoModTrigger) ([]dto.AutoModMatch, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	autoModConfig, err := s.getConfig(post.CommunityID)
+	if err != nil || len(autoModConfig.Rules) == 0 {
+		return nil, err
+	}
+
+	subject := &autoModSubject{
+		target:   model.AutoModTargetPost,
+		title:    post.Title,
+		postType: post.Type,
+	}
+	if post.Content != nil {
+		subject.body = post.Content.Text
+		if post.Content.Video != nil && post.Content.Video.URL != "" {
+			subject.links = append(subject.links, post.Content.Video.URL)
+		}
+	}
+	subject.links = append(subject.links, extractLinks(subject.body)...)
+
+	if err := s.loadAuthorStats(subject, autoModConfig.Rules, post.AuthorID, model.ReportTypePost, post.ID); err != nil {
+		return nil, err
+	}
+
+	matches, err := evaluateAutoModRules(autoModConfig.Rules, subject, trigger)
+	if err != nil || len(matches) == 0 {
+		return nil, err
+	}
+
+	outcome := foldAutoModActions(matches)
+	updates := bson.M{}
+	if outcome.status != "" {
+		post.ModerationStatus = outcome.status
+		updates["moderation_status"] = outcome.status
+	}
+	if outcome.lock {
+		post.IsLocked = true
+		updates["is_locked"] = true
+	}
+	if outcome.flair != "" {
+		post.Flair = outcome.flair
+		updates["flair"] = outcome.flair
+	}
+	if len(updates) > 0 {
+		if err := s.autoModRepo.UpdatePost(ctx, post.ID, updates); err != nil {
+			return nil, err
+		}
+	}
+
+	s.reply(post.ID, nil, outcome.replies)
+	s.notifyModerators(post.CommunityID, model.ModTargetPost, post.ID, outcome.notices)
+	s.recordMatches(post.CommunityID, model.ModTargetPost, post.ID, matches)
+
+	return matches, nil
+}
+
+// RunOnComment applies the rules to a comment that was just created or edited. Not called yet, see AutoModService.
+func (s *autoModService) RunOnComment(comment *model.Comment, trigger model.AutoModTrigger) ([]dto.AutoModMatch, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	post, err := s.autoModRepo.GetPost(ctx, comment.PostID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, apperror.ErrPostNotFound
+		}
+		return nil, err
+	}
+
+	autoModConfig, err := s.getConfig(post.CommunityID)
+	if err != nil || len(autoModConfig.Rules) == 0 {
+		return nil, err
+	}
+
+	subject := &autoModSubject{
+		target:   model.AutoModTargetComment,
+		body:     comment.Content,
+		postType: post.Type,
+		links:    extractLinks(comment.Content),
+	}
+
+	if err := s.loadAuthorStats(subject, autoModConfig.Rules, comment.AuthorID, model.ReportTypeComment, comment.ID); err != nil {
+		return nil, err
+	}
+
+	matches, err := evaluateAutoModRules(autoModConfig.Rules, subject, trigger)
+	if err != nil || len(matches) == 0 {
+		return nil, err
+	}
+
+	// Flair only applies to posts; locking a comment locks the thread it belongs to
+	outcome := foldAutoModActions(matches)
+	if outcome.status != "" {
+		comment.ModerationStatus = outcome.status
+		if err := s.autoModRepo.UpdateComment(ctx, comment.ID, bson.M{"moderation_status": outcome.status}); err != nil {
+			return nil, err
+		}
+	}
+	if outcome.lock && !post.IsLocked {
+		if err := s.autoModRepo.UpdatePost(ctx, post.ID, bson.M{"is_locked": true}); err != nil {
+			return nil, err
+		}
+	}
+
+	s.reply(post.ID, &comment.ID, outcome.replies)
+	s.notifyModerators(post.CommunityID, model.ModTargetComment, comment.ID, outcome.notices)
+	s.recordMatches(post.CommunityID, model.ModTargetComment, comment.ID, matches)
+
+	return matches, nil
+}
+
+// loadAuthorStats fills in what the rules need to know about the author and the content's reports.
+// Karma and report counts cost a query each, so they are only loaded when a rule asks for them.
+func (s *autoModService) loadAuthorStats(
+	subject *autoModSubject,
+	rules []model.AutoModRule,
+	authorID primitive.ObjectID,
+	reportType model.ReportTargetType,
+	targetID primitive.ObjectID,
+) error {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	author, err := s.userRepo.GetByID(ctx, authorID.Hex())
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return apperror.ErrUserNotFound
+		}
+		return err
+	}
+	subject.accountAge = time.Since(author.CreateAt)
+
+	var needKarma, needReports bool
+	for _, rule := range rules {
+		needKarma = needKarma || rule.Conditions.KarmaBelow != nil
+		needReports = needReports || rule.Conditions.ReportCountAtLeast != nil
+	}
+
+	if needKarma {
+		if subject.karma, err = s.userRepo.GetKarma(ctx, authorID.Hex()); err != nil {
+			return err
+		}
+	}
+	if needReports {
+		if subject.reportCount, err = s.reportRepo.CountUnresolvedByTarget(ctx, reportType, targetID.Hex()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// reply posts each message as a comment from the AutoMod account
+func (s *autoModService) reply(postID primitive.ObjectID, parentID *primitive.ObjectID, messages []string) {
+	if len(messages) == 0 {
+		return
+	}
+
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	authorID, authorName := autoModAccount()
+	for _, message := range messages {
+		_, err := s.autoModRepo.CreateComment(ctx, &model.Comment{
+			AuthorID:       authorID,
+			AuthorUsername: authorName,
+			AuthorIsBot:    true,
+			PostID:         postID,
+			ParentID:       parentID,
+			Content:        message,
+			CreatedAt:      time.Now(),
+		})
+		if err != nil {
+			log.Printf("failed to post AutoMod reply on post %s: %v", postID.Hex(), err)
+		}
+	}
+}
+
+// notifyModerators sends each notice to every moderator of the community
+func (s *autoModService) notifyModerators(communityID primitive.ObjectID, targetType model.ModTargetType, targetID primitive.ObjectID, notices []string) {
+	if len(notices) == 0 {
+		return
+	}
+
+	community, err := s.getCommunity(communityID.Hex())
+	if err != nil {
+		log.Printf("failed to load community %s for AutoMod notice: %v", communityID.Hex(), err)
+		return
+	}
+
+	moderatorIDs := make([]primitive.ObjectID, 0, len(community.Moderators))
+	for _, mod := range community.Moderators {
+		moderatorIDs = append(moderatorIDs, mod.UserID)
+	}
+
+	metadata := map[string]interface{}{
+		"community_id": communityID.Hex(),
+		"target_type":  targetType,
+		"target_id":    targetID.Hex(),
+	}
+	for _, notice := range notices {
+		if err := s.notificationService.NotifyMany(moderatorIDs, model.NotificationTypeModerator, notice, metadata); err != nil {
+			log.Printf("failed to notify moderators of community %s: %v", communityID.Hex(), err)
+		}
+	}
+}
+
+func (s *autoModService) recordMatches(communityID primitive.ObjectID, targetType model.ModTargetType, targetID primitive.ObjectID, matches []dto.AutoModMatch) {
+	actorID, _ := autoModAccount()
+	for _, match := range matches {
+		s.modLogService.Record(&model.ModLog{
+			CommunityID: &communityID,
+			ActorID:     actorID,
+			ActorRole:   model.ModLogActorAutoMod,
+			Action:      model.ModActionAutoMod,
+			TargetType:  targetType,
+			TargetID:    targetID,
+			Reason:      match.Rule,
+			After:       match.Actions,
+		})
+	}
+}
+
+// getConfig returns the community's AutoMod config, an empty one if rules were never saved
+func (s *autoModService) getConfig(communityID primitive.ObjectID) (*model.AutoModConfig, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	autoModConfig, err := s.autoModRepo.GetByCommunity(ctx, communityID.Hex())
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return &model.AutoModConfig{CommunityID: communityID, Rules: []model.AutoModRule{}}, nil
+		}
+		return nil, err
+	}
+
+	return autoModConfig, nil
+}
+
+func (s *autoModService) getCommunity(communityID string) (*model.Community, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	if !primitive.IsValidObjectID(communityID) {
+		return nil, apperror.ErrInvalidID
+	}
+
+	community, err := s.communityRepo.GetByID(ctx, communityID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, apperror.ErrCommunityNotFound
+		}
+		return nil, err
+	}
+
+	return community, nil
+}
+
+// autoModAccount is the user AutoMod acts as. Without AUTOMOD_USER_ID, replies and log entries
+// carry an empty author ID and only the username.
+func autoModAccount() (primitive.ObjectID, string) {
+	id, err := primitive.ObjectIDFromHex(config.GetEnvWithDefault("AUTOMOD_USER_ID", ""))
+	if err != nil {
+		id = primitive.NilObjectID
+	}
+	return id, config.GetEnvWithDefault("AUTOMOD_USERNAME", "AutoModerator")
+}
+
+func validateAutoModRules(rules []model.AutoModRule) error {
+	if len(rules) > maxAutoModRules {
+		return apperror.ErrInvalidAutoModRule
+	}
+
+	for _, rule := range rules {
+		if strings.TrimSpace(rule.Name) == "" || len(rule.Actions) == 0 {
+			return apperror.ErrInvalidAutoModRule
+		}
+		for _, target := range rule.Targets {
+			if !isValidAutoModTarget(target) {
+				return apperror.ErrInvalidAutoModRule
+			}
+		}
+		for _, trigger := range rule.Triggers {
+			if !isValidAutoModTrigger(trigger) {
+				return apperror.ErrInvalidAutoModRule
+			}
+		}
+		for _, pattern := range []string{rule.Conditions.TitleRegex, rule.Conditions.BodyRegex} {
+			if pattern == "" {
+				continue
+			}
+			if _, err := compileAutoModRegex(pattern); err != nil {
+				return apperror.ErrInvalidAutoModRule
+			}
+		}
+		for _, action := range rule.Actions {
+			if !model.IsValidAutoModAction(action.Type) {
+				return apperror.ErrInvalidAutoModRule
+			}
+			if action.Type == model.AutoModActionFlair && action.Flair == "" {
+				return apperror.ErrInvalidAutoModRule
+			}
+			if action.Type == model.AutoModActionReply && action.Message == "" {
+				return apperror.ErrInvalidAutoModRule
+			}
+		}
+	}
+
+	return nil
+}
+
+func evaluateAutoModRules(rules []model.AutoModRule, subject *autoModSubject, trigger model.AutoModTrigger) ([]dto.AutoModMatch, error) {
+	matches := []dto.AutoModMatch{}
+	for _, rule := range rules {
+		if !rule.AppliesTo(subject.target, trigger) {
+			continue
+		}
+
+		matched, err := matchAutoModConditions(&rule.Conditions, subject)
+		if err != nil {
+			return nil, err
+		}
+		if matched {
+			matches = append(matches, dto.AutoModMatch{Rule: rule.Name, Actions: rule.Actions})
+		}
+	}
+
+	return matches, nil
+}
+
+// matchAutoModConditions checks every condition that is set; a title condition never matches a comment
+func matchAutoModConditions(conditions *model.AutoModConditions, subject *autoModSubject) (bool, error) {
+	if conditions.TitleRegex != "" {
+		if subject.target != model.AutoModTargetPost {
+			return false, nil
+		}
+		re, err := compileAutoModRegex(conditions.TitleRegex)
+		if err != nil {
+			return false, apperror.ErrInvalidAutoModRule
+		}
+		if !re.MatchString(subject.title) {
+			return false, nil
+		}
+	}
+
+	if conditions.BodyRegex != "" {
+		re, err := compileAutoModRegex(conditions.BodyRegex)
+		if err != nil {
+			return false, apperror.ErrInvalidAutoModRule
+		}
+		if !re.MatchString(subject.body) {
+			return false, nil
+		}
+	}
+
+	if conditions.AccountAgeDaysBelow != nil && subject.accountAge >= time.Duration(*conditions.AccountAgeDaysBelow)*24*time.Hour {
+		return false, nil
+	}
+	if conditions.KarmaBelow != nil && subject.karma >= *conditions.KarmaBelow {
+		return false, nil
+	}
+	if conditions.ReportCountAtLeast != nil && subject.reportCount < *conditions.ReportCountAtLeast {
+		return false, nil
+	}
+
+	if len(conditions.PostTypes) > 0 {
+		matched := false
+		for _, postType := range conditions.PostTypes {
+			if postType == subject.postType {
+				matched = true
+			}
+		}
+		if !matched {
+			return false, nil
+		}
+	}
+
+	if len(conditions.Domains) > 0 && !linksMatchDomains(subject.links, conditions.Domains) {
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// foldAutoModActions merges the actions of all matches; removal wins over filtering
+func foldAutoModActions(matches []dto.AutoModMatch) *autoModOutcome {
+	outcome := &autoModOutcome{}
+	for _, match := range matches {
+		for _, action := range match.Actions {
+			switch action.Type {
+			case model.AutoModActionRemove:
+				outcome.status = model.ModerationStatusRemoved
+			case model.AutoModActionFilter:
+				if outcome.status != model.ModerationStatusRemoved {
+					outcome.status = model.ModerationStatusFiltered
+				}
+			case model.AutoModActionLock:
+				outcome.lock = true
+			case model.AutoModActionFlair:
+				outcome.flair = action.Flair
+			case model.AutoModActionReply:
+				outcome.replies = append(outcome.replies, action.Message)
+			case model.AutoModActionNotifyMods:
+				notice := action.Message
+				if notice == "" {
+					notice = fmt.Sprintf("AutoMod rule %q matched new content", match.Rule)
+				}
+				outcome.notices = append(outcome.notices, notice)
+			}
+		}
+	}
+	return outcome
+}
+
+func compileAutoModRegex(pattern string) (*regexp.Regexp, error) {
+	if cached, ok := autoModRegexCache.Load(pattern); ok {
+		return cached.(*regexp.Regexp), nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	autoModRegexCache.Store(pattern, re)
+	return re, nil
+}
+
+func extractLinks(text string) []string {
+	return autoModLinkRegex.FindAllString(text, -1)
+}
+
+// linksMatchDomains checks if any link points to one of the domains or a subdomain of it
+func linksMatchDomains(links []string, domains []string) bool {
+	for _, link := range links {
+		if !strings.Contains(link, "://") {
+			link = "http://" + link
+		}
+		parsed, err := url.Parse(link)
+		if err != nil {
+			continue
+		}
+		host := strings.ToLower(parsed.Hostname())
+
+		for _, domain := range domains {
+			domain = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(domain), "."))
+			if domain != "" && (host == domain || strings.HasSuffix(host, "."+domain)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isValidAutoModTarget(target model.AutoModTarget) bool {
+	return target == model.AutoModTargetPost || target == model.AutoModTargetComment
+}
+
+func isValidAutoModTrigger(trigger model.AutoModTrigger) bool {
+	return trigger == model.AutoModTriggerCreate || trigger == model.AutoModTriggerEdit
+}
@@ -0,0 +1,352 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/giakiet05/lkforum/internal/apperror"
+	"github.com/giakiet05/lkforum/internal/dto"
+	"github.com/giakiet05/lkforum/internal/model"
+	"github.com/giakiet05/lkforum/internal/repo"
+	"github.com/giakiet05/lkforum/internal/util"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// CommunityBanService manages community bans and mutes. The Check* methods are the single place
+// that decides whether a user may take part in a community: membership checks CheckCanJoin, reports
+// CheckCanVote and modmail CheckCanMessageModerators. CheckCanWrite is for posts and comments, which
+// have no service yet.
+type CommunityBanService interface {
+	BanUser(communityID string, req *dto.CommunityBanRequest, actorID string, isAdmin bool) (*model.CommunityBan, error)
+	LiftBan(communityID string, userID string, banType model.CommunityBanType, actorID string, isAdmin bool) error
+	GetCommunityBans(communityID string, banType model.CommunityBanType, actorID string, isAdmin bool, page int, pageSize int) (*dto.PaginatedCommunityBansResponse, error)
+
+	CheckCanJoin(communityID string, userID string) error
+	CheckCanVote(communityID string, userID string) error
+	CheckCanWrite(communityID string, userID string) error
+	CheckCanMessageModerators(communityID string, userID string) error
+
+	StartExpiredBanCleanup()
+}
+
+type communityBanService struct {
+	communityBanRepo    repo.CommunityBanRepo
+	communityRepo       repo.CommunityRepo
+	removalRepo         repo.RemovalRepo
+	notificationService NotificationService
+	modLogService       ModLogService
+}
+
+func NewCommunityBanService(
+	communityBanRepo repo.CommunityBanRepo,
+	communityRepo repo.CommunityRepo,
+	removalRepo repo.RemovalRepo,
+	notificationService NotificationService,
+	modLogService ModLogService,
+) CommunityBanService {
+	svc := &communityBanService{
+		communityBanRepo:    communityBanRepo,
+		communityRepo:       communityRepo,
+		removalRepo:         removalRepo,
+		notificationService: notificationService,
+		modLogService:       modLogService,
+	}
+	svc.StartExpiredBanCleanup()
+	return svc
+}
+
+func (s *communityBanService) BanUser(communityID string, req *dto.CommunityBanRequest, actorID string, isAdmin bool) (*model.CommunityBan, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	if req.Type != model.CommunityBanTypeBan && req.Type != model.CommunityBanTypeMute {
+		return nil, apperror.ErrInvalidBanType
+	}
+
+	community, err := s.getModeratedCommunity(communityID, actorID, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	userObjectID, err := primitive.ObjectIDFromHex(req.UserID)
+	if err != nil {
+		return nil, apperror.ErrInvalidID
+	}
+	actorObjectID, err := primitive.ObjectIDFromHex(actorID)
+	if err != nil {
+		return nil, apperror.ErrInvalidID
+	}
+
+	if isCommunityModerator(community, userObjectID) {
+		return nil, apperror.ErrCannotBanModerator
+	}
+
+	existed, err := s.communityRepo.IsUserExist(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !existed {
+		return nil, apperror.ErrUserNotFound
+	}
+
+	reason, reasonID, err := resolveRemovalReason(s.removalRepo, community.ID, req.RemovalReasonID, req.Reason)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	ban := &model.CommunityBan{
+		CommunityID: community.ID,
+		UserID:      userObjectID,
+		Type:        req.Type,
+		Reason:      reason,
+		IssuedBy:    actorObjectID,
+		CreatedAt:   now,
+	}
+	if req.DurationMinutes > 0 {
+		expiresAt := now.Add(time.Duration(req.DurationMinutes) * time.Minute)
+		ban.ExpiresAt = &expiresAt
+	}
+
+	ban, err = s.communityBanRepo.Upsert(ctx, ban)
+	if err != nil {
+		return nil, err
+	}
+
+	action := model.ModActionBanUser
+	if ban.Type == model.CommunityBanTypeMute {
+		action = model.ModActionMuteUser
+	}
+	entry := &model.ModLog{
+		CommunityID:     &community.ID,
+		ActorID:         actorObjectID,
+		ActorRole:       modLogActorRole(isAdmin),
+		Action:          action,
+		TargetType:      model.ModTargetUser,
+		TargetID:        userObjectID,
+		AffectedUserID:  &userObjectID,
+		Reason:          ban.Reason,
+		RemovalReasonID: reasonID,
+		After:           ban,
+	}
+	s.modLogService.Record(entry)
+
+	s.notifyBannedUser(community, ban, entry)
+
+	return ban, nil
+}
+
+func (s *communityBanService) LiftBan(communityID string, userID string, banType model.CommunityBanType, actorID string, isAdmin bool) error {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	if banType != model.CommunityBanTypeBan && banType != model.CommunityBanTypeMute {
+		return apperror.ErrInvalidBanType
+	}
+
+	community, err := s.getModeratedCommunity(communityID, actorID, isAdmin)
+	if err != nil {
+		return err
+	}
+
+	actorObjectID, err := primitive.ObjectIDFromHex(actorID)
+	if err != nil {
+		return apperror.ErrInvalidID
+	}
+
+	ban, err := s.communityBanRepo.Delete(ctx, communityID, userID, banType)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return apperror.ErrBanNotFound
+		}
+		return err
+	}
+
+	action := model.ModActionUnbanUser
+	if banType == model.CommunityBanTypeMute {
+		action = model.ModActionUnmuteUser
+	}
+	s.modLogService.Record(&model.ModLog{
+		CommunityID: &community.ID,
+		ActorID:     actorObjectID,
+		ActorRole:   modLogActorRole(isAdmin),
+		Action:      action,
+		TargetType:  model.ModTargetUser,
+		TargetID:    ban.UserID,
+		Before:      ban,
+	})
+
+	return nil
+}
+
+func (s *communityBanService) GetCommunityBans(communityID string, banType model.CommunityBanType, actorID string, isAdmin bool, page int, pageSize int) (*dto.PaginatedCommunityBansResponse, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	if _, err := s.getModeratedCommunity(communityID, actorID, isAdmin); err != nil {
+		return nil, err
+	}
+
+	bans, total, err := s.communityBanRepo.GetActivePaginated(ctx, communityID, banType, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.PaginatedCommunityBansResponse{
+		Bans: bans,
+		Pagination: dto.Pagination{
+			Page:     page,
+			PageSize: pageSize,
+			Total:    total,
+		},
+	}, nil
+}
+
+// CheckCanJoin rejects users banned from the community
+func (s *communityBanService) CheckCanJoin(communityID string, userID string) error {
+	bans, err := s.getActiveBans(communityID, userID)
+	if err != nil {
+		return err
+	}
+
+	if hasBanOfType(bans, model.CommunityBanTypeBan) {
+		return apperror.ErrBannedFromCommunity
+	}
+	return nil
+}
+
+// CheckCanVote rejects users banned from the community; muted users can still vote
+func (s *communityBanService) CheckCanVote(communityID string, userID string) error {
+	return s.CheckCanJoin(communityID, userID)
+}
+
+// CheckCanWrite rejects banned and muted users from posting or commenting in the community
+func (s *communityBanService) CheckCanWrite(communityID string, userID string) error {
+	bans, err := s.getActiveBans(communityID, userID)
+	if err != nil {
+		return err
+	}
+
+	if hasBanOfType(bans, model.CommunityBanTypeBan) {
+		return apperror.ErrBannedFromCommunity
+	}
+	if hasBanOfType(bans, model.CommunityBanTypeMute) {
+		return apperror.ErrMutedInCommunity
+	}
+	return nil
+}
+
+// CheckCanMessageModerators rejects muted users from modmail. Banned users keep it so they can ask
+// the moderators about their ban.
+func (s *communityBanService) CheckCanMessageModerators(communityID string, userID string) error {
+	bans, err := s.getActiveBans(communityID, userID)
+	if err != nil {
+		return err
+	}
+
+	if hasBanOfType(bans, model.CommunityBanTypeMute) {
+		return apperror.ErrMutedInCommunity
+	}
+	return nil
+}
+
+// StartExpiredBanCleanup periodically removes expired bans. Expired bans are already ignored by
+// every check, so this only keeps the collection small.
+func (s *communityBanService) StartExpiredBanCleanup() {
+	ticker := time.NewTicker(10 * time.Minute)
+
+	go func() {
+		for range ticker.C {
+			ctx, cancel := util.NewDefaultDBContext()
+			count, err := s.communityBanRepo.DeleteExpired(ctx)
+			cancel()
+
+			if err != nil {
+				log.Printf("⚠️ Expired community ban cleanup failed: %v", err)
+			} else if count > 0 {
+				log.Printf("✅ Removed %d expired community bans", count)
+			}
+		}
+	}()
+}
+
+func (s *communityBanService) getActiveBans(communityID string, userID string) ([]model.CommunityBan, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	if !primitive.IsValidObjectID(communityID) || !primitive.IsValidObjectID(userID) {
+		return nil, apperror.ErrInvalidID
+	}
+
+	return s.communityBanRepo.GetActive(ctx, communityID, userID)
+}
+
+// getModeratedCommunity loads the community and makes sure the actor may ban and mute users in it
+func (s *communityBanService) getModeratedCommunity(communityID string, actorID string, isAdmin bool) (*model.Community, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	community, err := s.communityRepo.GetByID(ctx, communityID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, apperror.ErrCommunityNotFound
+		}
+		return nil, err
+	}
+
+	if isAdmin {
+		return community, nil
+	}
+
+	actorObjectID, err := primitive.ObjectIDFromHex(actorID)
+	if err != nil {
+		return nil, apperror.ErrInvalidID
+	}
+	if !hasModPermission(community, actorObjectID, model.ModPermissionUsers) {
+		return nil, apperror.ErrForbidden
+	}
+
+	return community, nil
+}
+
+// notifyBannedUser tells the user why and for how long they were banned or muted, and which action to appeal
+func (s *communityBanService) notifyBannedUser(community *model.Community, ban *model.CommunityBan, entry *model.ModLog) {
+	verb := "banned from"
+	if ban.Type == model.CommunityBanTypeMute {
+		verb = "muted in"
+	}
+
+	duration := "permanently"
+	if ban.ExpiresAt != nil {
+		duration = "until " + ban.ExpiresAt.UTC().Format(time.RFC1123)
+	}
+
+	message := fmt.Sprintf("You have been %s %s %s. Reason: %s", verb, community.Name, duration, ban.Reason)
+	metadata := map[string]interface{}{
+		"community_id": community.ID.Hex(),
+		"type":         ban.Type,
+		"reason":       ban.Reason,
+	}
+	if ban.ExpiresAt != nil {
+		metadata["expires_at"] = ban.ExpiresAt
+	}
+	if !entry.ID.IsZero() {
+		metadata["mod_log_id"] = entry.ID.Hex()
+	}
+
+	if err := s.notificationService.Notify(ban.UserID, model.NotificationTypeBan, message, metadata); err != nil {
+		log.Printf("failed to notify user %s of community ban: %v", ban.UserID.Hex(), err)
+	}
+}
+
+func hasBanOfType(bans []model.CommunityBan, banType model.CommunityBanType) bool {
+	for _, ban := range bans {
+		if ban.Type == banType {
+			return true
+		}
+	}
+	return false
+}
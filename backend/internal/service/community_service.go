@@ -3,6 +3,7 @@ package service
 import (
 	"errors"
 	"fmt"
+	"log"
 	"time"
 
 	"github.com/giakiet05/lkforum/internal/apperror"
@@ -10,29 +11,47 @@ import (
 	"github.com/giakiet05/lkforum/internal/model"
 	"github.com/giakiet05/lkforum/internal/repo"
 	"github.com/giakiet05/lkforum/internal/util"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type CommunityService interface {
 	CreateCommunity(req *dto.CreateCommunityRequest, userID string) (*model.Community, error)
-	GetCommunityByID(id string) (*model.Community, error)
+	GetCommunityByID(id string, isAdmin bool) (*model.Community, error)
 	GetCommunitiesFilter(name string, description string, createFrom time.Time, page int, pageSize int) (*dto.PaginatedCommunitiesResponse, error)
 	GetCommunitiesByModeratorIDPaginated(moderatorID string, page int, pageSize int) (*dto.PaginatedCommunitiesResponse, error)
 	GetAllCommunitiesPaginated(page int, pageSize int) (*dto.PaginatedCommunitiesResponse, error)
 	UpdateCommunity(req *dto.UpdateCommunityRequest, userID string) (*model.Community, error)
 	AddModerator(req *dto.AddModeratorRequest, userID string) error
 	RemoveModerator(req *dto.RemoveModeratorRequest, userID string) error
+	UpdateModeratorPermissions(req *dto.UpdateModeratorPermissionsRequest, userID string) error
+	OfferOwnership(communityID string, newOwnerID string, userID string) error
+	AcceptOwnership(communityID string, userID string) error
+	CancelOwnershipTransfer(communityID string, userID string) error
 	IsModerator(community *model.Community, userID string) (bool, error)
 	DeleteCommunityByID(communityID string, userID string) error
 }
 
 type communityService struct {
-	communityRepo repo.CommunityRepo
+	communityRepo          repo.CommunityRepo
+	moderatorInviteService ModeratorInviteService
+	notificationService    NotificationService
+	modLogService          ModLogService
 }
 
-func NewCommunityService(communityRepo repo.CommunityRepo) CommunityService {
-	return &communityService{communityRepo: communityRepo}
+func NewCommunityService(
+	communityRepo repo.CommunityRepo,
+	moderatorInviteService ModeratorInviteService,
+	notificationService NotificationService,
+	modLogService ModLogService,
+) CommunityService {
+	return &communityService{
+		communityRepo:          communityRepo,
+		moderatorInviteService: moderatorInviteService,
+		notificationService:    notificationService,
+		modLogService:          modLogService,
+	}
 }
 
 func (c *communityService) CreateCommunity(req *dto.CreateCommunityRequest, userID string) (*model.Community, error) {
@@ -44,24 +63,32 @@ func (c *communityService) CreateCommunity(req *dto.CreateCommunityRequest, user
 		return nil, err
 	}
 
-	existed, err := c.communityRepo.IsUserExist(ctx, userID)
-	if err != nil {
+	if err := validateCommunitySetting(&req.Setting); err != nil {
 		return nil, err
 	}
-	if !existed {
-		return nil, apperror.ErrUserNotFound
+
+	creatorName, err := c.communityRepo.GetUsername(ctx, userID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, apperror.ErrUserNotFound
+		}
+		return nil, err
 	}
 
+	// The creator is the owner and heads the moderator list
+	now := time.Now()
+	moderators := []model.Moderator{{UserID: userObjectID, Username: creatorName, AssignedAt: now}}
+
 	community := &model.Community{
 		Name:           req.Name,
 		Description:    req.Description,
 		Avatar:         req.Avatar,
 		Banner:         req.Banner,
 		Setting:        req.Setting,
-		Moderators:     req.Moderators,
-		CreateAt:       time.Now(),
+		Moderators:     moderators,
+		CreateAt:       now,
 		CreateByID:     userObjectID,
-		CreateByName:   req.CreatorName,
+		CreateByName:   creatorName,
 		CreateByAvatar: req.CreatorAvatar,
 		IsDeleted:      false,
 		IsBanned:       false,
@@ -74,10 +101,24 @@ func (c *communityService) CreateCommunity(req *dto.CreateCommunityRequest, user
 		return nil, err
 	}
 
+	// Everyone else the creator listed is invited, not added
+	var invitees []dto.ModeratorDTO
+	for _, mod := range req.Moderators {
+		if mod.ModeratorID != userID {
+			invitees = append(invitees, mod)
+		}
+	}
+	if len(invitees) > 0 {
+		if _, err := c.moderatorInviteService.InviteModerators(community, invitees, userID); err != nil {
+			log.Printf("failed to invite moderators to community %s: %v", community.ID.Hex(), err)
+		}
+	}
+
 	return community, nil
 }
 
-func (c *communityService) GetCommunityByID(id string) (*model.Community, error) {
+// GetCommunityByID returns the community. Banned communities are only shown to admins.
+func (c *communityService) GetCommunityByID(id string, isAdmin bool) (*model.Community, error) {
 	ctx, cancel := util.NewDefaultDBContext()
 	defer cancel()
 
@@ -88,6 +129,9 @@ func (c *communityService) GetCommunityByID(id string) (*model.Community, error)
 		}
 		return nil, err
 	}
+	if community.IsBanned && !isAdmin {
+		return nil, apperror.ErrCommunityNotFound
+	}
 
 	return community, nil
 }
@@ -170,40 +214,62 @@ func (c *communityService) UpdateCommunity(req *dto.UpdateCommunityRequest, user
 		return nil, err
 	}
 
-	ok, err := c.IsModerator(community, userID)
-	if err != nil {
+	if err := requireModPermission(community, userID, model.ModPermissionSettings); err != nil {
 		return nil, err
 	}
-	if !ok {
-		return nil, apperror.ErrForbidden
-	}
 
-	var updateCount = 0
+	before := communitySnapshot(community)
+
+	// Only the fields sent are written, so concurrent moderator changes and bans are kept
+	updates := bson.M{}
 	if req.Description != nil {
-		community.Description = req.Description
-		updateCount++
+		updates["description"] = req.Description
 	}
 	if req.Avatar != nil {
-		community.Avatar = req.Avatar
-		updateCount++
+		updates["avatar"] = req.Avatar
 	}
 	if req.Banner != nil {
-		community.Banner = req.Banner
-		updateCount++
+		updates["banner"] = req.Banner
 	}
 	if req.Setting != nil {
-		community.Setting = *req.Setting
-		updateCount++
+		if err := validateCommunitySetting(req.Setting); err != nil {
+			return nil, err
+		}
+		updates["setting"] = req.Setting
 	}
 
-	if updateCount == 0 {
+	if len(updates) == 0 {
 		return nil, apperror.ErrNoFieldsToUpdate
 	}
 
-	return community, c.communityRepo.Replace(ctx, community)
+	updated, err := c.communityRepo.Update(ctx, req.CommunityID, updates)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, apperror.ErrCommunityNotFound
+		}
+		return nil, err
+	}
+
+	c.recordModAction(updated, userID, model.ModActionUpdateCommunity, model.ModTargetCommunity, updated.ID, before, communitySnapshot(updated))
+	return updated, nil
 }
 
+// AddModerator invites the users as moderators; they join the moderator list once they accept
 func (c *communityService) AddModerator(req *dto.AddModeratorRequest, userID string) error {
+	community, err := c.getCommunity(req.CommunityID)
+	if err != nil {
+		return err
+	}
+
+	if err := requireModPermission(community, userID, model.ModPermissionMods); err != nil {
+		return err
+	}
+
+	_, err = c.moderatorInviteService.InviteModerators(community, req.AddedModerator, userID)
+	return err
+}
+
+func (c *communityService) RemoveModerator(req *dto.RemoveModeratorRequest, userID string) error {
 	ctx, cancel := util.NewDefaultDBContext()
 	defer cancel()
 
@@ -212,105 +278,247 @@ func (c *communityService) AddModerator(req *dto.AddModeratorRequest, userID str
 		return err
 	}
 
-	ok, err := c.IsModerator(community, userID)
-	if err != nil {
+	if err := requireModPermission(community, userID, model.ModPermissionMods); err != nil {
 		return err
 	}
-	if !ok {
-		return apperror.ErrForbidden
-	}
 
-	var newModerators []model.Moderator
-	for _, modDTO := range req.AddedModerator {
-		ok, err := c.IsModerator(community, modDTO.ModeratorID)
-		if err != nil {
-			return err
+	var removedModerators []model.Moderator
+	for _, modID := range req.RemovedModerator {
+		if userID == modID {
+			return fmt.Errorf("cannot remove yourself as a moderator")
 		}
-		if ok {
-			continue
+
+		// Moderators can only remove the ones below them, so nobody can remove the owner
+		if !outranksModerator(community, userID, modID) {
+			return apperror.ErrModeratorOutranked
 		}
 
-		objectID, err := primitive.ObjectIDFromHex(modDTO.ModeratorID)
-		if err != nil {
-			return apperror.ErrInvalidID
+		for _, mod := range community.Moderators {
+			if mod.UserID.Hex() == modID {
+				removedModerators = append(removedModerators, mod)
+				break
+			}
 		}
+	}
+	if len(removedModerators) == 0 {
+		return nil
+	}
 
-		existed, err := c.communityRepo.IsUserExist(ctx, modDTO.ModeratorID)
-		if err != nil {
-			return err
+	removedIDs := make([]primitive.ObjectID, 0, len(removedModerators))
+	for _, mod := range removedModerators {
+		removedIDs = append(removedIDs, mod.UserID)
+	}
+	if err := c.communityRepo.RemoveModerators(ctx, community.ID, removedIDs); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return apperror.ErrCommunityNotFound
 		}
-		if !existed {
-			return fmt.Errorf("new moderator id not found: %s", modDTO.ModeratorID)
+		return err
+	}
+
+	for _, mod := range removedModerators {
+		c.recordModAction(community, userID, model.ModActionRemoveModerator, model.ModTargetUser, mod.UserID, mod, nil)
+	}
+	return nil
+}
+
+func (c *communityService) UpdateModeratorPermissions(req *dto.UpdateModeratorPermissionsRequest, userID string) error {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	community, err := c.getCommunity(req.CommunityID)
+	if err != nil {
+		return err
+	}
+
+	if err := requireModPermission(community, userID, model.ModPermissionMods); err != nil {
+		return err
+	}
+	if err := validateModPermissions(req.Permissions); err != nil {
+		return err
+	}
+
+	moderatorObjectID, err := primitive.ObjectIDFromHex(req.ModeratorID)
+	if err != nil {
+		return apperror.ErrInvalidID
+	}
+	mod := findModerator(community, moderatorObjectID)
+	if mod == nil {
+		return apperror.ErrNotModerator
+	}
+	if !outranksModerator(community, userID, req.ModeratorID) {
+		return apperror.ErrModeratorOutranked
+	}
+	if !canGrantModPermissions(community, userID, req.Permissions) {
+		return apperror.ErrForbidden
+	}
+
+	before := *mod
+	mod.Permissions = req.Permissions
+
+	if err := c.communityRepo.SetModeratorPermissions(ctx, community.ID, mod.UserID, req.Permissions); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return apperror.ErrNotModerator
 		}
+		return err
+	}
+
+	c.recordModAction(community, userID, model.ModActionUpdateModerator, model.ModTargetUser, mod.UserID, before, *mod)
+	return nil
+}
+
+// OfferOwnership lets the owner hand the community to another moderator. Ownership only moves once
+// the new owner accepts.
+func (c *communityService) OfferOwnership(communityID string, newOwnerID string, userID string) error {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	community, err := c.getCommunity(communityID)
+	if err != nil {
+		return err
+	}
 
-		newModerators = append(
-			newModerators,
-			model.Moderator{
-				UserID:     objectID,
-				Username:   modDTO.Username,
-				AssignedAt: time.Now(),
-			})
+	if community.CreateByID.Hex() != userID {
+		return apperror.ErrForbidden
+	}
+	if newOwnerID == userID {
+		return apperror.ErrBadRequest
+	}
+
+	newOwnerObjectID, err := primitive.ObjectIDFromHex(newOwnerID)
+	if err != nil {
+		return apperror.ErrInvalidID
+	}
+	if findModerator(community, newOwnerObjectID) == nil {
+		return apperror.ErrNotModerator
 	}
 
-	if len(newModerators) == 0 {
-		return fmt.Errorf("no new modertor to add")
+	if err := c.communityRepo.SetPendingOwner(ctx, community.ID, community.CreateByID, newOwnerObjectID); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return apperror.ErrNotModerator
+		}
+		return err
+	}
+
+	c.recordModAction(community, userID, model.ModActionOfferOwnership, model.ModTargetUser, newOwnerObjectID, nil, nil)
+
+	message := fmt.Sprintf("You have been offered ownership of %s", community.Name)
+	metadata := map[string]interface{}{"community_id": community.ID.Hex()}
+	if err := c.notificationService.Notify(newOwnerObjectID, model.NotificationTypeModerator, message, metadata); err != nil {
+		log.Printf("failed to notify user %s of ownership offer: %v", newOwnerID, err)
 	}
 
-	community.Moderators = append(community.Moderators, newModerators...)
-	return c.communityRepo.Replace(ctx, community)
+	return nil
 }
 
-func (c *communityService) RemoveModerator(req *dto.RemoveModeratorRequest, userID string) error {
+// AcceptOwnership makes the pending owner the head of the moderator list. The previous owner
+// stays on as the most senior moderator below them.
+func (c *communityService) AcceptOwnership(communityID string, userID string) error {
 	ctx, cancel := util.NewDefaultDBContext()
 	defer cancel()
 
-	community, err := c.communityRepo.GetByID(ctx, req.CommunityID)
+	community, err := c.getCommunity(communityID)
 	if err != nil {
 		return err
 	}
 
-	ok, err := c.IsModerator(community, userID)
+	if community.PendingOwnerID == nil || community.PendingOwnerID.Hex() != userID {
+		return apperror.ErrNoPendingTransfer
+	}
+
+	newOwner := findModerator(community, *community.PendingOwnerID)
+	if newOwner == nil {
+		return apperror.ErrNotModerator
+	}
+
+	previousOwnerID := community.CreateByID
+	owner := *newOwner
+	owner.Permissions = nil
+
+	if err := c.communityRepo.TransferOwnership(ctx, community.ID, previousOwnerID, owner); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return apperror.ErrNoPendingTransfer
+		}
+		return err
+	}
+
+	c.recordModAction(community, userID, model.ModActionTransferOwnership, model.ModTargetCommunity, community.ID,
+		map[string]interface{}{"owner_id": previousOwnerID},
+		map[string]interface{}{"owner_id": owner.UserID},
+	)
+	return nil
+}
+
+// CancelOwnershipTransfer withdraws a pending offer, either by the owner or by declining it
+func (c *communityService) CancelOwnershipTransfer(communityID string, userID string) error {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	community, err := c.getCommunity(communityID)
 	if err != nil {
 		return err
 	}
-	if !ok {
+
+	if community.PendingOwnerID == nil {
+		return apperror.ErrNoPendingTransfer
+	}
+	if community.CreateByID.Hex() != userID && community.PendingOwnerID.Hex() != userID {
 		return apperror.ErrForbidden
 	}
 
-	for _, modID := range req.RemovedModerator {
-		if userID == modID {
-			return fmt.Errorf("cannot remove yourself as a moderator")
+	pendingOwnerID := *community.PendingOwnerID
+	if err := c.communityRepo.ClearPendingOwner(ctx, community.ID, pendingOwnerID); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return apperror.ErrNoPendingTransfer
 		}
+		return err
+	}
 
-		for i, mod := range community.Moderators {
-			if mod.UserID.Hex() == modID {
-				community.Moderators = append(community.Moderators[:i], community.Moderators[i+1:]...)
-				break
-			}
+	c.recordModAction(community, userID, model.ModActionCancelOwnership, model.ModTargetUser, pendingOwnerID, nil, nil)
+	return nil
+}
+
+func (c *communityService) getCommunity(communityID string) (*model.Community, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	if !primitive.IsValidObjectID(communityID) {
+		return nil, apperror.ErrInvalidID
+	}
+
+	community, err := c.communityRepo.GetByID(ctx, communityID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, apperror.ErrCommunityNotFound
 		}
+		return nil, err
+	}
+	if community.IsBanned {
+		return nil, apperror.ErrCommunityNotFound
 	}
 
-	return c.communityRepo.Replace(ctx, community)
+	return community, nil
 }
 
 func (c *communityService) DeleteCommunityByID(communityID string, userID string) error {
 	ctx, cancel := util.NewDefaultDBContext()
 	defer cancel()
 
-	community, err := c.communityRepo.GetByID(ctx, communityID)
+	community, err := c.getCommunity(communityID)
 	if err != nil {
 		return err
 	}
 
-	ok, err := c.IsModerator(community, userID)
-	if err != nil {
-		return err
+	// Only the owner can delete the community
+	if community.CreateByID.Hex() != userID {
+		return apperror.ErrForbidden
 	}
-	if !ok {
-		return fmt.Errorf("user is not a moderator of the community")
+
+	if err := c.communityRepo.Delete(ctx, communityID); err != nil {
+		return err
 	}
 
-	return c.communityRepo.Delete(ctx, communityID)
+	c.recordModAction(community, userID, model.ModActionDeleteCommunity, model.ModTargetCommunity, community.ID, communitySnapshot(community), nil)
+	return nil
 }
 
 func (c *communityService) IsModerator(community *model.Community, userID string) (bool, error) {
@@ -319,10 +527,157 @@ func (c *communityService) IsModerator(community *model.Community, userID string
 		return false, fmt.Errorf("invalid user id: %s", userID)
 	}
 
+	return isCommunityModerator(community, objectID), nil
+}
+
+// canModerate is isCommunityModerator for acting as one. Moderators of a banned community keep their
+// place but cannot use it until an admin lifts the ban.
+func canModerate(community *model.Community, userID primitive.ObjectID) bool {
+	return !community.IsBanned && isCommunityModerator(community, userID)
+}
+
+// isCommunityModerator reports whether the user is the owner or listed as a moderator of the community
+func isCommunityModerator(community *model.Community, userID primitive.ObjectID) bool {
+	if community.CreateByID == userID {
+		return true
+	}
 	for _, m := range community.Moderators {
-		if m.UserID == objectID {
-			return true, nil
+		if m.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// findModerator returns the moderator entry of the user, or nil if they do not moderate the community
+func findModerator(community *model.Community, userID primitive.ObjectID) *model.Moderator {
+	for i := range community.Moderators {
+		if community.Moderators[i].UserID == userID {
+			return &community.Moderators[i]
+		}
+	}
+	return nil
+}
+
+// moderatorRank returns the seniority of the user, 0 being the owner, or -1 if they do not moderate the community
+func moderatorRank(community *model.Community, userID primitive.ObjectID) int {
+	if community.CreateByID == userID {
+		return 0
+	}
+	for i, m := range community.Moderators {
+		if m.UserID == userID {
+			return i + 1
 		}
 	}
-	return false, nil
+	return -1
+}
+
+// outranksModerator reports whether the actor is more senior than the target moderator
+func outranksModerator(community *model.Community, actorID string, targetID string) bool {
+	actorObjectID, err := primitive.ObjectIDFromHex(actorID)
+	if err != nil {
+		return false
+	}
+	targetObjectID, err := primitive.ObjectIDFromHex(targetID)
+	if err != nil {
+		return false
+	}
+
+	actorRank := moderatorRank(community, actorObjectID)
+	return actorRank >= 0 && moderatorRank(community, targetObjectID) > actorRank
+}
+
+// hasModPermission reports whether the user moderates the community with the given permission.
+// The owner holds every permission. Nobody moderates a banned community, see canModerate.
+func hasModPermission(community *model.Community, userID primitive.ObjectID, permission model.ModPermission) bool {
+	if community.IsBanned {
+		return false
+	}
+	if community.CreateByID == userID {
+		return true
+	}
+	mod := findModerator(community, userID)
+	return mod != nil && mod.HasPermission(permission)
+}
+
+// requireModPermission is hasModPermission for a user ID taken from the request
+func requireModPermission(community *model.Community, userID string, permission model.ModPermission) error {
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return apperror.ErrInvalidID
+	}
+	if !hasModPermission(community, userObjectID, permission) {
+		return apperror.ErrForbidden
+	}
+	return nil
+}
+
+// canGrantModPermissions checks that the actor holds every permission they hand out.
+// An empty set means full permissions.
+func canGrantModPermissions(community *model.Community, actorID string, permissions []model.ModPermission) bool {
+	if len(permissions) == 0 {
+		permissions = model.ModPermissions
+	}
+	for _, p := range permissions {
+		if requireModPermission(community, actorID, p) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func validateModPermissions(permissions []model.ModPermission) error {
+	for _, p := range permissions {
+		if !model.IsValidModPermission(p) {
+			return apperror.ErrInvalidModPermission
+		}
+	}
+	return nil
+}
+
+// validateCommunitySetting rejects negative lengths, intervals and thresholds
+func validateCommunitySetting(setting *model.CommunitySetting) error {
+	if setting.MaxPostLength < 0 || setting.PostIntervalSeconds < 0 || setting.CommentIntervalSeconds < 0 ||
+		setting.MinAccountAgeDays < 0 || setting.MinKarma < 0 {
+		return apperror.ErrInvalidSetting
+	}
+	return nil
+}
+
+// recordModAction appends a moderator action on the community to the mod log
+func (c *communityService) recordModAction(
+	community *model.Community,
+	actorID string,
+	action model.ModAction,
+	targetType model.ModTargetType,
+	targetID primitive.ObjectID,
+	before interface{},
+	after interface{},
+) {
+	actorObjectID, err := primitive.ObjectIDFromHex(actorID)
+	if err != nil {
+		return
+	}
+
+	c.modLogService.Record(&model.ModLog{
+		CommunityID: &community.ID,
+		ActorID:     actorObjectID,
+		ActorRole:   model.ModLogActorModerator,
+		Action:      action,
+		TargetType:  targetType,
+		TargetID:    targetID,
+		Before:      before,
+		After:       after,
+	})
+}
+
+// communitySnapshot captures the moderator-editable part of a community for the mod log
+func communitySnapshot(community *model.Community) map[string]interface{} {
+	return map[string]interface{}{
+		"name":        community.Name,
+		"description": community.Description,
+		"avatar":      community.Avatar,
+		"banner":      community.Banner,
+		"setting":     community.Setting,
+	}
 }
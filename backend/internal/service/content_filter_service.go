@@ -0,0 +1,392 @@
+package service
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/giakiet05/lkforum/internal/apperror"
+	"github.com/giakiet05/lkforum/internal/config"
+	"github.com/giakiet05/lkforum/internal/dto"
+	"github.com/giakiet05/lkforum/internal/model"
+	"github.com/giakiet05/lkforum/internal/repo"
+	"github.com/giakiet05/lkforum/internal/util"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	maxContentFilterEntries = 1000
+	maxContentFilterPattern = 100
+)
+
+// ContentFilterService manages per-community banned word and domain lists. Anything written into a
+// community calls Check before saving: a blocked result must be rejected with its message, a queued
+// one saved as filtered, and the returned text saved in place of the original. Modmail from users is
+// checked today; posts and comments will be once they have a service. Direct messages belong to no
+// community and are not filtered.
+type ContentFilterService interface {
+	GetFilter(communityID string, userID string) (*model.ContentFilter, error)
+	UpdateFilter(communityID string, req *dto.UpdateContentFilterRequest, userID string) (*model.ContentFilter, error)
+	TestContent(communityID string, req *dto.CheckContentRequest, userID string) (*dto.ContentFilterResult, error)
+
+	Check(communityID string, text string, links []string) (*dto.ContentFilterResult, error)
+}
+
+type contentFilterService struct {
+	contentFilterRepo repo.ContentFilterRepo
+	communityRepo     repo.CommunityRepo
+	modLogService     ModLogService
+
+	cacheTTL time.Duration
+	mu       sync.RWMutex
+	cache    map[string]*compiledContentFilter
+}
+
+// compiledContentFilter is a community's filter with its patterns normalized for matching
+type compiledContentFilter struct {
+	words    []compiledFilterEntry
+	domains  []compiledFilterEntry
+	loadedAt time.Time
+}
+
+type compiledFilterEntry struct {
+	entry   model.ContentFilterEntry
+	pattern []rune
+}
+
+func NewContentFilterService(contentFilterRepo repo.ContentFilterRepo, communityRepo repo.CommunityRepo, modLogService ModLogService) ContentFilterService {
+	return &contentFilterService{
+		contentFilterRepo: contentFilterRepo,
+		communityRepo:     communityRepo,
+		modLogService:     modLogService,
+		cacheTTL:          time.Duration(config.GetEnvIntWithDefault("CONTENT_FILTER_CACHE_TTL_SECONDS", 300)) * time.Second,
+		cache:             make(map[string]*compiledContentFilter),
+	}
+}
+
+func (s *contentFilterService) GetFilter(communityID string, userID string) (*model.ContentFilter, error) {
+	community, err := s.getCommunity(communityID)
+	if err != nil {
+		return nil, err
+	}
+
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, apperror.ErrInvalidID
+	}
+	if !canModerate(community, userObjectID) {
+		return nil, apperror.ErrForbidden
+	}
+
+	return s.getFilter(community.ID)
+}
+
+func (s *contentFilterService) UpdateFilter(communityID string, req *dto.UpdateContentFilterRequest, userID string) (*model.ContentFilter, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	community, err := s.getCommunity(communityID)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireModPermission(community, userID, model.ModPermissionSettings); err != nil {
+		return nil, err
+	}
+
+	entries, err := cleanContentFilterEntries(req.Entries)
+	if err != nil {
+		return nil, err
+	}
+
+	before, err := s.getFilter(community.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	userObjectID, _ := primitive.ObjectIDFromHex(userID)
+	saved, err := s.contentFilterRepo.Upsert(ctx, &model.ContentFilter{
+		CommunityID: community.ID,
+		Entries:     entries,
+		UpdatedBy:   userObjectID,
+		UpdatedAt:   time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	delete(s.cache, communityID)
+	s.mu.Unlock()
+
+	s.modLogService.Record(&model.ModLog{
+		CommunityID: &community.ID,
+		ActorID:     userObjectID,
+		ActorRole:   model.ModLogActorModerator,
+		Action:      model.ModActionUpdateFilters,
+		TargetType:  model.ModTargetCommunity,
+		TargetID:    community.ID,
+		Before:      before.Entries,
+		After:       saved.Entries,
+	})
+
+	return saved, nil
+}
+
+// TestContent lets moderators see what the saved filter would do to a piece of text
+func (s *contentFilterService) TestContent(communityID string, req *dto.CheckContentRequest, userID string) (*dto.ContentFilterResult, error) {
+	community, err := s.getCommunity(communityID)
+	if err != nil {
+		return nil, err
+	}
+
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, apperror.ErrInvalidID
+	}
+	if !canModerate(community, userObjectID) {
+		return nil, apperror.ErrForbidden
+	}
+
+	return s.Check(communityID, req.Text, req.Links)
+}
+
+func (s *contentFilterService) Check(communityID string, text string, links []string) (*dto.ContentFilterResult, error) {
+	compiled, err := s.getCompiled(communityID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &dto.ContentFilterResult{Text: text, Matches: []model.ContentFilterEntry{}}
+	apply := func(entry model.ContentFilterEntry) {
+		result.Matches = append(result.Matches, entry)
+		switch entry.Action {
+		case model.ContentFilterActionBlock:
+			if !result.Blocked {
+				result.Blocked = true
+				result.Message = entry.Message
+			}
+		case model.ContentFilterActionQueue:
+			result.Queued = true
+		}
+	}
+
+	original := []rune(text)
+	normalized, origins := util.NormalizeForMatching(text)
+	masked := make([]bool, len(original))
+	for _, word := range compiled.words {
+		spans := findFilterWord(normalized, origins, original, word.pattern)
+		if len(spans) == 0 {
+			continue
+		}
+		apply(word.entry)
+		if word.entry.Action == model.ContentFilterActionMask {
+			for _, span := range spans {
+				for i := span[0]; i < span[1]; i++ {
+					masked[i] = true
+				}
+			}
+		}
+	}
+
+	maskedText := make([]rune, len(original))
+	for i, r := range original {
+		if masked[i] && !unicode.IsSpace(r) {
+			r = '*'
+		}
+		maskedText[i] = r
+	}
+	result.Text = string(maskedText)
+
+	allLinks := append(extractLinks(text), links...)
+	for _, domain := range compiled.domains {
+		matched := false
+		for _, link := range allLinks {
+			if !linkMatchesFilterDomain(link, string(domain.pattern)) {
+				continue
+			}
+			matched = true
+			if domain.entry.Action == model.ContentFilterActionMask {
+				result.Text = strings.ReplaceAll(result.Text, link, strings.Repeat("*", len([]rune(link))))
+			}
+		}
+		if matched {
+			apply(domain.entry)
+		}
+	}
+
+	if result.Blocked && result.Message == "" {
+		result.Message = "Your submission contains words or links that are not allowed in this community"
+	}
+
+	return result, nil
+}
+
+// getCompiled returns the community's filter from the in-process cache, reloading it once it is older
+// than the cache TTL. Updates made through this instance evict the entry right away.
+func (s *contentFilterService) getCompiled(communityID string) (*compiledContentFilter, error) {
+	s.mu.RLock()
+	compiled, ok := s.cache[communityID]
+	s.mu.RUnlock()
+	if ok && time.Since(compiled.loadedAt) < s.cacheTTL {
+		return compiled, nil
+	}
+
+	communityObjectID, err := primitive.ObjectIDFromHex(communityID)
+	if err != nil {
+		return nil, apperror.ErrInvalidID
+	}
+
+	filter, err := s.getFilter(communityObjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	compiled = &compiledContentFilter{loadedAt: time.Now()}
+	for _, entry := range filter.Entries {
+		switch entry.Kind {
+		case model.ContentFilterKindWord:
+			normalized, _ := util.NormalizeForMatching(strings.TrimSpace(entry.Pattern))
+			compiled.words = append(compiled.words, compiledFilterEntry{entry: entry, pattern: normalized})
+		case model.ContentFilterKindDomain:
+			compiled.domains = append(compiled.domains, compiledFilterEntry{entry: entry, pattern: []rune(util.NormalizeString(entry.Pattern))})
+		}
+	}
+
+	s.mu.Lock()
+	s.cache[communityID] = compiled
+	s.mu.Unlock()
+
+	return compiled, nil
+}
+
+// getFilter returns the community's filter, an empty one if it was never saved
+func (s *contentFilterService) getFilter(communityID primitive.ObjectID) (*model.ContentFilter, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	filter, err := s.contentFilterRepo.GetByCommunity(ctx, communityID.Hex())
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return &model.ContentFilter{CommunityID: communityID, Entries: []model.ContentFilterEntry{}}, nil
+		}
+		return nil, err
+	}
+
+	return filter, nil
+}
+
+func (s *contentFilterService) getCommunity(communityID string) (*model.Community, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	if !primitive.IsValidObjectID(communityID) {
+		return nil, apperror.ErrInvalidID
+	}
+
+	community, err := s.communityRepo.GetByID(ctx, communityID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, apperror.ErrCommunityNotFound
+		}
+		return nil, err
+	}
+
+	return community, nil
+}
+
+// cleanContentFilterEntries validates the entries and stores domains as bare lower-case host names,
+// without www. so the entry covers every subdomain
+func cleanContentFilterEntries(entries []model.ContentFilterEntry) ([]model.ContentFilterEntry, error) {
+	if len(entries) > maxContentFilterEntries {
+		return nil, apperror.ErrInvalidContentFilter
+	}
+
+	cleaned := make([]model.ContentFilterEntry, 0, len(entries))
+	for _, entry := range entries {
+		entry.Pattern = strings.TrimSpace(entry.Pattern)
+		if entry.Kind == model.ContentFilterKindDomain {
+			entry.Pattern = strings.TrimPrefix(filterDomainHost(entry.Pattern), "www.")
+		}
+
+		if entry.Kind != model.ContentFilterKindWord && entry.Kind != model.ContentFilterKindDomain {
+			return nil, apperror.ErrInvalidContentFilter
+		}
+		if entry.Action != model.ContentFilterActionBlock && entry.Action != model.ContentFilterActionQueue && entry.Action != model.ContentFilterActionMask {
+			return nil, apperror.ErrInvalidContentFilter
+		}
+		if util.NormalizeString(entry.Pattern) == "" || len([]rune(entry.Pattern)) > maxContentFilterPattern {
+			return nil, apperror.ErrInvalidContentFilter
+		}
+
+		cleaned = append(cleaned, entry)
+	}
+
+	return cleaned, nil
+}
+
+// findFilterWord finds the pattern as a whole word or phrase in the normalized text and returns the
+// matching rune ranges of the original text. Word boundaries are judged on the original runes so
+// leetspeak symbols next to a word do not hide it.
+func findFilterWord(normalized []rune, origins []int, original []rune, pattern []rune) [][2]int {
+	var spans [][2]int
+	if len(pattern) == 0 {
+		return spans
+	}
+
+	for i := 0; i+len(pattern) <= len(normalized); i++ {
+		matched := true
+		for j, r := range pattern {
+			if normalized[i+j] != r {
+				matched = false
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+
+		start := origins[i]
+		end := origins[i+len(pattern)-1] + 1
+		for end < len(original) && unicode.Is(unicode.Mn, original[end]) {
+			end++
+		}
+
+		if start > 0 && isFilterWordRune(original[start-1]) {
+			continue
+		}
+		if end < len(original) && isFilterWordRune(original[end]) {
+			continue
+		}
+
+		spans = append(spans, [2]int{start, end})
+	}
+
+	return spans
+}
+
+func isFilterWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r)
+}
+
+// filterDomainHost reduces a domain entry or link to its lower-case host name
+func filterDomainHost(value string) string {
+	if !strings.Contains(value, "://") {
+		value = "http://" + value
+	}
+	parsed, err := url.Parse(value)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(parsed.Hostname()), ".")
+}
+
+// linkMatchesFilterDomain compares hosts after normalization, so look-alike letters in a link do
+// not get it past a banned domain
+func linkMatchesFilterDomain(link string, domain string) bool {
+	host := util.NormalizeString(filterDomainHost(link))
+	return domain != "" && (host == domain || strings.HasSuffix(host, "."+domain))
+}
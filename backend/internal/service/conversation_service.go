@@ -0,0 +1,333 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"github.com/giakiet05/lkforum/internal/apperror"
+	"github.com/giakiet05/lkforum/internal/dto"
+	"github.com/giakiet05/lkforum/internal/model"
+	"github.com/giakiet05/lkforum/internal/repo"
+	"github.com/giakiet05/lkforum/internal/util"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type ConversationService interface {
+	SendDirectMessage(req *dto.SendDirectMessageRequest, senderID string) (*model.Message, error)
+	GetInbox(userID string, page int, pageSize int) (*dto.PaginatedConversationsResponse, error)
+	GetMessageRequests(userID string, page int, pageSize int) (*dto.PaginatedConversationsResponse, error)
+	GetMessages(conversationID string, userID string, page int, pageSize int) (*dto.PaginatedMessagesResponse, error)
+	MarkAsRead(conversationID string, userID string) error
+
+	AcceptRequest(conversationID string, userID string) error
+	DeclineRequest(conversationID string, userID string) error
+	BlockRequest(conversationID string, userID string) error
+	UnblockUser(blockedUserID string, userID string) error
+}
+
+type conversationService struct {
+	conversationRepo repo.ConversationRepo
+	membershipRepo   repo.MembershipRepo
+}
+
+func NewConversationService(conversationRepo repo.ConversationRepo, membershipRepo repo.MembershipRepo) ConversationService {
+	return &conversationService{conversationRepo: conversationRepo, membershipRepo: membershipRepo}
+}
+
+func (s *conversationService) SendDirectMessage(req *dto.SendDirectMessageRequest, senderID string) (*model.Message, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	if senderID == req.RecipientID {
+		return nil, apperror.ErrCannotMessageSelf
+	}
+
+	senderObjectID, err := primitive.ObjectIDFromHex(senderID)
+	if err != nil {
+		return nil, apperror.ErrInvalidID
+	}
+
+	recipientObjectID, err := primitive.ObjectIDFromHex(req.RecipientID)
+	if err != nil {
+		return nil, apperror.ErrInvalidID
+	}
+
+	existed, err := s.conversationRepo.IsUserExist(ctx, req.RecipientID)
+	if err != nil {
+		return nil, err
+	}
+	if !existed {
+		return nil, apperror.ErrUserNotFound
+	}
+
+	// Blocks work both ways: neither side can message the other until the block is lifted
+	blocked, err := s.conversationRepo.IsBlocked(ctx, req.RecipientID, senderID)
+	if err != nil {
+		return nil, err
+	}
+	if !blocked {
+		blocked, err = s.conversationRepo.IsBlocked(ctx, senderID, req.RecipientID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if blocked {
+		return nil, apperror.ErrUserBlocked
+	}
+
+	conversation, err := s.conversationRepo.GetDirectBetween(ctx, senderID, req.RecipientID)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	now := time.Now()
+	if conversation == nil {
+		conversation, err = s.createDirectConversation(senderObjectID, recipientObjectID, now)
+		if err != nil {
+			return nil, err
+		}
+	} else if conversation.RequestedTo != nil && *conversation.RequestedTo == senderObjectID && conversation.Status != model.ConversationStatusActive {
+		// Replying to a request is an implicit accept
+		if err := s.conversationRepo.UpdateStatus(ctx, conversation.ID.Hex(), model.ConversationStatusActive); err != nil {
+			return nil, err
+		}
+	}
+
+	message := &model.Message{
+		ConversationID: conversation.ID,
+		SenderID:       &senderObjectID,
+		Type:           model.MessageTypeUser,
+		Content:        req.Content,
+		CreatedAt:      now,
+		ReadBy:         []primitive.ObjectID{senderObjectID},
+	}
+	message, err = s.conversationRepo.CreateMessage(ctx, message)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.conversationRepo.UpdateLastMessageAt(ctx, conversation.ID.Hex(), now); err != nil {
+		return nil, err
+	}
+
+	return message, nil
+}
+
+// createDirectConversation opens a new direct conversation. First contact between users
+// who share no community lands in the recipient's requests inbox instead of the main inbox.
+func (s *conversationService) createDirectConversation(senderID, recipientID primitive.ObjectID, now time.Time) (*model.Conversation, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	// There is no follow relation yet, so shared community membership is the only trust signal
+	shared, err := s.membershipRepo.ShareCommunity(ctx, senderID.Hex(), recipientID.Hex())
+	if err != nil {
+		return nil, err
+	}
+
+	conversation := &model.Conversation{
+		Type:          model.ConversationTypeDirect,
+		Members:       []primitive.ObjectID{senderID, recipientID},
+		Status:        model.ConversationStatusActive,
+		CreatedBy:     senderID,
+		CreatedAt:     now,
+		LastMessageAt: &now,
+	}
+	if !shared {
+		conversation.Status = model.ConversationStatusRequest
+		conversation.RequestedBy = &senderID
+		conversation.RequestedTo = &recipientID
+	}
+
+	return s.conversationRepo.Create(ctx, conversation)
+}
+
+func (s *conversationService) GetInbox(userID string, page int, pageSize int) (*dto.PaginatedConversationsResponse, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	conversations, total, err := s.conversationRepo.GetInboxPaginated(ctx, userID, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range conversations {
+		hideRequestOutcome(&conversations[i], userID)
+	}
+
+	return &dto.PaginatedConversationsResponse{
+		Conversations: conversations,
+		Pagination: dto.Pagination{
+			Page:     page,
+			PageSize: pageSize,
+			Total:    total,
+		},
+	}, nil
+}
+
+func (s *conversationService) GetMessageRequests(userID string, page int, pageSize int) (*dto.PaginatedConversationsResponse, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	conversations, total, err := s.conversationRepo.GetRequestsPaginated(ctx, userID, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.PaginatedConversationsResponse{
+		Conversations: conversations,
+		Pagination: dto.Pagination{
+			Page:     page,
+			PageSize: pageSize,
+			Total:    total,
+		},
+	}, nil
+}
+
+func (s *conversationService) GetMessages(conversationID string, userID string, page int, pageSize int) (*dto.PaginatedMessagesResponse, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	conversation, err := s.getMemberConversation(conversationID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	messages, total, err := s.conversationRepo.GetMessagesPaginated(ctx, conversationID, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	// The sender of a pending request must not learn whether the recipient has seen it
+	if isPendingRequester(conversation, userID) {
+		for i := range messages {
+			messages[i].ReadBy = nil
+		}
+	}
+
+	return &dto.PaginatedMessagesResponse{
+		Messages: messages,
+		Pagination: dto.Pagination{
+			Page:     page,
+			PageSize: pageSize,
+			Total:    total,
+		},
+	}, nil
+}
+
+func (s *conversationService) MarkAsRead(conversationID string, userID string) error {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	if _, err := s.getMemberConversation(conversationID, userID); err != nil {
+		return err
+	}
+
+	return s.conversationRepo.MarkMessagesRead(ctx, conversationID, userID)
+}
+
+func (s *conversationService) AcceptRequest(conversationID string, userID string) error {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	if _, err := s.getPendingRequest(conversationID, userID); err != nil {
+		return err
+	}
+
+	return s.conversationRepo.UpdateStatus(ctx, conversationID, model.ConversationStatusActive)
+}
+
+func (s *conversationService) DeclineRequest(conversationID string, userID string) error {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	if _, err := s.getPendingRequest(conversationID, userID); err != nil {
+		return err
+	}
+
+	return s.conversationRepo.UpdateStatus(ctx, conversationID, model.ConversationStatusDeclined)
+}
+
+func (s *conversationService) BlockRequest(conversationID string, userID string) error {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	conversation, err := s.getPendingRequest(conversationID, userID)
+	if err != nil {
+		return err
+	}
+
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return apperror.ErrInvalidID
+	}
+
+	block := &model.UserBlock{
+		UserID:        userObjectID,
+		BlockedUserID: *conversation.RequestedBy,
+		CreatedAt:     time.Now(),
+	}
+	if _, err := s.conversationRepo.CreateBlock(ctx, block); err != nil {
+		return err
+	}
+
+	return s.conversationRepo.UpdateStatus(ctx, conversationID, model.ConversationStatusDeclined)
+}
+
+func (s *conversationService) UnblockUser(blockedUserID string, userID string) error {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	return s.conversationRepo.DeleteBlock(ctx, userID, blockedUserID)
+}
+
+// getMemberConversation loads a conversation and makes sure the user takes part in it
+func (s *conversationService) getMemberConversation(conversationID string, userID string) (*model.Conversation, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	conversation, err := s.conversationRepo.GetByID(ctx, conversationID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, apperror.ErrConversationNotFound
+		}
+		return nil, err
+	}
+
+	for _, member := range conversation.Members {
+		if member.Hex() == userID {
+			return conversation, nil
+		}
+	}
+
+	return nil, apperror.ErrNotConversationMember
+}
+
+// getPendingRequest loads a conversation that is waiting in the user's requests inbox
+func (s *conversationService) getPendingRequest(conversationID string, userID string) (*model.Conversation, error) {
+	conversation, err := s.getMemberConversation(conversationID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if conversation.Status != model.ConversationStatusRequest || conversation.RequestedTo == nil || conversation.RequestedTo.Hex() != userID {
+		return nil, apperror.ErrNotMessageRequest
+	}
+
+	return conversation, nil
+}
+
+// isPendingRequester reports whether the user sent a request that has not been accepted yet
+func isPendingRequester(conversation *model.Conversation, userID string) bool {
+	return conversation.Status != model.ConversationStatusActive &&
+		conversation.RequestedBy != nil &&
+		conversation.RequestedBy.Hex() == userID
+}
+
+// hideRequestOutcome keeps a declined request looking pending to its sender
+func hideRequestOutcome(conversation *model.Conversation, userID string) {
+	if isPendingRequester(conversation, userID) {
+		conversation.Status = model.ConversationStatusRequest
+	}
+}
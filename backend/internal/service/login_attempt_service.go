@@ -0,0 +1,269 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/giakiet05/lkforum/internal/apperror"
+	"github.com/giakiet05/lkforum/internal/config"
+	"github.com/giakiet05/lkforum/internal/dto"
+	"github.com/giakiet05/lkforum/internal/mail"
+	"github.com/giakiet05/lkforum/internal/model"
+	"github.com/giakiet05/lkforum/internal/util"
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// lockRecordTTL keeps the lock key around after the lock ends, so the unlock can still be recorded
+// on the next successful login
+const lockRecordTTL = 24 * time.Hour
+
+// LoginAttemptService slows down password guessing. Failed logins are counted per account and per
+// IP; past a few free attempts every further one has to wait twice as long as the previous, and an
+// account that keeps failing is locked for a while and its owner is emailed.
+//
+// Identifiers without an account are counted and locked the same way, so the responses do not
+// reveal which accounts exist. Redis failures let logins through.
+type LoginAttemptService interface {
+	Check(accountKey string, ip string) error
+	RecordFailure(accountKey string, user *model.User, client dto.ClientInfo)
+	RecordSuccess(accountKey string, user *model.User, client dto.ClientInfo)
+	Unlock(userID string, reason string, client dto.ClientInfo) (bool, error)
+}
+
+type loginAttemptService struct {
+	redisClient          *redis.Client
+	mailer               mail.Mailer
+	securityEventService SecurityEventService
+
+	freeAttempts   int64
+	ipFreeAttempts int64
+	lockThreshold  int64
+	lockDuration   time.Duration
+	failureWindow  time.Duration
+	maxBackoff     time.Duration
+}
+
+func NewLoginAttemptService(redisClient *redis.Client, mailer mail.Mailer, securityEventService SecurityEventService) LoginAttemptService {
+	return &loginAttemptService{
+		redisClient:          redisClient,
+		mailer:               mailer,
+		securityEventService: securityEventService,
+
+		freeAttempts:   int64(config.GetEnvIntWithDefault("LOGIN_FREE_ATTEMPTS", 3)),
+		ipFreeAttempts: int64(config.GetEnvIntWithDefault("LOGIN_IP_FREE_ATTEMPTS", 20)),
+		lockThreshold:  int64(config.GetEnvIntWithDefault("LOGIN_LOCKOUT_THRESHOLD", 10)),
+		lockDuration:   time.Minute * time.Duration(config.GetEnvIntWithDefault("LOGIN_LOCKOUT_MIN", 15)),
+		failureWindow:  time.Minute * time.Duration(config.GetEnvIntWithDefault("LOGIN_FAILURE_WINDOW_MIN", 15)),
+		maxBackoff:     time.Second * time.Duration(config.GetEnvIntWithDefault("LOGIN_BACKOFF_MAX_SEC", 300)),
+	}
+}
+
+// loginAccountKey names the counters of a login identifier: the account when it exists, otherwise
+// the identifier itself
+func loginAccountKey(user *model.User, identifier string) string {
+	if user != nil {
+		return user.ID.Hex()
+	}
+	return "unknown:" + strings.ToLower(strings.TrimSpace(identifier))
+}
+
+// Check refuses the attempt while the account is locked or the account or IP is backing off
+func (s *loginAttemptService) Check(accountKey string, ip string) error {
+	if s.redisClient == nil {
+		return nil
+	}
+
+	ctx, cancel := util.NewDefaultRedisContext()
+	defer cancel()
+
+	pipe := s.redisClient.Pipeline()
+	lock := pipe.Get(ctx, loginLockKey(accountKey))
+	account := pipe.HMGet(ctx, loginAccountFailuresKey(accountKey), "count", "last")
+	address := pipe.HMGet(ctx, loginIPFailuresKey(ip), "count", "last")
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		log.Printf("Failed to check login attempts: %v\n", err)
+		return nil
+	}
+
+	now := time.Now()
+	if until, err := strconv.ParseInt(lock.Val(), 10, 64); err == nil && now.Unix() < until {
+		wait := time.Unix(until, 0).Sub(now)
+		return apperror.ErrAccountLocked.WithMessage("This account is temporarily locked after too many failed login attempts. Try again in %s", util.FormatWait(wait))
+	}
+
+	wait := max(s.backoff(account.Val(), s.freeAttempts, now), s.backoff(address.Val(), s.ipFreeAttempts, now))
+	if wait > 0 {
+		return apperror.ErrTooManyLoginAttempts.WithMessage("Too many failed login attempts. Try again in %s", util.FormatWait(wait))
+	}
+	return nil
+}
+
+// RecordFailure counts a wrong password and locks the account once it reaches the threshold.
+// user is nil when the identifier has no account.
+func (s *loginAttemptService) RecordFailure(accountKey string, user *model.User, client dto.ClientInfo) {
+	if s.redisClient == nil {
+		return
+	}
+
+	ctx, cancel := util.NewDefaultRedisContext()
+	defer cancel()
+
+	now := time.Now()
+	pipe := s.redisClient.TxPipeline()
+	count := pipe.HIncrBy(ctx, loginAccountFailuresKey(accountKey), "count", 1)
+	pipe.HSet(ctx, loginAccountFailuresKey(accountKey), "last", now.UnixMilli())
+	pipe.Expire(ctx, loginAccountFailuresKey(accountKey), s.failureWindow)
+	pipe.HIncrBy(ctx, loginIPFailuresKey(client.IP), "count", 1)
+	pipe.HSet(ctx, loginIPFailuresKey(client.IP), "last", now.UnixMilli())
+	pipe.Expire(ctx, loginIPFailuresKey(client.IP), s.failureWindow)
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("Failed to record failed login: %v\n", err)
+		return
+	}
+
+	if count.Val() < s.lockThreshold {
+		return
+	}
+
+	until := now.Add(s.lockDuration)
+	lockPipe := s.redisClient.TxPipeline()
+	lockPipe.Set(ctx, loginLockKey(accountKey), until.Unix(), s.lockDuration+lockRecordTTL)
+	lockPipe.Del(ctx, loginAccountFailuresKey(accountKey))
+	if _, err := lockPipe.Exec(ctx); err != nil {
+		log.Printf("Failed to lock account %s: %v\n", accountKey, err)
+		return
+	}
+
+	if user == nil {
+		return
+	}
+
+	s.securityEventService.Record(&model.SecurityEvent{
+		UserID:    user.ID,
+		Type:      model.SecurityEventAccountLocked,
+		IP:        client.IP,
+		UserAgent: client.UserAgent,
+		Details:   fmt.Sprintf("locked until %s after %d failed login attempts", until.Format(time.RFC3339), count.Val()),
+	})
+	if user.Email != "" {
+		go s.sendLockNotice(user, client, until)
+	}
+}
+
+// RecordSuccess clears the account's failures. The IP keeps its count, or an attacker could wipe it
+// by logging into an account of their own between guesses.
+func (s *loginAttemptService) RecordSuccess(accountKey string, user *model.User, client dto.ClientInfo) {
+	if s.redisClient == nil {
+		return
+	}
+
+	ctx, cancel := util.NewDefaultRedisContext()
+	defer cancel()
+
+	pipe := s.redisClient.TxPipeline()
+	pipe.Del(ctx, loginAccountFailuresKey(accountKey))
+	lock := pipe.GetDel(ctx, loginLockKey(accountKey))
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		log.Printf("Failed to clear failed logins: %v\n", err)
+		return
+	}
+
+	// Check refused the login while the lock lasted, so a lock found here has run out
+	if lock.Val() != "" && user != nil {
+		s.recordUnlock(user.ID, "lock expired", client)
+	}
+}
+
+// Unlock lifts a lock early, after a password reset or by an admin. It reports whether the account was locked.
+func (s *loginAttemptService) Unlock(userID string, reason string, client dto.ClientInfo) (bool, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return false, apperror.ErrInvalidID
+	}
+	if s.redisClient == nil {
+		return false, nil
+	}
+
+	ctx, cancel := util.NewDefaultRedisContext()
+	defer cancel()
+
+	pipe := s.redisClient.TxPipeline()
+	pipe.Del(ctx, loginAccountFailuresKey(userID))
+	lock := pipe.GetDel(ctx, loginLockKey(userID))
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return false, err
+	}
+
+	until, err := strconv.ParseInt(lock.Val(), 10, 64)
+	if err != nil || time.Now().Unix() >= until {
+		return false, nil
+	}
+
+	s.recordUnlock(userObjectID, reason, client)
+	return true, nil
+}
+
+// backoff is how long the next attempt has to wait: nothing for the free attempts, then one second
+// doubling with every further failure, up to maxBackoff
+func (s *loginAttemptService) backoff(failures []interface{}, free int64, now time.Time) time.Duration {
+	if len(failures) != 2 {
+		return 0
+	}
+	countStr, _ := failures[0].(string)
+	lastStr, _ := failures[1].(string)
+	count, err := strconv.ParseInt(countStr, 10, 64)
+	if err != nil || count <= free {
+		return 0
+	}
+	last, err := strconv.ParseInt(lastStr, 10, 64)
+	if err != nil {
+		return 0
+	}
+
+	delay := s.maxBackoff
+	if exponent := count - free - 1; exponent < 32 {
+		delay = time.Duration(math.Min(float64(time.Second)*math.Pow(2, float64(exponent)), float64(s.maxBackoff)))
+	}
+	return time.UnixMilli(last).Add(delay).Sub(now)
+}
+
+func (s *loginAttemptService) recordUnlock(userID primitive.ObjectID, reason string, client dto.ClientInfo) {
+	s.securityEventService.Record(&model.SecurityEvent{
+		UserID:    userID,
+		Type:      model.SecurityEventAccountUnlocked,
+		IP:        client.IP,
+		UserAgent: client.UserAgent,
+		Details:   reason,
+	})
+}
+
+func (s *loginAttemptService) sendLockNotice(user *model.User, client dto.ClientInfo, until time.Time) {
+	link := fmt.Sprintf("%s/forgot-password", config.GetEnvWithDefault("FRONTEND_URL", "http://localhost:5173"))
+	body := fmt.Sprintf("Hi %s,\n\nThere were too many failed attempts to log into your account, the last one from %s. "+
+		"To protect it, logging in is blocked until %s.\n\n"+
+		"If this was you, just wait and try again. If it was not, someone may be guessing your password: "+
+		"you can choose a new one right away, which also lifts the lock:\n\n%s\n",
+		user.Username, client.IP, until.UTC().Format("15:04 MST, Jan 2"), link)
+
+	if err := s.mailer.Send(user.Email, "Your account was temporarily locked", body); err != nil {
+		log.Printf("Failed to send lockout notice to user %s: %v\n", user.ID.Hex(), err)
+	}
+}
+
+func loginAccountFailuresKey(accountKey string) string {
+	return "login_failures:account:" + accountKey
+}
+
+func loginIPFailuresKey(ip string) string {
+	return "login_failures:ip:" + ip
+}
+
+func loginLockKey(accountKey string) string {
+	return "login_lock:account:" + accountKey
+}
@@ -35,12 +35,13 @@ type MembershipService interface {
 }
 
 type membershipService struct {
-	membershipRepo repo.MembershipRepo
-	redisClient    *redis.Client
+	membershipRepo      repo.MembershipRepo
+	redisClient         *redis.Client
+	communityBanService CommunityBanService
 }
 
-func NewMembershipService(membershipRepo repo.MembershipRepo, redisClient *redis.Client) MembershipService {
-	svc := &membershipService{membershipRepo: membershipRepo, redisClient: redisClient}
+func NewMembershipService(membershipRepo repo.MembershipRepo, redisClient *redis.Client, communityBanService CommunityBanService) MembershipService {
+	svc := &membershipService{membershipRepo: membershipRepo, redisClient: redisClient, communityBanService: communityBanService}
 	svc.StartRedisToMongoMembershipSync()
 	return svc
 }
@@ -71,6 +72,10 @@ func (m *membershipService) CreateMembership(req *dto.CreateMembershipRequest, u
 		return nil, apperror.ErrCommunityNotFound
 	}
 
+	if err := m.communityBanService.CheckCanJoin(req.CommunityID, req.UserID); err != nil {
+		return nil, err
+	}
+
 	membership := &model.Membership{
 		UserID:      userObjectID,
 		CommunityID: communityObjectID,
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/giakiet05/lkforum/internal/apperror"
+	"github.com/giakiet05/lkforum/internal/dto"
+	"github.com/giakiet05/lkforum/internal/model"
+	"github.com/giakiet05/lkforum/internal/repo"
+	"github.com/giakiet05/lkforum/internal/util"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type ModLogService interface {
+	Record(entry *model.ModLog)
+	GetCommunityModLogs(communityID string, userID string, filter repo.ModLogFilter, page int, pageSize int) (*dto.PaginatedModLogsResponse, error)
+	GetPublicCommunityModLogs(communityID string, filter repo.ModLogFilter, page int, pageSize int) (*dto.PaginatedModLogsResponse, error)
+	GetModLogs(filter repo.ModLogFilter, page int, pageSize int) (*dto.PaginatedModLogsResponse, error)
+}
+
+type modLogService struct {
+	modLogRepo    repo.ModLogRepo
+	communityRepo repo.CommunityRepo
+}
+
+func NewModLogService(modLogRepo repo.ModLogRepo, communityRepo repo.CommunityRepo) ModLogService {
+	return &modLogService{modLogRepo: modLogRepo, communityRepo: communityRepo}
+}
+
+// Record appends an entry to the mod log. A failure is logged but never fails the action being recorded.
+func (s *modLogService) Record(entry *model.ModLog) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+
+	if _, err := s.modLogRepo.Create(ctx, entry); err != nil {
+		log.Printf("failed to record mod log entry %s on %s %s: %v", entry.Action, entry.TargetType, entry.TargetID.Hex(), err)
+	}
+}
+
+// GetCommunityModLogs returns the full mod log of a community to its moderators
+func (s *modLogService) GetCommunityModLogs(communityID string, userID string, filter repo.ModLogFilter, page int, pageSize int) (*dto.PaginatedModLogsResponse, error) {
+	community, err := s.getCommunity(communityID)
+	if err != nil {
+		return nil, err
+	}
+
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, apperror.ErrInvalidID
+	}
+	if !canModerate(community, userObjectID) {
+		return nil, apperror.ErrForbidden
+	}
+
+	filter.CommunityID = communityID
+	return s.getModLogs(filter, page, pageSize)
+}
+
+// GetPublicCommunityModLogs returns a read-only view of the mod log for communities that opted in.
+// Before/after snapshots are left out of the public view.
+func (s *modLogService) GetPublicCommunityModLogs(communityID string, filter repo.ModLogFilter, page int, pageSize int) (*dto.PaginatedModLogsResponse, error) {
+	community, err := s.getCommunity(communityID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !community.Setting.PublicModLog {
+		return nil, apperror.ErrForbidden
+	}
+
+	filter.CommunityID = communityID
+	response, err := s.getModLogs(filter, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range response.ModLogs {
+		response.ModLogs[i].Before = nil
+		response.ModLogs[i].After = nil
+	}
+
+	return response, nil
+}
+
+// GetModLogs returns mod log entries across the whole site, for admins
+func (s *modLogService) GetModLogs(filter repo.ModLogFilter, page int, pageSize int) (*dto.PaginatedModLogsResponse, error) {
+	return s.getModLogs(filter, page, pageSize)
+}
+
+func (s *modLogService) getModLogs(filter repo.ModLogFilter, page int, pageSize int) (*dto.PaginatedModLogsResponse, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	for _, id := range []string{filter.CommunityID, filter.ActorID, filter.TargetID} {
+		if id != "" && !primitive.IsValidObjectID(id) {
+			return nil, apperror.ErrInvalidID
+		}
+	}
+
+	entries, total, err := s.modLogRepo.GetPaginated(ctx, filter, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.PaginatedModLogsResponse{
+		ModLogs: entries,
+		Pagination: dto.Pagination{
+			Page:     page,
+			PageSize: pageSize,
+			Total:    total,
+		},
+	}, nil
+}
+
+func (s *modLogService) getCommunity(communityID string) (*model.Community, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	community, err := s.communityRepo.GetByID(ctx, communityID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, apperror.ErrCommunityNotFound
+		}
+		return nil, err
+	}
+	if community.IsBanned {
+		return nil, apperror.ErrCommunityNotFound
+	}
+
+	return community, nil
+}
+
+// modLogActorRole picks the role an action is recorded under
+func modLogActorRole(isAdmin bool) model.ModLogActorRole {
+	if isAdmin {
+		return model.ModLogActorAdmin
+	}
+	return model.ModLogActorModerator
+}
@@ -0,0 +1,357 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/giakiet05/lkforum/internal/apperror"
+	"github.com/giakiet05/lkforum/internal/config"
+	"github.com/giakiet05/lkforum/internal/dto"
+	"github.com/giakiet05/lkforum/internal/model"
+	"github.com/giakiet05/lkforum/internal/repo"
+	"github.com/giakiet05/lkforum/internal/util"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ModeratorInviteService handles moderator invitations. Nobody becomes a moderator without accepting one,
+// except the owner when the community is created.
+type ModeratorInviteService interface {
+	InviteModerators(community *model.Community, invitees []dto.ModeratorDTO, inviterID string) ([]model.ModeratorInvite, error)
+	GetMyInvites(userID string, page int, pageSize int) (*dto.PaginatedModeratorInvitesResponse, error)
+	GetCommunityInvites(communityID string, userID string, page int, pageSize int) (*dto.PaginatedModeratorInvitesResponse, error)
+	AcceptInvite(inviteID string, userID string) error
+	DeclineInvite(inviteID string, userID string) error
+	CancelInvite(inviteID string, userID string) error
+}
+
+type moderatorInviteService struct {
+	moderatorInviteRepo repo.ModeratorInviteRepo
+	communityRepo       repo.CommunityRepo
+	notificationService NotificationService
+	modLogService       ModLogService
+}
+
+func NewModeratorInviteService(
+	moderatorInviteRepo repo.ModeratorInviteRepo,
+	communityRepo repo.CommunityRepo,
+	notificationService NotificationService,
+	modLogService ModLogService,
+) ModeratorInviteService {
+	return &moderatorInviteService{
+		moderatorInviteRepo: moderatorInviteRepo,
+		communityRepo:       communityRepo,
+		notificationService: notificationService,
+		modLogService:       modLogService,
+	}
+}
+
+// InviteModerators sends an invitation to each user. The caller checks that the inviter may manage
+// moderators; users who already have a pending invitation are skipped.
+func (s *moderatorInviteService) InviteModerators(community *model.Community, invitees []dto.ModeratorDTO, inviterID string) ([]model.ModeratorInvite, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	inviterObjectID, err := primitive.ObjectIDFromHex(inviterID)
+	if err != nil {
+		return nil, apperror.ErrInvalidID
+	}
+
+	expDays := config.GetEnvIntWithDefault("MOD_INVITE_EXP_DAYS", 7)
+
+	var invites []model.ModeratorInvite
+	for _, invitee := range invitees {
+		inviteeObjectID, err := primitive.ObjectIDFromHex(invitee.ModeratorID)
+		if err != nil {
+			return invites, apperror.ErrInvalidID
+		}
+
+		if isCommunityModerator(community, inviteeObjectID) {
+			return invites, apperror.ErrAlreadyModerator
+		}
+		if err := validateModPermissions(invitee.Permissions); err != nil {
+			return invites, err
+		}
+		if !canGrantModPermissions(community, inviterID, invitee.Permissions) {
+			return invites, apperror.ErrForbidden
+		}
+
+		pending, err := s.moderatorInviteRepo.HasPending(ctx, community.ID, inviteeObjectID)
+		if err != nil {
+			return invites, err
+		}
+		if pending {
+			continue
+		}
+
+		username, err := s.communityRepo.GetUsername(ctx, invitee.ModeratorID)
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				return invites, apperror.ErrUserNotFound
+			}
+			return invites, err
+		}
+
+		now := time.Now()
+		invite, err := s.moderatorInviteRepo.Create(ctx, &model.ModeratorInvite{
+			CommunityID:   community.ID,
+			CommunityName: community.Name,
+			InviteeID:     inviteeObjectID,
+			InviteeName:   username,
+			InvitedBy:     inviterObjectID,
+			Permissions:   invitee.Permissions,
+			Status:        model.ModeratorInviteStatusPending,
+			CreatedAt:     now,
+			ExpiresAt:     now.Add(24 * time.Hour * time.Duration(expDays)),
+		})
+		if err != nil {
+			return invites, err
+		}
+		invites = append(invites, *invite)
+
+		s.recordInviteAction(community.ID, inviterObjectID, model.ModActionInviteModerator, inviteeObjectID, invite)
+		s.notifyInvitee(invite)
+	}
+
+	return invites, nil
+}
+
+func (s *moderatorInviteService) GetMyInvites(userID string, page int, pageSize int) (*dto.PaginatedModeratorInvitesResponse, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	if !primitive.IsValidObjectID(userID) {
+		return nil, apperror.ErrInvalidID
+	}
+
+	invites, total, err := s.moderatorInviteRepo.GetPendingByInviteePaginated(ctx, userID, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return newPaginatedModeratorInvites(invites, total, page, pageSize), nil
+}
+
+func (s *moderatorInviteService) GetCommunityInvites(communityID string, userID string, page int, pageSize int) (*dto.PaginatedModeratorInvitesResponse, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	community, err := s.getCommunity(communityID)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireModPermission(community, userID, model.ModPermissionMods); err != nil {
+		return nil, err
+	}
+
+	invites, total, err := s.moderatorInviteRepo.GetPendingByCommunityPaginated(ctx, communityID, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return newPaginatedModeratorInvites(invites, total, page, pageSize), nil
+}
+
+// AcceptInvite adds the invitee to the bottom of the moderator list with the permissions they were offered
+func (s *moderatorInviteService) AcceptInvite(inviteID string, userID string) error {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	invite, err := s.getInviteForInvitee(inviteID, userID)
+	if err != nil {
+		return err
+	}
+
+	community, err := s.getCommunity(invite.CommunityID.Hex())
+	if err != nil {
+		return err
+	}
+
+	if isCommunityModerator(community, invite.InviteeID) {
+		return apperror.ErrAlreadyModerator
+	}
+
+	// Resolve the name again, the user may have renamed since the invitation was sent
+	username, err := s.communityRepo.GetUsername(ctx, userID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return apperror.ErrUserNotFound
+		}
+		return err
+	}
+
+	// Resolving only succeeds while the invite is pending, so it is accepted at most once
+	if _, err := s.moderatorInviteRepo.Resolve(ctx, invite.ID, model.ModeratorInviteStatusAccepted); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return apperror.ErrInviteNotFound
+		}
+		return err
+	}
+
+	moderator := model.Moderator{
+		UserID:      invite.InviteeID,
+		Username:    username,
+		AssignedAt:  time.Now(),
+		Permissions: invite.Permissions,
+	}
+	// Pushed rather than replaced so moderator changes made meanwhile are kept; the filter turns
+	// away a user who became a moderator since the check above
+	if err := s.communityRepo.AddModerator(ctx, community.ID, moderator); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return apperror.ErrAlreadyModerator
+		}
+		return err
+	}
+
+	s.recordInviteAction(community.ID, invite.InvitedBy, model.ModActionAddModerator, invite.InviteeID, moderator)
+	return nil
+}
+
+func (s *moderatorInviteService) DeclineInvite(inviteID string, userID string) error {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	invite, err := s.getInviteForInvitee(inviteID, userID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.moderatorInviteRepo.Resolve(ctx, invite.ID, model.ModeratorInviteStatusDeclined); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return apperror.ErrInviteNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+// CancelInvite withdraws a pending invitation; any moderator who can manage moderators may do it
+func (s *moderatorInviteService) CancelInvite(inviteID string, userID string) error {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	invite, err := s.getPendingInvite(inviteID)
+	if err != nil {
+		return err
+	}
+
+	community, err := s.getCommunity(invite.CommunityID.Hex())
+	if err != nil {
+		return err
+	}
+	if err := requireModPermission(community, userID, model.ModPermissionMods); err != nil {
+		return err
+	}
+
+	if _, err := s.moderatorInviteRepo.Resolve(ctx, invite.ID, model.ModeratorInviteStatusCancelled); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return apperror.ErrInviteNotFound
+		}
+		return err
+	}
+
+	actorObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return apperror.ErrInvalidID
+	}
+	s.recordInviteAction(community.ID, actorObjectID, model.ModActionCancelInvite, invite.InviteeID, invite)
+	return nil
+}
+
+func (s *moderatorInviteService) getPendingInvite(inviteID string) (*model.ModeratorInvite, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	if !primitive.IsValidObjectID(inviteID) {
+		return nil, apperror.ErrInvalidID
+	}
+
+	invite, err := s.moderatorInviteRepo.GetPendingByID(ctx, inviteID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, apperror.ErrInviteNotFound
+		}
+		return nil, err
+	}
+
+	return invite, nil
+}
+
+// getInviteForInvitee loads a pending, unexpired invitation addressed to the user
+func (s *moderatorInviteService) getInviteForInvitee(inviteID string, userID string) (*model.ModeratorInvite, error) {
+	invite, err := s.getPendingInvite(inviteID)
+	if err != nil {
+		return nil, err
+	}
+
+	if invite.InviteeID.Hex() != userID {
+		return nil, apperror.ErrInviteNotFound
+	}
+	if time.Now().After(invite.ExpiresAt) {
+		return nil, apperror.ErrInviteExpired
+	}
+
+	return invite, nil
+}
+
+func (s *moderatorInviteService) getCommunity(communityID string) (*model.Community, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	if !primitive.IsValidObjectID(communityID) {
+		return nil, apperror.ErrInvalidID
+	}
+
+	community, err := s.communityRepo.GetByID(ctx, communityID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, apperror.ErrCommunityNotFound
+		}
+		return nil, err
+	}
+	if community.IsBanned {
+		return nil, apperror.ErrCommunityNotFound
+	}
+
+	return community, nil
+}
+
+func (s *moderatorInviteService) recordInviteAction(communityID primitive.ObjectID, actorID primitive.ObjectID, action model.ModAction, inviteeID primitive.ObjectID, after interface{}) {
+	s.modLogService.Record(&model.ModLog{
+		CommunityID: &communityID,
+		ActorID:     actorID,
+		ActorRole:   model.ModLogActorModerator,
+		Action:      action,
+		TargetType:  model.ModTargetUser,
+		TargetID:    inviteeID,
+		After:       after,
+	})
+}
+
+// notifyInvitee puts the invitation in the invitee's notifications
+func (s *moderatorInviteService) notifyInvitee(invite *model.ModeratorInvite) {
+	message := fmt.Sprintf("You have been invited to moderate %s", invite.CommunityName)
+	metadata := map[string]interface{}{
+		"invite_id":    invite.ID.Hex(),
+		"community_id": invite.CommunityID.Hex(),
+		"expires_at":   invite.ExpiresAt,
+	}
+
+	if err := s.notificationService.Notify(invite.InviteeID, model.NotificationTypeModerator, message, metadata); err != nil {
+		log.Printf("failed to notify user %s of moderator invite: %v", invite.InviteeID.Hex(), err)
+	}
+}
+
+func newPaginatedModeratorInvites(invites []model.ModeratorInvite, total int64, page int, pageSize int) *dto.PaginatedModeratorInvitesResponse {
+	return &dto.PaginatedModeratorInvitesResponse{
+		Invites: invites,
+		Pagination: dto.Pagination{
+			Page:     page,
+			PageSize: pageSize,
+			Total:    total,
+		},
+	}
+}
@@ -0,0 +1,435 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/giakiet05/lkforum/internal/apperror"
+	"github.com/giakiet05/lkforum/internal/dto"
+	"github.com/giakiet05/lkforum/internal/model"
+	"github.com/giakiet05/lkforum/internal/repo"
+	"github.com/giakiet05/lkforum/internal/util"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ModmailService handles threads between a user and a community's mod team. Moderators with the mail
+// permission share one inbox per community; the user only sees replies, never internal notes, and
+// only sees who answered when the moderator did not reply as the team.
+type ModmailService interface {
+	CreateThread(req *dto.CreateModmailThreadRequest, userID string) (*model.ModmailThread, error)
+	GetMyThreads(userID string, page int, pageSize int) (*dto.PaginatedModmailThreadsResponse, error)
+	GetCommunityThreads(communityID string, userID string, status model.ModmailThreadStatus, highlightedOnly bool, page int, pageSize int) (*dto.PaginatedModmailThreadsResponse, error)
+	GetThread(threadID string, userID string) (*model.ModmailThread, error)
+	GetMessages(threadID string, userID string, page int, pageSize int) (*dto.PaginatedModmailMessagesResponse, error)
+	Reply(threadID string, req *dto.ModmailReplyRequest, userID string) (*model.ModmailMessage, error)
+
+	ArchiveThread(threadID string, userID string) error
+	UnarchiveThread(threadID string, userID string) error
+	HighlightThread(threadID string, userID string) error
+	UnhighlightThread(threadID string, userID string) error
+}
+
+type modmailService struct {
+	modmailRepo          repo.ModmailRepo
+	communityRepo        repo.CommunityRepo
+	communityBanService  CommunityBanService
+	contentFilterService ContentFilterService
+	notificationService  NotificationService
+}
+
+func NewModmailService(
+	modmailRepo repo.ModmailRepo,
+	communityRepo repo.CommunityRepo,
+	communityBanService CommunityBanService,
+	contentFilterService ContentFilterService,
+	notificationService NotificationService,
+) ModmailService {
+	return &modmailService{
+		modmailRepo:          modmailRepo,
+		communityRepo:        communityRepo,
+		communityBanService:  communityBanService,
+		contentFilterService: contentFilterService,
+		notificationService:  notificationService,
+	}
+}
+
+func (s *modmailService) CreateThread(req *dto.CreateModmailThreadRequest, userID string) (*model.ModmailThread, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, apperror.ErrInvalidID
+	}
+
+	community, err := s.getCommunity(req.CommunityID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.communityBanService.CheckCanMessageModerators(community.ID.Hex(), userID); err != nil {
+		return nil, err
+	}
+
+	subject, err := s.filterText(community.ID.Hex(), req.Subject)
+	if err != nil {
+		return nil, err
+	}
+	content, err := s.filterText(community.ID.Hex(), req.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	username, err := s.communityRepo.GetUsername(ctx, userID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, apperror.ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	thread, err := s.modmailRepo.CreateThread(ctx, &model.ModmailThread{
+		CommunityID:   community.ID,
+		CommunityName: community.Name,
+		UserID:        userObjectID,
+		Username:      username,
+		Subject:       subject,
+		Status:        model.ModmailThreadStatusOpen,
+		CreatedAt:     now,
+		LastMessageAt: now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.modmailRepo.CreateMessage(ctx, &model.ModmailMessage{
+		ThreadID:   thread.ID,
+		AuthorID:   &userObjectID,
+		AuthorName: username,
+		Content:    content,
+		CreatedAt:  now,
+	}); err != nil {
+		return nil, err
+	}
+
+	s.notifyTeam(community, thread, fmt.Sprintf("New modmail from u/%s: %s", username, thread.Subject))
+	return thread, nil
+}
+
+func (s *modmailService) GetMyThreads(userID string, page int, pageSize int) (*dto.PaginatedModmailThreadsResponse, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	if !primitive.IsValidObjectID(userID) {
+		return nil, apperror.ErrInvalidID
+	}
+
+	threads, total, err := s.modmailRepo.GetThreadsByUserPaginated(ctx, userID, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return newPaginatedModmailThreads(threads, total, page, pageSize), nil
+}
+
+// GetCommunityThreads lists the team inbox, optionally narrowed to one status or to highlighted threads
+func (s *modmailService) GetCommunityThreads(communityID string, userID string, status model.ModmailThreadStatus, highlightedOnly bool, page int, pageSize int) (*dto.PaginatedModmailThreadsResponse, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	if status != "" && !model.IsValidModmailThreadStatus(status) {
+		return nil, apperror.ErrBadRequest
+	}
+
+	community, err := s.getCommunity(communityID)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireModPermission(community, userID, model.ModPermissionMail); err != nil {
+		return nil, err
+	}
+
+	threads, total, err := s.modmailRepo.GetThreadsByCommunityPaginated(ctx, communityID, status, highlightedOnly, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return newPaginatedModmailThreads(threads, total, page, pageSize), nil
+}
+
+func (s *modmailService) GetThread(threadID string, userID string) (*model.ModmailThread, error) {
+	thread, _, _, err := s.getThreadForViewer(threadID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return thread, nil
+}
+
+// GetMessages returns the newest messages first. The mod team sees the whole thread; the user sees it
+// without internal notes and with team replies unsigned.
+func (s *modmailService) GetMessages(threadID string, userID string, page int, pageSize int) (*dto.PaginatedModmailMessagesResponse, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	thread, _, isTeam, err := s.getThreadForViewer(threadID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	messages, total, err := s.modmailRepo.GetMessagesPaginated(ctx, threadID, isTeam, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isTeam {
+		for i := range messages {
+			if messages[i].AsTeam {
+				messages[i].AuthorID = nil
+				messages[i].AuthorName = teamDisplayName(thread)
+			}
+		}
+	}
+
+	return &dto.PaginatedModmailMessagesResponse{
+		Messages: messages,
+		Pagination: dto.Pagination{
+			Page:     page,
+			PageSize: pageSize,
+			Total:    total,
+		},
+	}, nil
+}
+
+// Reply adds a message to the thread. The user who opened the thread writes as themselves and
+// reopens it if it was archived; moderators may reply as the team or add internal notes.
+func (s *modmailService) Reply(threadID string, req *dto.ModmailReplyRequest, userID string) (*model.ModmailMessage, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	thread, community, isTeam, err := s.getThreadForViewer(threadID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// A moderator who opened a thread with their own team still writes as the user there,
+	// unless they explicitly ask for a team reply or a note
+	fromTeam := isTeam && (thread.UserID.Hex() != userID || req.AsTeam || req.IsInternal)
+	if !fromTeam && (req.AsTeam || req.IsInternal) {
+		return nil, apperror.ErrForbidden
+	}
+	content := req.Content
+	if !fromTeam {
+		if err := s.communityBanService.CheckCanMessageModerators(thread.CommunityID.Hex(), userID); err != nil {
+			return nil, err
+		}
+		if content, err = s.filterText(thread.CommunityID.Hex(), req.Content); err != nil {
+			return nil, err
+		}
+	}
+
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, apperror.ErrInvalidID
+	}
+
+	username, err := s.communityRepo.GetUsername(ctx, userID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, apperror.ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	message, err := s.modmailRepo.CreateMessage(ctx, &model.ModmailMessage{
+		ThreadID:   thread.ID,
+		AuthorID:   &userObjectID,
+		AuthorName: username,
+		IsFromTeam: fromTeam,
+		AsTeam:     fromTeam && req.AsTeam,
+		IsInternal: fromTeam && req.IsInternal,
+		Content:    content,
+		CreatedAt:  now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	update := bson.M{"last_message_at": now}
+	if !message.IsInternal {
+		update["last_reply_by_team"] = fromTeam
+	}
+	if !fromTeam && thread.Status == model.ModmailThreadStatusArchived {
+		update["status"] = model.ModmailThreadStatusOpen
+	}
+	if err := s.modmailRepo.UpdateThread(ctx, thread.ID, update); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case !fromTeam:
+		s.notifyTeam(community, thread, fmt.Sprintf("u/%s replied to modmail: %s", username, thread.Subject))
+	case !message.IsInternal:
+		s.notifyUser(thread, fmt.Sprintf("The moderators of %s replied to your message: %s", thread.CommunityName, thread.Subject))
+	}
+
+	return message, nil
+}
+
+func (s *modmailService) ArchiveThread(threadID string, userID string) error {
+	return s.updateThreadAsTeam(threadID, userID, bson.M{"status": model.ModmailThreadStatusArchived})
+}
+
+func (s *modmailService) UnarchiveThread(threadID string, userID string) error {
+	return s.updateThreadAsTeam(threadID, userID, bson.M{"status": model.ModmailThreadStatusOpen})
+}
+
+func (s *modmailService) HighlightThread(threadID string, userID string) error {
+	return s.updateThreadAsTeam(threadID, userID, bson.M{"is_highlighted": true})
+}
+
+func (s *modmailService) UnhighlightThread(threadID string, userID string) error {
+	return s.updateThreadAsTeam(threadID, userID, bson.M{"is_highlighted": false})
+}
+
+// updateThreadAsTeam changes a thread's inbox state; only the mod team may do it
+func (s *modmailService) updateThreadAsTeam(threadID string, userID string, update bson.M) error {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	thread, _, isTeam, err := s.getThreadForViewer(threadID, userID)
+	if err != nil {
+		return err
+	}
+	if !isTeam {
+		return apperror.ErrForbidden
+	}
+
+	if err := s.modmailRepo.UpdateThread(ctx, thread.ID, update); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return apperror.ErrModmailThreadNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// getThreadForViewer loads a thread the user may read, either as the user who opened it or as a
+// moderator with the mail permission, and reports whether they are reading it as the team.
+// Threads of other users are reported as not found.
+func (s *modmailService) getThreadForViewer(threadID string, userID string) (*model.ModmailThread, *model.Community, bool, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	if !primitive.IsValidObjectID(threadID) {
+		return nil, nil, false, apperror.ErrInvalidID
+	}
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, nil, false, apperror.ErrInvalidID
+	}
+
+	thread, err := s.modmailRepo.GetThreadByID(ctx, threadID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil, false, apperror.ErrModmailThreadNotFound
+		}
+		return nil, nil, false, err
+	}
+
+	community, err := s.getCommunity(thread.CommunityID.Hex())
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	isTeam := hasModPermission(community, userObjectID, model.ModPermissionMail)
+	if !isTeam && thread.UserID != userObjectID {
+		return nil, nil, false, apperror.ErrModmailThreadNotFound
+	}
+
+	return thread, community, isTeam, nil
+}
+
+func (s *modmailService) getCommunity(communityID string) (*model.Community, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	if !primitive.IsValidObjectID(communityID) {
+		return nil, apperror.ErrInvalidID
+	}
+
+	community, err := s.communityRepo.GetByID(ctx, communityID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, apperror.ErrCommunityNotFound
+		}
+		return nil, err
+	}
+	if community.IsBanned {
+		return nil, apperror.ErrCommunityNotFound
+	}
+
+	return community, nil
+}
+
+// notifyTeam tells the owner and every moderator with the mail permission about activity in the thread
+func (s *modmailService) notifyTeam(community *model.Community, thread *model.ModmailThread, message string) {
+	recipients := []primitive.ObjectID{community.CreateByID}
+	for _, mod := range community.Moderators {
+		if mod.UserID != community.CreateByID && mod.HasPermission(model.ModPermissionMail) {
+			recipients = append(recipients, mod.UserID)
+		}
+	}
+
+	if err := s.notificationService.NotifyMany(recipients, model.NotificationTypeModmail, message, modmailMetadata(thread)); err != nil {
+		log.Printf("failed to notify mod team of community %s about modmail: %v", community.ID.Hex(), err)
+	}
+}
+
+func (s *modmailService) notifyUser(thread *model.ModmailThread, message string) {
+	if err := s.notificationService.Notify(thread.UserID, model.NotificationTypeModmail, message, modmailMetadata(thread)); err != nil {
+		log.Printf("failed to notify user %s about modmail: %v", thread.UserID.Hex(), err)
+	}
+}
+
+func modmailMetadata(thread *model.ModmailThread) map[string]interface{} {
+	return map[string]interface{}{
+		"thread_id":    thread.ID.Hex(),
+		"community_id": thread.CommunityID.Hex(),
+	}
+}
+
+// teamDisplayName is how team replies are signed for the user
+func teamDisplayName(thread *model.ModmailThread) string {
+	return fmt.Sprintf("%s mod team", thread.CommunityName)
+}
+
+func newPaginatedModmailThreads(threads []model.ModmailThread, total int64, page int, pageSize int) *dto.PaginatedModmailThreadsResponse {
+	return &dto.PaginatedModmailThreadsResponse{
+		Threads: threads,
+		Pagination: dto.Pagination{
+			Page:     page,
+			PageSize: pageSize,
+			Total:    total,
+		},
+	}
+}
+
+// filterText runs a user's modmail text through the community's content filter and returns it with
+// matches masked. There is no review queue for modmail, so queued matches are let through; the
+// moderators read the message anyway.
+func (s *modmailService) filterText(communityID string, text string) (string, error) {
+	result, err := s.contentFilterService.Check(communityID, text, nil)
+	if err != nil {
+		return "", err
+	}
+	if result.Blocked {
+		return "", apperror.ErrContentBlocked.WithMessage("%s", result.Message)
+	}
+	return result.Text, nil
+}
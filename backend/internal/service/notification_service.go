@@ -0,0 +1,67 @@
+package service
+
+import (
+	"time"
+
+	"github.com/giakiet05/lkforum/internal/dto"
+	"github.com/giakiet05/lkforum/internal/model"
+	"github.com/giakiet05/lkforum/internal/repo"
+	"github.com/giakiet05/lkforum/internal/util"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type NotificationService interface {
+	Notify(userID primitive.ObjectID, notificationType model.NotificationType, message string, metadata map[string]interface{}) error
+	NotifyMany(userIDs []primitive.ObjectID, notificationType model.NotificationType, message string, metadata map[string]interface{}) error
+	GetNotificationsByUserID(userID string, page int, pageSize int) (*dto.PaginatedNotificationsResponse, error)
+}
+
+type notificationService struct {
+	notificationRepo repo.NotificationRepo
+}
+
+func NewNotificationService(notificationRepo repo.NotificationRepo) NotificationService {
+	return &notificationService{notificationRepo: notificationRepo}
+}
+
+func (s *notificationService) Notify(userID primitive.ObjectID, notificationType model.NotificationType, message string, metadata map[string]interface{}) error {
+	return s.NotifyMany([]primitive.ObjectID{userID}, notificationType, message, metadata)
+}
+
+func (s *notificationService) NotifyMany(userIDs []primitive.ObjectID, notificationType model.NotificationType, message string, metadata map[string]interface{}) error {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	now := time.Now()
+	notifications := make([]model.Notification, 0, len(userIDs))
+	for _, userID := range userIDs {
+		notifications = append(notifications, model.Notification{
+			UserID:    userID,
+			Type:      notificationType,
+			Message:   message,
+			Metadata:  metadata,
+			CreatedAt: now,
+		})
+	}
+
+	return s.notificationRepo.CreateMany(ctx, notifications)
+}
+
+func (s *notificationService) GetNotificationsByUserID(userID string, page int, pageSize int) (*dto.PaginatedNotificationsResponse, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	notifications, total, err := s.notificationRepo.GetByUserIDPaginated(ctx, userID, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.PaginatedNotificationsResponse{
+		Notifications: notifications,
+		Pagination: dto.Pagination{
+			Page:     page,
+			PageSize: pageSize,
+			Total:    total,
+		},
+	}, nil
+}
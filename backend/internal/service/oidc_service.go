@@ -0,0 +1,412 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/giakiet05/lkforum/internal/apperror"
+	"github.com/giakiet05/lkforum/internal/dto"
+	"github.com/giakiet05/lkforum/internal/model"
+	"github.com/giakiet05/lkforum/internal/oidc"
+	"github.com/giakiet05/lkforum/internal/repo"
+	"github.com/giakiet05/lkforum/internal/util"
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	oidcStateTTL  = 10 * time.Minute
+	oidcSignupTTL = 15 * time.Minute
+)
+
+var usernameInvalidChars = regexp.MustCompile(`[^a-zA-Z0-9_]+`)
+
+// OIDCService signs users in through OpenID Connect providers and manages the identities linked to accounts.
+// The state of an authorization request lives in Redis until the provider redirects back, so it
+// works only while Redis is available.
+type OIDCService interface {
+	GetProviders() []string
+	StartLogin(provider string) (*dto.OIDCAuthorizationResponse, error)
+	StartLink(provider string, userID string) (*dto.OIDCAuthorizationResponse, error)
+	Callback(provider string, code string, state string, client dto.ClientInfo) (*dto.OIDCCallbackResponse, error)
+	CompleteSignup(signupToken string, username string, client dto.ClientInfo) (*dto.LoginResponse, error)
+
+	GetIdentities(userID string) ([]model.ExternalIdentity, error)
+	Unlink(userID string, provider string) error
+}
+
+type oidcService struct {
+	identityRepo   repo.ExternalIdentityRepo
+	userRepo       repo.UserRepo
+	sessionService SessionService
+	redisClient    *redis.Client
+	providers      map[string]*oidc.Provider
+}
+
+func NewOIDCService(identityRepo repo.ExternalIdentityRepo, userRepo repo.UserRepo, sessionService SessionService, redisClient *redis.Client, providers map[string]*oidc.Provider) OIDCService {
+	return &oidcService{
+		identityRepo:   identityRepo,
+		userRepo:       userRepo,
+		sessionService: sessionService,
+		redisClient:    redisClient,
+		providers:      providers,
+	}
+}
+
+// oidcState is what we remember about an authorization request. UserID is set when a signed in user links a provider.
+type oidcState struct {
+	Provider     string `json:"provider"`
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"code_verifier"`
+	UserID       string `json:"user_id,omitempty"`
+}
+
+// oidcSignup is a verified external identity waiting for its new owner to pick a username
+type oidcSignup struct {
+	Provider      string `json:"provider"`
+	Subject       string `json:"subject"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+}
+
+func (s *oidcService) GetProviders() []string {
+	return oidc.ProviderNames(s.providers)
+}
+
+func (s *oidcService) StartLogin(provider string) (*dto.OIDCAuthorizationResponse, error) {
+	return s.start(provider, "")
+}
+
+func (s *oidcService) StartLink(provider string, userID string) (*dto.OIDCAuthorizationResponse, error) {
+	if _, err := primitive.ObjectIDFromHex(userID); err != nil {
+		return nil, apperror.ErrInvalidID
+	}
+	return s.start(provider, userID)
+}
+
+func (s *oidcService) start(providerName string, userID string) (*dto.OIDCAuthorizationResponse, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return nil, apperror.ErrOIDCProviderNotFound
+	}
+	if s.redisClient == nil {
+		return nil, apperror.ErrInternal
+	}
+
+	state, err := util.RandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := util.RandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	verifier, err := util.RandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := util.NewDBContextWith(15 * time.Second)
+	defer cancel()
+
+	authorizationURL, err := provider.AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		log.Printf("OIDC provider %s unavailable: %v\n", providerName, err)
+		return nil, apperror.ErrOIDCLoginFailed
+	}
+
+	data, err := json.Marshal(oidcState{Provider: providerName, Nonce: nonce, CodeVerifier: verifier, UserID: userID})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.redisClient.Set(ctx, "oidc:state:"+state, data, oidcStateTTL).Err(); err != nil {
+		return nil, err
+	}
+
+	return &dto.OIDCAuthorizationResponse{AuthorizationURL: authorizationURL}, nil
+}
+
+// Callback finishes the authorization code flow. The state is consumed whatever the outcome,
+// so every attempt starts over from StartLogin or StartLink.
+func (s *oidcService) Callback(providerName string, code string, stateToken string, client dto.ClientInfo) (*dto.OIDCCallbackResponse, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return nil, apperror.ErrOIDCProviderNotFound
+	}
+	if s.redisClient == nil {
+		return nil, apperror.ErrInternal
+	}
+
+	ctx, cancel := util.NewDBContextWith(15 * time.Second)
+	defer cancel()
+
+	var state oidcState
+	if err := s.consume(ctx, "oidc:state:"+stateToken, &state); err != nil {
+		return nil, err
+	}
+	if state.Provider != providerName {
+		return nil, apperror.ErrInvalidOIDCState
+	}
+
+	rawIDToken, err := provider.Exchange(ctx, code, state.CodeVerifier)
+	if err != nil {
+		log.Printf("OIDC code exchange with %s failed: %v\n", providerName, err)
+		return nil, apperror.ErrOIDCLoginFailed
+	}
+	claims, err := provider.VerifyIDToken(ctx, rawIDToken, state.Nonce)
+	if err != nil {
+		log.Printf("OIDC ID token from %s rejected: %v\n", providerName, err)
+		return nil, apperror.ErrOIDCLoginFailed
+	}
+
+	if state.UserID != "" {
+		if err := s.link(ctx, state.UserID, providerName, claims); err != nil {
+			return nil, err
+		}
+		return &dto.OIDCCallbackResponse{Linked: true}, nil
+	}
+
+	identity, err := s.identityRepo.GetByProviderSubject(ctx, providerName, claims.Subject)
+	if err == nil {
+		user, err := s.userRepo.GetByID(ctx, identity.UserID.Hex())
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				return nil, apperror.ErrOIDCLoginFailed
+			}
+			return nil, err
+		}
+
+		response, err := completeLogin(s.sessionService, user, client)
+		if err != nil {
+			return nil, err
+		}
+		return &dto.OIDCCallbackResponse{LoginResponse: *response}, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	return s.startSignup(ctx, providerName, claims)
+}
+
+// startSignup parks the identity of someone new until they choose a username. An email that already
+// has an account is never linked automatically: the owner has to sign in and link it themselves.
+func (s *oidcService) startSignup(ctx context.Context, providerName string, claims *oidc.Claims) (*dto.OIDCCallbackResponse, error) {
+	if claims.Email == "" {
+		return nil, apperror.ErrOIDCEmailRequired
+	}
+	if _, err := s.userRepo.GetByEmail(ctx, claims.Email); err == nil {
+		return nil, apperror.ErrOIDCEmailExists
+	} else if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	signupToken, err := util.RandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(oidcSignup{
+		Provider:      providerName,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.redisClient.Set(ctx, "oidc:signup:"+util.HashToken(signupToken), data, oidcSignupTTL).Err(); err != nil {
+		return nil, err
+	}
+
+	return &dto.OIDCCallbackResponse{
+		SignupRequired:    true,
+		SignupToken:       signupToken,
+		SuggestedUsername: suggestUsername(claims),
+	}, nil
+}
+
+// CompleteSignup creates the account for a new external identity with the username the user picked
+func (s *oidcService) CompleteSignup(signupToken string, username string, client dto.ClientInfo) (*dto.LoginResponse, error) {
+	if s.redisClient == nil {
+		return nil, apperror.ErrInternal
+	}
+
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	if _, err := s.userRepo.GetByUsername(ctx, username); err == nil {
+		return nil, apperror.ErrUsernameExists
+	} else if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	var signup oidcSignup
+	if err := s.consume(ctx, "oidc:signup:"+util.HashToken(signupToken), &signup); err != nil {
+		if errors.Is(err, apperror.ErrInvalidOIDCState) {
+			return nil, apperror.ErrInvalidSignupToken
+		}
+		return nil, err
+	}
+
+	// Someone may have registered the email or linked the identity while the user was choosing
+	if _, err := s.userRepo.GetByEmail(ctx, signup.Email); err == nil {
+		return nil, apperror.ErrOIDCEmailExists
+	} else if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+	if _, err := s.identityRepo.GetByProviderSubject(ctx, signup.Provider, signup.Subject); err == nil {
+		return nil, apperror.ErrIdentityAlreadyLinked
+	} else if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	now := time.Now()
+	user := &model.User{
+		Username: username,
+		Email:    signup.Email,
+		Role:     model.UserRole,
+		CreateAt: now,
+	}
+	// The provider vouches for the address, so there is nothing left to verify
+	if signup.EmailVerified {
+		user.VerifiedEmail = signup.Email
+		user.EmailVerifiedAt = &now
+	}
+
+	user, err := s.userRepo.Create(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.identityRepo.Create(ctx, &model.ExternalIdentity{
+		UserID:    user.ID,
+		Provider:  signup.Provider,
+		Subject:   signup.Subject,
+		Email:     signup.Email,
+		CreatedAt: now,
+	}); err != nil {
+		return nil, err
+	}
+
+	return completeLogin(s.sessionService, user, client)
+}
+
+func (s *oidcService) link(ctx context.Context, userID string, providerName string, claims *oidc.Claims) error {
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return apperror.ErrInvalidID
+	}
+
+	identity, err := s.identityRepo.GetByProviderSubject(ctx, providerName, claims.Subject)
+	if err == nil {
+		if identity.UserID == userObjectID {
+			return nil
+		}
+		return apperror.ErrIdentityAlreadyLinked.WithMessage("This %s account is linked to another user", providerName)
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return err
+	}
+
+	identities, err := s.identityRepo.GetByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, existing := range identities {
+		if existing.Provider == providerName {
+			return apperror.ErrIdentityAlreadyLinked.WithMessage("You already linked a %s account, unlink it first", providerName)
+		}
+	}
+
+	_, err = s.identityRepo.Create(ctx, &model.ExternalIdentity{
+		UserID:    userObjectID,
+		Provider:  providerName,
+		Subject:   claims.Subject,
+		Email:     claims.Email,
+		CreatedAt: time.Now(),
+	})
+	return err
+}
+
+func (s *oidcService) GetIdentities(userID string) ([]model.ExternalIdentity, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	if _, err := primitive.ObjectIDFromHex(userID); err != nil {
+		return nil, apperror.ErrInvalidID
+	}
+	return s.identityRepo.GetByUser(ctx, userID)
+}
+
+// Unlink removes a linked identity, unless it is the only way left to sign in
+func (s *oidcService) Unlink(userID string, provider string) error {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return apperror.ErrUserNotFound
+		}
+		return err
+	}
+
+	identities, err := s.identityRepo.GetByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	linked := false
+	for _, identity := range identities {
+		if identity.Provider == provider {
+			linked = true
+		}
+	}
+	if !linked {
+		return apperror.ErrIdentityNotFound
+	}
+	if user.Password == "" && len(identities) == 1 {
+		return apperror.ErrCannotUnlinkLastLogin
+	}
+
+	if err := s.identityRepo.DeleteByUserAndProvider(ctx, userID, provider); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return apperror.ErrIdentityNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// consume reads and deletes a one-time Redis entry
+func (s *oidcService) consume(ctx context.Context, key string, v interface{}) error {
+	data, err := s.redisClient.GetDel(ctx, key).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return apperror.ErrInvalidOIDCState
+		}
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// suggestUsername proposes a username from the profile, the user is free to pick another
+func suggestUsername(claims *oidc.Claims) string {
+	candidate := claims.PreferredUsername
+	if candidate == "" {
+		candidate, _, _ = strings.Cut(claims.Email, "@")
+	}
+
+	candidate = usernameInvalidChars.ReplaceAllString(candidate, "_")
+	candidate = strings.Trim(candidate, "_")
+	if len(candidate) > 32 {
+		candidate = candidate[:32]
+	}
+	return candidate
+}
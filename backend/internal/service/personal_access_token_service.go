@@ -0,0 +1,207 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/giakiet05/lkforum/internal/apperror"
+	"github.com/giakiet05/lkforum/internal/auth"
+	"github.com/giakiet05/lkforum/internal/dto"
+	"github.com/giakiet05/lkforum/internal/model"
+	"github.com/giakiet05/lkforum/internal/repo"
+	"github.com/giakiet05/lkforum/internal/util"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	maxPersonalAccessTokens = 25
+	// lastUsedResolution keeps a busy script from writing its last use on every request
+	lastUsedResolution = time.Minute
+	// displayedTokenChars is how much of the token after its prefix is kept to recognize it
+	displayedTokenChars = 4
+)
+
+// PersonalAccessTokenService manages the long-lived tokens users create for scripts and bots.
+// A token acts as its user within its scopes, but never with admin powers, and cannot manage the
+// account: sessions, two-factor, linked logins and the tokens themselves need a real sign in.
+type PersonalAccessTokenService interface {
+	CreateToken(userID string, req *dto.CreatePersonalAccessTokenRequest) (*dto.CreatedPersonalAccessTokenResponse, error)
+	GetTokens(userID string) ([]dto.PersonalAccessTokenResponse, error)
+	RevokeToken(userID string, tokenID string) error
+
+	VerifyPersonalAccessToken(token string) (auth.AuthUser, error)
+}
+
+type personalAccessTokenService struct {
+	tokenRepo repo.PersonalAccessTokenRepo
+	userRepo  repo.UserRepo
+}
+
+func NewPersonalAccessTokenService(tokenRepo repo.PersonalAccessTokenRepo, userRepo repo.UserRepo) PersonalAccessTokenService {
+	return &personalAccessTokenService{
+		tokenRepo: tokenRepo,
+		userRepo:  userRepo,
+	}
+}
+
+func (s *personalAccessTokenService) CreateToken(userID string, req *dto.CreatePersonalAccessTokenRequest) (*dto.CreatedPersonalAccessTokenResponse, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, apperror.ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, apperror.ErrBadRequest
+	}
+
+	scopes, err := normalizeTokenScopes(req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := s.tokenRepo.CountActiveByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= maxPersonalAccessTokens {
+		return nil, apperror.ErrAccessTokenLimit
+	}
+
+	secret, err := util.RandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	token := auth.PersonalAccessTokenPrefix + secret
+
+	now := time.Now()
+	created, err := s.tokenRepo.Create(ctx, &model.PersonalAccessToken{
+		UserID:    user.ID,
+		Name:      name,
+		Prefix:    token[:len(auth.PersonalAccessTokenPrefix)+displayedTokenChars],
+		TokenHash: util.HashToken(token),
+		Scopes:    scopes,
+		CreatedAt: now,
+		ExpiresAt: now.Add(24 * time.Hour * time.Duration(req.ExpiresInDays)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.CreatedPersonalAccessTokenResponse{
+		PersonalAccessTokenResponse: dto.FromPersonalAccessToken(created),
+		Token:                       token,
+	}, nil
+}
+
+func (s *personalAccessTokenService) GetTokens(userID string) ([]dto.PersonalAccessTokenResponse, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	tokens, err := s.tokenRepo.GetByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]dto.PersonalAccessTokenResponse, 0, len(tokens))
+	for i := range tokens {
+		responses = append(responses, dto.FromPersonalAccessToken(&tokens[i]))
+	}
+	return responses, nil
+}
+
+func (s *personalAccessTokenService) RevokeToken(userID string, tokenID string) error {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	if !primitive.IsValidObjectID(tokenID) {
+		return apperror.ErrInvalidID
+	}
+
+	if err := s.tokenRepo.Delete(ctx, userID, tokenID); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return apperror.ErrAccessTokenNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// VerifyPersonalAccessToken is called by AuthMiddleware for every request made with a token. The
+// token and its user are read from the database each time, so revoking the token, suspending or
+// deleting the user takes effect right away.
+func (s *personalAccessTokenService) VerifyPersonalAccessToken(token string) (auth.AuthUser, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	pat, err := s.tokenRepo.GetByHash(ctx, util.HashToken(token))
+	if err != nil {
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			log.Printf("Failed to look up personal access token: %v\n", err)
+		}
+		return auth.AuthUser{}, apperror.ErrInvalidToken
+	}
+
+	now := time.Now()
+	if pat.IsExpired(now) {
+		return auth.AuthUser{}, apperror.ErrInvalidToken
+	}
+
+	user, err := s.userRepo.GetByID(ctx, pat.UserID.Hex())
+	if err != nil {
+		return auth.AuthUser{}, apperror.ErrInvalidToken
+	}
+	if user.IsSuspended(now) {
+		return auth.AuthUser{}, apperror.ErrUserInactive
+	}
+
+	if pat.LastUsedAt == nil || now.Sub(*pat.LastUsedAt) >= lastUsedResolution {
+		if err := s.tokenRepo.TouchLastUsed(ctx, pat.ID, now); err != nil {
+			log.Printf("Failed to record use of personal access token %s: %v\n", pat.ID.Hex(), err)
+		}
+	}
+
+	return auth.AuthUser{
+		ID:            user.ID.Hex(),
+		TokenID:       pat.ID.Hex(),
+		ExpiresAt:     pat.ExpiresAt,
+		Role:          string(model.UserRole), // admin powers are never handed to a token
+		EmailVerified: user.IsEmailVerified(),
+		Scopes:        append([]model.TokenScope{}, pat.Scopes...),
+	}, nil
+}
+
+// normalizeTokenScopes rejects unknown scopes and drops duplicates
+func normalizeTokenScopes(scopes []model.TokenScope) ([]model.TokenScope, error) {
+	normalized := make([]model.TokenScope, 0, len(scopes))
+	for _, scope := range scopes {
+		if !model.IsValidTokenScope(scope) {
+			return nil, apperror.ErrInvalidTokenScope
+		}
+		if !containsTokenScope(normalized, scope) {
+			normalized = append(normalized, scope)
+		}
+	}
+	if len(normalized) == 0 {
+		return nil, apperror.ErrInvalidTokenScope
+	}
+	return normalized, nil
+}
+
+func containsTokenScope(scopes []model.TokenScope, scope model.TokenScope) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
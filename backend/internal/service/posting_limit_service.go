@@ -0,0 +1,160 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/giakiet05/lkforum/internal/apperror"
+	"github.com/giakiet05/lkforum/internal/model"
+	"github.com/giakiet05/lkforum/internal/repo"
+	"github.com/giakiet05/lkforum/internal/util"
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// PostingLimitService enforces a community's own throttles on top of its bans and mutes: slow mode,
+// minimum account age and minimum karma. Accounts with an unverified email cannot post anywhere.
+// CheckCanPost and CheckCanComment are meant to be called right before content is saved; a
+// successful check starts the slow mode interval. There is no post or comment service yet, so
+// nothing calls them and the settings are stored but not enforced until those services exist.
+type PostingLimitService interface {
+	CheckCanPost(communityID string, userID string) error
+	CheckCanComment(communityID string, userID string) error
+}
+
+type postingLimitService struct {
+	communityRepo       repo.CommunityRepo
+	userRepo            repo.UserRepo
+	redisClient         *redis.Client
+	communityBanService CommunityBanService
+}
+
+func NewPostingLimitService(
+	communityRepo repo.CommunityRepo,
+	userRepo repo.UserRepo,
+	redisClient *redis.Client,
+	communityBanService CommunityBanService,
+) PostingLimitService {
+	return &postingLimitService{
+		communityRepo:       communityRepo,
+		userRepo:            userRepo,
+		redisClient:         redisClient,
+		communityBanService: communityBanService,
+	}
+}
+
+// CheckCanPost is checked right before a post is saved. Not called yet, see PostingLimitService.
+func (s *postingLimitService) CheckCanPost(communityID string, userID string) error {
+	return s.check(communityID, userID, "post", func(setting *model.CommunitySetting) int {
+		return setting.PostIntervalSeconds
+	})
+}
+
+// CheckCanComment is checked right before a comment is saved. Not called yet, see PostingLimitService.
+func (s *postingLimitService) CheckCanComment(communityID string, userID string) error {
+	return s.check(communityID, userID, "comment", func(setting *model.CommunitySetting) int {
+		return setting.CommentIntervalSeconds
+	})
+}
+
+func (s *postingLimitService) check(communityID string, userID string, kind string, interval func(*model.CommunitySetting) int) error {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	if err := s.communityBanService.CheckCanWrite(communityID, userID); err != nil {
+		return err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return apperror.ErrUserNotFound
+		}
+		return err
+	}
+	if !user.IsEmailVerified() {
+		return apperror.ErrEmailNotVerified
+	}
+
+	community, err := s.communityRepo.GetByID(ctx, communityID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return apperror.ErrCommunityNotFound
+		}
+		return err
+	}
+
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return apperror.ErrInvalidID
+	}
+	if isCommunityModerator(community, userObjectID) {
+		return nil
+	}
+
+	setting := &community.Setting
+	if setting.MinAccountAgeDays > 0 || setting.MinKarma > 0 {
+		if err := s.checkAuthor(setting, user); err != nil {
+			return err
+		}
+	}
+
+	if seconds := interval(setting); seconds > 0 {
+		return s.claimSlowMode(communityID, userID, kind, time.Duration(seconds)*time.Second)
+	}
+	return nil
+}
+
+// checkAuthor holds back accounts younger than the minimum age or below the minimum karma
+func (s *postingLimitService) checkAuthor(setting *model.CommunitySetting, user *model.User) error {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	if setting.MinAccountAgeDays > 0 {
+		allowedAt := user.CreateAt.AddDate(0, 0, setting.MinAccountAgeDays)
+		if wait := time.Until(allowedAt); wait > 0 {
+			return apperror.ErrAccountTooNew.WithMessage(
+				"Your account must be at least %d days old to post in this community. You can post in %s",
+				setting.MinAccountAgeDays, util.FormatWait(wait))
+		}
+	}
+
+	if setting.MinKarma > 0 {
+		karma, err := s.userRepo.GetKarma(ctx, user.ID.Hex())
+		if err != nil {
+			return err
+		}
+		if karma < setting.MinKarma {
+			return apperror.ErrNotEnoughKarma.WithMessage(
+				"You need at least %d karma to post in this community, you have %d", setting.MinKarma, karma)
+		}
+	}
+
+	return nil
+}
+
+// claimSlowMode starts the user's interval in Redis, or reports how long is left of the running one.
+// If Redis is unavailable slow mode is skipped rather than blocking everyone.
+func (s *postingLimitService) claimSlowMode(communityID string, userID string, kind string, interval time.Duration) error {
+	ctx, cancel := util.NewDefaultRedisContext()
+	defer cancel()
+
+	key := fmt.Sprintf("slowmode:%s:%s:%s", communityID, kind, userID)
+	claimed, err := s.redisClient.SetNX(ctx, key, 1, interval).Result()
+	if err != nil {
+		log.Printf("slow mode check failed for community %s: %v", communityID, err)
+		return nil
+	}
+	if claimed {
+		return nil
+	}
+
+	wait, err := s.redisClient.PTTL(ctx, key).Result()
+	if err != nil || wait <= 0 {
+		wait = interval
+	}
+	return apperror.ErrSlowMode.WithMessage("This community is in slow mode. You can %s again in %s", kind, util.FormatWait(wait))
+}
@@ -0,0 +1,522 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/giakiet05/lkforum/internal/apperror"
+	"github.com/giakiet05/lkforum/internal/dto"
+	"github.com/giakiet05/lkforum/internal/model"
+	"github.com/giakiet05/lkforum/internal/repo"
+	"github.com/giakiet05/lkforum/internal/util"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// RemovalService manages each community's library of removal reasons and lets moderators remove
+// posts and comments with one. The author is told why and can appeal the removal.
+type RemovalService interface {
+	GetReasons(communityID string, userID string) ([]model.RemovalReason, error)
+	CreateReason(communityID string, req *dto.CreateRemovalReasonRequest, userID string) (*model.RemovalReason, error)
+	UpdateReason(communityID string, reasonID string, req *dto.UpdateRemovalReasonRequest, userID string) (*model.RemovalReason, error)
+	DeleteReason(communityID string, reasonID string, userID string) error
+
+	RemovePost(communityID string, postID string, req *dto.RemoveContentRequest, actorID string, isAdmin bool) error
+	RemoveComment(communityID string, commentID string, req *dto.RemoveContentRequest, actorID string, isAdmin bool) error
+	RestorePost(communityID string, postID string, actorID string, isAdmin bool) error
+	RestoreComment(communityID string, commentID string, actorID string, isAdmin bool) error
+}
+
+type removalService struct {
+	removalRepo         repo.RemovalRepo
+	communityRepo       repo.CommunityRepo
+	reportService       ReportService
+	notificationService NotificationService
+	modLogService       ModLogService
+}
+
+func NewRemovalService(
+	removalRepo repo.RemovalRepo,
+	communityRepo repo.CommunityRepo,
+	reportService ReportService,
+	notificationService NotificationService,
+	modLogService ModLogService,
+) RemovalService {
+	return &removalService{
+		removalRepo:         removalRepo,
+		communityRepo:       communityRepo,
+		reportService:       reportService,
+		notificationService: notificationService,
+		modLogService:       modLogService,
+	}
+}
+
+// GetReasons lists the library to every moderator, since anyone who removes content picks from it
+func (s *removalService) GetReasons(communityID string, userID string) ([]model.RemovalReason, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	community, err := s.getCommunity(communityID)
+	if err != nil {
+		return nil, err
+	}
+
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, apperror.ErrInvalidID
+	}
+	if !canModerate(community, userObjectID) {
+		return nil, apperror.ErrForbidden
+	}
+
+	return s.removalRepo.GetReasonsByCommunity(ctx, communityID)
+}
+
+func (s *removalService) CreateReason(communityID string, req *dto.CreateRemovalReasonRequest, userID string) (*model.RemovalReason, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	community, err := s.getCommunity(communityID)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireModPermission(community, userID, model.ModPermissionSettings); err != nil {
+		return nil, err
+	}
+
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, apperror.ErrInvalidID
+	}
+
+	reason, err := s.removalRepo.CreateReason(ctx, &model.RemovalReason{
+		CommunityID: community.ID,
+		Title:       req.Title,
+		Message:     req.Message,
+		CreatedBy:   userObjectID,
+		CreatedAt:   time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.recordReasonChange(community.ID, userObjectID, nil, reason)
+	return reason, nil
+}
+
+func (s *removalService) UpdateReason(communityID string, reasonID string, req *dto.UpdateRemovalReasonRequest, userID string) (*model.RemovalReason, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	community, existing, err := s.getReasonForSettings(communityID, reasonID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := bson.M{}
+	if req.Title != nil {
+		updates["title"] = *req.Title
+	}
+	if req.Message != nil {
+		updates["message"] = *req.Message
+	}
+	if len(updates) == 0 {
+		return nil, apperror.ErrNoFieldsToUpdate
+	}
+	updates["updated_at"] = time.Now()
+
+	updated, err := s.removalRepo.UpdateReason(ctx, existing.ID, updates)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, apperror.ErrRemovalReasonNotFound
+		}
+		return nil, err
+	}
+
+	userObjectID, _ := primitive.ObjectIDFromHex(userID)
+	s.recordReasonChange(community.ID, userObjectID, existing, updated)
+	return updated, nil
+}
+
+// DeleteReason removes the template from the library. Bans and removals that used it keep their reason text.
+func (s *removalService) DeleteReason(communityID string, reasonID string, userID string) error {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	community, existing, err := s.getReasonForSettings(communityID, reasonID, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.removalRepo.DeleteReason(ctx, existing.ID); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return apperror.ErrRemovalReasonNotFound
+		}
+		return err
+	}
+
+	userObjectID, _ := primitive.ObjectIDFromHex(userID)
+	s.recordReasonChange(community.ID, userObjectID, existing, nil)
+	return nil
+}
+
+func (s *removalService) RemovePost(communityID string, postID string, req *dto.RemoveContentRequest, actorID string, isAdmin bool) error {
+	community, err := s.getModeratedCommunity(communityID, actorID, isAdmin)
+	if err != nil {
+		return err
+	}
+
+	post, err := s.getPost(community, postID)
+	if err != nil {
+		return err
+	}
+
+	return s.remove(community, removalTarget{
+		targetType: model.ModTargetPost,
+		targetID:   post.ID,
+		authorID:   post.AuthorID,
+		status:     post.ModerationStatus,
+		action:     model.ModActionRemovePost,
+		setStatus:  s.removalRepo.SetPostModerationStatus,
+	}, req, actorID, isAdmin)
+}
+
+func (s *removalService) RemoveComment(communityID string, commentID string, req *dto.RemoveContentRequest, actorID string, isAdmin bool) error {
+	community, err := s.getModeratedCommunity(communityID, actorID, isAdmin)
+	if err != nil {
+		return err
+	}
+
+	comment, err := s.getComment(community, commentID)
+	if err != nil {
+		return err
+	}
+
+	return s.remove(community, removalTarget{
+		targetType: model.ModTargetComment,
+		targetID:   comment.ID,
+		authorID:   comment.AuthorID,
+		status:     comment.ModerationStatus,
+		action:     model.ModActionRemoveComment,
+		setStatus:  s.removalRepo.SetCommentModerationStatus,
+	}, req, actorID, isAdmin)
+}
+
+func (s *removalService) RestorePost(communityID string, postID string, actorID string, isAdmin bool) error {
+	community, err := s.getModeratedCommunity(communityID, actorID, isAdmin)
+	if err != nil {
+		return err
+	}
+
+	post, err := s.getPost(community, postID)
+	if err != nil {
+		return err
+	}
+
+	return s.restore(community, removalTarget{
+		targetType: model.ModTargetPost,
+		targetID:   post.ID,
+		authorID:   post.AuthorID,
+		status:     post.ModerationStatus,
+		action:     model.ModActionRestorePost,
+		setStatus:  s.removalRepo.SetPostModerationStatus,
+	}, actorID, isAdmin)
+}
+
+func (s *removalService) RestoreComment(communityID string, commentID string, actorID string, isAdmin bool) error {
+	community, err := s.getModeratedCommunity(communityID, actorID, isAdmin)
+	if err != nil {
+		return err
+	}
+
+	comment, err := s.getComment(community, commentID)
+	if err != nil {
+		return err
+	}
+
+	return s.restore(community, removalTarget{
+		targetType: model.ModTargetComment,
+		targetID:   comment.ID,
+		authorID:   comment.AuthorID,
+		status:     comment.ModerationStatus,
+		action:     model.ModActionRestoreComment,
+		setStatus:  s.removalRepo.SetCommentModerationStatus,
+	}, actorID, isAdmin)
+}
+
+// removalTarget is the post or comment being removed or restored
+type removalTarget struct {
+	targetType model.ModTargetType
+	targetID   primitive.ObjectID
+	authorID   primitive.ObjectID
+	status     model.ModerationStatus
+	action     model.ModAction
+	setStatus  func(ctx context.Context, id primitive.ObjectID, status model.ModerationStatus) error
+}
+
+// remove hides the content, records the removal as an appealable action, resolves the report it
+// answers if one is given and tells the author why
+func (s *removalService) remove(community *model.Community, target removalTarget, req *dto.RemoveContentRequest, actorID string, isAdmin bool) error {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	if target.status == model.ModerationStatusRemoved {
+		return nil
+	}
+
+	actorObjectID, err := primitive.ObjectIDFromHex(actorID)
+	if err != nil {
+		return apperror.ErrInvalidID
+	}
+
+	reason, reasonID, err := resolveRemovalReason(s.removalRepo, community.ID, req.RemovalReasonID, req.Note)
+	if err != nil {
+		return err
+	}
+
+	if req.ReportID != "" {
+		report, err := s.reportService.GetReportByID(req.ReportID, actorID, isAdmin)
+		if err != nil {
+			return err
+		}
+		if report.TargetID != target.targetID {
+			return apperror.ErrInvalidReportTarget
+		}
+	}
+
+	if err := target.setStatus(ctx, target.targetID, model.ModerationStatusRemoved); err != nil {
+		return err
+	}
+
+	entry := &model.ModLog{
+		CommunityID:     &community.ID,
+		ActorID:         actorObjectID,
+		ActorRole:       modLogActorRole(isAdmin),
+		Action:          target.action,
+		TargetType:      target.targetType,
+		TargetID:        target.targetID,
+		AffectedUserID:  &target.authorID,
+		Reason:          reason,
+		RemovalReasonID: reasonID,
+		Before:          map[string]interface{}{"moderation_status": target.status},
+		After:           map[string]interface{}{"moderation_status": model.ModerationStatusRemoved},
+	}
+	s.modLogService.Record(entry)
+
+	if req.ReportID != "" {
+		statusReq := &dto.UpdateReportStatusRequest{Status: model.ReportStatusActioned, Note: reason}
+		if _, err := s.reportService.UpdateReportStatus(req.ReportID, statusReq, actorID, isAdmin); err != nil && !errors.Is(err, apperror.ErrReportAlreadyResolved) {
+			return err
+		}
+	}
+
+	s.notifyAuthor(community, target, reason, entry)
+	return nil
+}
+
+func (s *removalService) restore(community *model.Community, target removalTarget, actorID string, isAdmin bool) error {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	if target.status != model.ModerationStatusRemoved {
+		return nil
+	}
+
+	actorObjectID, err := primitive.ObjectIDFromHex(actorID)
+	if err != nil {
+		return apperror.ErrInvalidID
+	}
+
+	if err := target.setStatus(ctx, target.targetID, model.ModerationStatusApproved); err != nil {
+		return err
+	}
+
+	s.modLogService.Record(&model.ModLog{
+		CommunityID: &community.ID,
+		ActorID:     actorObjectID,
+		ActorRole:   modLogActorRole(isAdmin),
+		Action:      target.action,
+		TargetType:  target.targetType,
+		TargetID:    target.targetID,
+		Before:      map[string]interface{}{"moderation_status": target.status},
+		After:       map[string]interface{}{"moderation_status": model.ModerationStatusApproved},
+	})
+	return nil
+}
+
+// getModeratedCommunity loads the community and makes sure the actor may remove content in it
+func (s *removalService) getModeratedCommunity(communityID string, actorID string, isAdmin bool) (*model.Community, error) {
+	community, err := s.getCommunity(communityID)
+	if err != nil {
+		return nil, err
+	}
+	if isAdmin {
+		return community, nil
+	}
+	if err := requireModPermission(community, actorID, model.ModPermissionPosts); err != nil {
+		return nil, err
+	}
+	return community, nil
+}
+
+func (s *removalService) getPost(community *model.Community, postID string) (*model.Post, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	if !primitive.IsValidObjectID(postID) {
+		return nil, apperror.ErrInvalidID
+	}
+
+	post, err := s.removalRepo.GetPost(ctx, postID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, apperror.ErrPostNotFound
+		}
+		return nil, err
+	}
+	if post.CommunityID != community.ID {
+		return nil, apperror.ErrPostNotFound
+	}
+
+	return post, nil
+}
+
+// getComment loads a comment on a post of the community
+func (s *removalService) getComment(community *model.Community, commentID string) (*model.Comment, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	if !primitive.IsValidObjectID(commentID) {
+		return nil, apperror.ErrInvalidID
+	}
+
+	comment, err := s.removalRepo.GetComment(ctx, commentID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, apperror.ErrCommentNotFound
+		}
+		return nil, err
+	}
+
+	if _, err := s.getPost(community, comment.PostID.Hex()); err != nil {
+		if errors.Is(err, apperror.ErrPostNotFound) {
+			return nil, apperror.ErrCommentNotFound
+		}
+		return nil, err
+	}
+
+	return comment, nil
+}
+
+// getReasonForSettings loads a template of the community for a moderator allowed to edit the library
+func (s *removalService) getReasonForSettings(communityID string, reasonID string, userID string) (*model.Community, *model.RemovalReason, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	community, err := s.getCommunity(communityID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := requireModPermission(community, userID, model.ModPermissionSettings); err != nil {
+		return nil, nil, err
+	}
+
+	if !primitive.IsValidObjectID(reasonID) {
+		return nil, nil, apperror.ErrInvalidID
+	}
+	reason, err := s.removalRepo.GetReason(ctx, community.ID, reasonID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil, apperror.ErrRemovalReasonNotFound
+		}
+		return nil, nil, err
+	}
+
+	return community, reason, nil
+}
+
+func (s *removalService) getCommunity(communityID string) (*model.Community, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	if !primitive.IsValidObjectID(communityID) {
+		return nil, apperror.ErrInvalidID
+	}
+
+	community, err := s.communityRepo.GetByID(ctx, communityID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, apperror.ErrCommunityNotFound
+		}
+		return nil, err
+	}
+
+	return community, nil
+}
+
+// recordReasonChange logs an edit to the library; before is nil for new templates and after for deleted ones
+func (s *removalService) recordReasonChange(communityID primitive.ObjectID, actorID primitive.ObjectID, before interface{}, after interface{}) {
+	s.modLogService.Record(&model.ModLog{
+		CommunityID: &communityID,
+		ActorID:     actorID,
+		ActorRole:   model.ModLogActorModerator,
+		Action:      model.ModActionUpdateReasons,
+		TargetType:  model.ModTargetCommunity,
+		TargetID:    communityID,
+		Before:      before,
+		After:       after,
+	})
+}
+
+// notifyAuthor tells the author their content was removed, why, and which action to appeal
+func (s *removalService) notifyAuthor(community *model.Community, target removalTarget, reason string, entry *model.ModLog) {
+	message := fmt.Sprintf("Your %s in %s was removed by the moderators. Reason: %s", target.targetType, community.Name, reason)
+	metadata := map[string]interface{}{
+		"community_id": community.ID.Hex(),
+		"target_type":  target.targetType,
+		"target_id":    target.targetID.Hex(),
+		"reason":       reason,
+	}
+	if !entry.ID.IsZero() {
+		metadata["mod_log_id"] = entry.ID.Hex()
+	}
+
+	if err := s.notificationService.Notify(target.authorID, model.NotificationTypeModerator, message, metadata); err != nil {
+		log.Printf("failed to notify user %s of %s removal: %v", target.authorID.Hex(), target.targetType, err)
+	}
+}
+
+// resolveRemovalReason builds the reason shown to the affected user from a template of the community's
+// library and the moderator's own note. Either may be empty, but not both.
+func resolveRemovalReason(removalRepo repo.RemovalRepo, communityID primitive.ObjectID, reasonID string, note string) (string, *primitive.ObjectID, error) {
+	if reasonID == "" {
+		if note == "" {
+			return "", nil, apperror.ErrBadRequest
+		}
+		return note, nil, nil
+	}
+
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	if !primitive.IsValidObjectID(reasonID) {
+		return "", nil, apperror.ErrInvalidID
+	}
+	reason, err := removalRepo.GetReason(ctx, communityID, reasonID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return "", nil, apperror.ErrRemovalReasonNotFound
+		}
+		return "", nil, err
+	}
+
+	text := fmt.Sprintf("%s: %s", reason.Title, reason.Message)
+	if note != "" {
+		text += "\n\nModerator note: " + note
+	}
+	return text, &reason.ID, nil
+}
@@ -0,0 +1,351 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/giakiet05/lkforum/internal/apperror"
+	"github.com/giakiet05/lkforum/internal/dto"
+	"github.com/giakiet05/lkforum/internal/model"
+	"github.com/giakiet05/lkforum/internal/repo"
+	"github.com/giakiet05/lkforum/internal/util"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type ReportService interface {
+	CreateReport(req *dto.CreateReportRequest, reporterID string) (*model.Report, error)
+	GetReportByID(reportID string, userID string, isAdmin bool) (*model.Report, error)
+	GetCommunityReports(communityID string, userID string, status model.ReportStatus, page int, pageSize int) (*dto.PaginatedReportsResponse, error)
+	GetUserReports(status model.ReportStatus, page int, pageSize int) (*dto.PaginatedReportsResponse, error)
+	UpdateReportStatus(reportID string, req *dto.UpdateReportStatusRequest, userID string, isAdmin bool) (*model.Report, error)
+}
+
+type reportService struct {
+	reportRepo          repo.ReportRepo
+	communityRepo       repo.CommunityRepo
+	communityBanService CommunityBanService
+	notificationService NotificationService
+	modLogService       ModLogService
+}
+
+func NewReportService(
+	reportRepo repo.ReportRepo,
+	communityRepo repo.CommunityRepo,
+	communityBanService CommunityBanService,
+	notificationService NotificationService,
+	modLogService ModLogService,
+) ReportService {
+	return &reportService{
+		reportRepo:          reportRepo,
+		communityRepo:       communityRepo,
+		communityBanService: communityBanService,
+		notificationService: notificationService,
+		modLogService:       modLogService,
+	}
+}
+
+func (s *reportService) CreateReport(req *dto.CreateReportRequest, reporterID string) (*model.Report, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	if !isValidReportReason(req.Reason) {
+		return nil, apperror.ErrInvalidReportReason
+	}
+
+	reporterObjectID, err := primitive.ObjectIDFromHex(reporterID)
+	if err != nil {
+		return nil, apperror.ErrInvalidID
+	}
+
+	targetObjectID, err := primitive.ObjectIDFromHex(req.TargetID)
+	if err != nil {
+		return nil, apperror.ErrInvalidID
+	}
+
+	communityID, err := s.resolveReportTarget(req.TargetType, req.TargetID, reporterID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Reporting is taking part like voting: banned users cannot, muted users still can
+	if communityID != nil {
+		if err := s.communityBanService.CheckCanVote(communityID.Hex(), reporterID); err != nil {
+			return nil, err
+		}
+	}
+
+	reported, err := s.reportRepo.HasReported(ctx, req.TargetType, targetObjectID, reporterObjectID)
+	if err != nil {
+		return nil, err
+	}
+	if reported {
+		return nil, apperror.ErrAlreadyReported
+	}
+
+	entry := model.ReportEntry{
+		ReporterID:  reporterObjectID,
+		Reason:      req.Reason,
+		Description: req.Description,
+		CreatedAt:   time.Now(),
+	}
+
+	report, err := s.reportRepo.AddEntry(ctx, req.TargetType, targetObjectID, communityID, entry)
+	if err != nil {
+		// The same reporter raced past HasReported
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, apperror.ErrAlreadyReported
+		}
+		return nil, err
+	}
+	return report, nil
+}
+
+// resolveReportTarget checks that the reported target exists and returns the community it belongs to.
+// User reports have no community and are routed to admins.
+func (s *reportService) resolveReportTarget(targetType model.ReportTargetType, targetID string, reporterID string) (*primitive.ObjectID, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	switch targetType {
+	case model.ReportTypeUser:
+		if targetID == reporterID {
+			return nil, apperror.ErrCannotReportSelf
+		}
+		existed, err := s.reportRepo.IsUserExist(ctx, targetID)
+		if err != nil {
+			return nil, err
+		}
+		if !existed {
+			return nil, apperror.ErrUserNotFound
+		}
+		return nil, nil
+	case model.ReportTypePost:
+		communityID, err := s.reportRepo.GetPostCommunityID(ctx, targetID)
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				return nil, apperror.ErrPostNotFound
+			}
+			return nil, err
+		}
+		return &communityID, nil
+	case model.ReportTypeComment:
+		communityID, err := s.reportRepo.GetCommentCommunityID(ctx, targetID)
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				return nil, apperror.ErrCommentNotFound
+			}
+			return nil, err
+		}
+		return &communityID, nil
+	default:
+		return nil, apperror.ErrInvalidReportTarget
+	}
+}
+
+func (s *reportService) GetReportByID(reportID string, userID string, isAdmin bool) (*model.Report, error) {
+	report, err := s.getReport(reportID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.ensureCanHandle(report, userID, isAdmin); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+func (s *reportService) GetCommunityReports(communityID string, userID string, status model.ReportStatus, page int, pageSize int) (*dto.PaginatedReportsResponse, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	community, err := s.communityRepo.GetByID(ctx, communityID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, apperror.ErrCommunityNotFound
+		}
+		return nil, err
+	}
+
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, apperror.ErrInvalidID
+	}
+	if !hasModPermission(community, userObjectID, model.ModPermissionPosts) {
+		return nil, apperror.ErrForbidden
+	}
+
+	reports, total, err := s.reportRepo.GetPaginated(ctx, communityID, "", status, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.PaginatedReportsResponse{
+		Reports: reports,
+		Pagination: dto.Pagination{
+			Page:     page,
+			PageSize: pageSize,
+			Total:    total,
+		},
+	}, nil
+}
+
+func (s *reportService) GetUserReports(status model.ReportStatus, page int, pageSize int) (*dto.PaginatedReportsResponse, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	reports, total, err := s.reportRepo.GetPaginated(ctx, "", model.ReportTypeUser, status, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.PaginatedReportsResponse{
+		Reports: reports,
+		Pagination: dto.Pagination{
+			Page:     page,
+			PageSize: pageSize,
+			Total:    total,
+		},
+	}, nil
+}
+
+func (s *reportService) UpdateReportStatus(reportID string, req *dto.UpdateReportStatusRequest, userID string, isAdmin bool) (*model.Report, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	report, err := s.getReport(reportID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.ensureCanHandle(report, userID, isAdmin); err != nil {
+		return nil, err
+	}
+
+	if isResolvedReportStatus(report.Status) {
+		return nil, apperror.ErrReportAlreadyResolved
+	}
+
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, apperror.ErrInvalidID
+	}
+
+	updates := bson.M{"status": req.Status}
+	switch req.Status {
+	case model.ReportStatusInReview:
+	case model.ReportStatusActioned, model.ReportStatusDismissed:
+		updates["resolved_by"] = userObjectID
+		updates["resolved_at"] = time.Now()
+		updates["resolution_note"] = req.Note
+	default:
+		return nil, apperror.ErrInvalidReportStatus
+	}
+
+	updated, err := s.reportRepo.Update(ctx, reportID, updates)
+	if err != nil {
+		return nil, err
+	}
+
+	s.modLogService.Record(&model.ModLog{
+		CommunityID: report.CommunityID,
+		ActorID:     userObjectID,
+		ActorRole:   modLogActorRole(isAdmin),
+		Action:      model.ModActionUpdateReport,
+		TargetType:  model.ModTargetReport,
+		TargetID:    report.ID,
+		Reason:      req.Note,
+		Before:      map[string]interface{}{"status": report.Status},
+		After:       map[string]interface{}{"status": updated.Status},
+	})
+
+	if isResolvedReportStatus(updated.Status) {
+		s.notifyReporters(updated)
+	}
+
+	return updated, nil
+}
+
+func (s *reportService) getReport(reportID string) (*model.Report, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	report, err := s.reportRepo.GetByID(ctx, reportID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, apperror.ErrReportNotFound
+		}
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// ensureCanHandle checks that the user may triage the report: moderators handle reports on
+// their community's content, admins handle everything including user reports
+func (s *reportService) ensureCanHandle(report *model.Report, userID string, isAdmin bool) error {
+	if isAdmin {
+		return nil
+	}
+	if report.CommunityID == nil {
+		return apperror.ErrForbidden
+	}
+
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	community, err := s.communityRepo.GetByID(ctx, report.CommunityID.Hex())
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return apperror.ErrCommunityNotFound
+		}
+		return err
+	}
+
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return apperror.ErrInvalidID
+	}
+	if !hasModPermission(community, userObjectID, model.ModPermissionPosts) {
+		return apperror.ErrForbidden
+	}
+
+	return nil
+}
+
+// notifyReporters tells everyone who filed the report how it was resolved
+func (s *reportService) notifyReporters(report *model.Report) {
+	reporterIDs := make([]primitive.ObjectID, 0, len(report.Entries))
+	for _, entry := range report.Entries {
+		reporterIDs = append(reporterIDs, entry.ReporterID)
+	}
+
+	message := fmt.Sprintf("Your report on a %s has been reviewed: %s", report.TargetType, report.Status)
+	metadata := map[string]interface{}{
+		"report_id":   report.ID.Hex(),
+		"target_type": report.TargetType,
+		"target_id":   report.TargetID.Hex(),
+		"status":      report.Status,
+	}
+
+	if err := s.notificationService.NotifyMany(reporterIDs, model.NotificationTypeReport, message, metadata); err != nil {
+		log.Printf("failed to notify reporters of report %s: %v", report.ID.Hex(), err)
+	}
+}
+
+func isValidReportReason(reason model.ReportReason) bool {
+	for _, r := range model.ReportReasons {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
+
+func isResolvedReportStatus(status model.ReportStatus) bool {
+	return status == model.ReportStatusActioned || status == model.ReportStatusDismissed
+}
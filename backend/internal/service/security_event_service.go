@@ -0,0 +1,64 @@
+package service
+
+import (
+	"log"
+	"time"
+
+	"github.com/giakiet05/lkforum/internal/apperror"
+	"github.com/giakiet05/lkforum/internal/dto"
+	"github.com/giakiet05/lkforum/internal/model"
+	"github.com/giakiet05/lkforum/internal/repo"
+	"github.com/giakiet05/lkforum/internal/util"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type SecurityEventService interface {
+	Record(event *model.SecurityEvent)
+	GetSecurityEvents(filter repo.SecurityEventFilter, page int, pageSize int) (*dto.PaginatedSecurityEventsResponse, error)
+}
+
+type securityEventService struct {
+	securityEventRepo repo.SecurityEventRepo
+}
+
+func NewSecurityEventService(securityEventRepo repo.SecurityEventRepo) SecurityEventService {
+	return &securityEventService{securityEventRepo: securityEventRepo}
+}
+
+// Record appends an event to the security log. A failure is logged but never fails the request being recorded.
+func (s *securityEventService) Record(event *model.SecurityEvent) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+
+	if _, err := s.securityEventRepo.Create(ctx, event); err != nil {
+		log.Printf("failed to record security event %s for user %s: %v", event.Type, event.UserID.Hex(), err)
+	}
+}
+
+// GetSecurityEvents lists security events across the whole site, for admins
+func (s *securityEventService) GetSecurityEvents(filter repo.SecurityEventFilter, page int, pageSize int) (*dto.PaginatedSecurityEventsResponse, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	if filter.UserID != "" && !primitive.IsValidObjectID(filter.UserID) {
+		return nil, apperror.ErrInvalidID
+	}
+
+	events, total, err := s.securityEventRepo.GetPaginated(ctx, filter, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.PaginatedSecurityEventsResponse{
+		SecurityEvents: events,
+		Pagination: dto.Pagination{
+			Page:     page,
+			PageSize: pageSize,
+			Total:    total,
+		},
+	}, nil
+}
@@ -0,0 +1,306 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/giakiet05/lkforum/internal/apperror"
+	"github.com/giakiet05/lkforum/internal/auth"
+	"github.com/giakiet05/lkforum/internal/config"
+	"github.com/giakiet05/lkforum/internal/dto"
+	"github.com/giakiet05/lkforum/internal/model"
+	"github.com/giakiet05/lkforum/internal/repo"
+	"github.com/giakiet05/lkforum/internal/util"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// SessionService issues tokens per device. Every login starts a session, every refresh moves it
+// to a new refresh token, and revoking a session signs out that device alone.
+//
+// A session is also the family of the refresh tokens it issued. Each of them can be used once: when
+// one comes back after it was already exchanged, either the user or whoever stole it is holding a
+// stale copy, so the whole family is revoked and the reuse is recorded as a security event.
+type SessionService interface {
+	StartSession(user *model.User, client dto.ClientInfo) (*auth.TokenPair, error)
+	RefreshSession(refreshToken string, client dto.ClientInfo) (*auth.TokenPair, error)
+
+	GetSessions(userID string, currentSessionID string) ([]dto.SessionResponse, error)
+	RevokeSession(userID string, sessionID string) error
+	RevokeOtherSessions(userID string, currentSessionID string) error
+	RevokeAllSessions(userID string) error
+}
+
+type sessionService struct {
+	sessionRepo          repo.SessionRepo
+	userRepo             repo.UserRepo
+	securityEventService SecurityEventService
+}
+
+func NewSessionService(sessionRepo repo.SessionRepo, userRepo repo.UserRepo, securityEventService SecurityEventService) SessionService {
+	return &sessionService{
+		sessionRepo:          sessionRepo,
+		userRepo:             userRepo,
+		securityEventService: securityEventService,
+	}
+}
+
+func (s *sessionService) StartSession(user *model.User, client dto.ClientInfo) (*auth.TokenPair, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	now := time.Now()
+	session, err := s.sessionRepo.Create(ctx, &model.Session{
+		UserID:     user.ID,
+		Device:     describeDevice(client.UserAgent),
+		IP:         client.IP,
+		UserAgent:  client.UserAgent,
+		CreatedAt:  now,
+		LastUsedAt: now,
+		ExpiresAt:  sessionExpiry(now),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := auth.GenerateToken(user, session.ID.Hex())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.sessionRepo.Rotate(ctx, session.ID, "", bson.M{
+		"refresh_token_id":  tokens.RefreshTokenID,
+		"refresh_issued_at": tokens.IssuedAt,
+	}); err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// RefreshSession swaps a refresh token for new tokens of the same session. Only the refresh token
+// issued last is accepted, presenting an older one revokes the session.
+func (s *sessionService) RefreshSession(refreshToken string, client dto.ClientInfo) (*auth.TokenPair, error) {
+	claims, err := auth.ParseRefreshToken(refreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	session, err := s.sessionRepo.GetByID(ctx, claims.SessionID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, apperror.ErrInvalidToken
+		}
+		return nil, err
+	}
+	if session.UserID.Hex() != claims.UserID {
+		return nil, apperror.ErrInvalidToken
+	}
+	if !session.IsActive(time.Now()) {
+		return nil, apperror.ErrTokenInvalidated
+	}
+	if session.RefreshTokenID != claims.TokenID {
+		s.revokeReusedSession(session, claims.TokenID, client)
+		return nil, apperror.ErrRefreshTokenReused
+	}
+
+	user, err := s.userRepo.GetByID(ctx, claims.UserID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, apperror.ErrUserNotFound
+		}
+		return nil, err
+	}
+	if user.IsSuspended(time.Now()) {
+		return nil, apperror.ErrUserInactive
+	}
+
+	tokens, err := auth.GenerateToken(user, claims.SessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Another refresh with the same token may have won the race, which makes this one a reuse
+	err = s.sessionRepo.Rotate(ctx, session.ID, claims.TokenID, bson.M{
+		"refresh_token_id":  tokens.RefreshTokenID,
+		"refresh_issued_at": tokens.IssuedAt,
+		"last_used_at":      tokens.IssuedAt,
+		"expires_at":        sessionExpiry(tokens.IssuedAt),
+		"ip":                client.IP,
+		"user_agent":        client.UserAgent,
+		"device":            describeDevice(client.UserAgent),
+	})
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			if current, getErr := s.sessionRepo.GetByID(ctx, claims.SessionID); getErr == nil && current.IsActive(time.Now()) {
+				s.revokeReusedSession(current, claims.TokenID, client)
+				return nil, apperror.ErrRefreshTokenReused
+			}
+			return nil, apperror.ErrTokenInvalidated
+		}
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// GetSessions lists the user's active sessions. Sessions cut off by a sign out of every device,
+// such as after a suspension or password reset, are left out.
+func (s *sessionService) GetSessions(userID string, currentSessionID string) ([]dto.SessionResponse, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	if _, err := primitive.ObjectIDFromHex(userID); err != nil {
+		return nil, apperror.ErrInvalidID
+	}
+
+	sessions, err := s.sessionRepo.GetActiveByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]dto.SessionResponse, 0, len(sessions))
+	for i := range sessions {
+		if auth.TokenSvc != nil && auth.TokenSvc.IsTokenRevoked(ctx, userID, sessions[i].RefreshIssuedAt) {
+			continue
+		}
+		responses = append(responses, dto.FromSession(&sessions[i], currentSessionID))
+	}
+	return responses, nil
+}
+
+func (s *sessionService) RevokeSession(userID string, sessionID string) error {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	if _, err := primitive.ObjectIDFromHex(sessionID); err != nil {
+		return apperror.ErrInvalidID
+	}
+
+	if err := s.sessionRepo.Revoke(ctx, userID, sessionID); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return apperror.ErrSessionNotFound
+		}
+		return err
+	}
+
+	s.revokeTokens([]string{sessionID})
+	return nil
+}
+
+// RevokeOtherSessions signs out every device except the one making the request
+func (s *sessionService) RevokeOtherSessions(userID string, currentSessionID string) error {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	revoked, err := s.sessionRepo.RevokeAllExcept(ctx, userID, currentSessionID)
+	if err != nil {
+		return err
+	}
+
+	s.revokeTokens(revoked)
+	return nil
+}
+
+func (s *sessionService) RevokeAllSessions(userID string) error {
+	return s.RevokeOtherSessions(userID, "")
+}
+
+// revokeReusedSession ends a session whose refresh token was presented a second time and records
+// where the stale token came from
+func (s *sessionService) revokeReusedSession(session *model.Session, tokenID string, client dto.ClientInfo) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	if err := s.sessionRepo.Revoke(ctx, session.UserID.Hex(), session.ID.Hex()); err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		log.Printf("Failed to revoke session %s after refresh token reuse: %v\n", session.ID.Hex(), err)
+	}
+	s.revokeTokens([]string{session.ID.Hex()})
+
+	s.securityEventService.Record(&model.SecurityEvent{
+		UserID:    session.UserID,
+		Type:      model.SecurityEventRefreshTokenReuse,
+		SessionID: &session.ID,
+		IP:        client.IP,
+		UserAgent: client.UserAgent,
+		Details:   fmt.Sprintf("refresh token %s was used again, session last refreshed from %s (%s)", tokenID, session.IP, session.Device),
+	})
+}
+
+// revokeTokens makes the access tokens of revoked sessions stop working before they expire
+func (s *sessionService) revokeTokens(sessionIDs []string) {
+	if auth.TokenSvc == nil {
+		return
+	}
+
+	ctx, cancel := util.NewDefaultRedisContext()
+	defer cancel()
+	for _, sessionID := range sessionIDs {
+		if err := auth.TokenSvc.RevokeSession(ctx, sessionID); err != nil {
+			log.Printf("Failed to revoke tokens of session %s: %v\n", sessionID, err)
+		}
+	}
+}
+
+// sessionExpiry is when a session ends if its refresh token is not used again
+func sessionExpiry(from time.Time) time.Time {
+	expDays := config.GetEnvIntWithDefault("REFRESH_TOKEN_EXP_DAYS", 7)
+	return from.Add(24 * time.Hour * time.Duration(expDays))
+}
+
+// describeDevice turns a user agent into a short label such as "Firefox on Windows"
+func describeDevice(userAgent string) string {
+	if userAgent == "" {
+		return "Unknown device"
+	}
+
+	browser := ""
+	for _, candidate := range []struct{ token, name string }{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+	} {
+		if strings.Contains(userAgent, candidate.token) {
+			browser = candidate.name
+			break
+		}
+	}
+
+	os := ""
+	for _, candidate := range []struct{ token, name string }{
+		{"iPhone", "iOS"},
+		{"iPad", "iPadOS"},
+		{"Android", "Android"},
+		{"Windows", "Windows"},
+		{"Mac OS X", "macOS"},
+		{"Linux", "Linux"},
+	} {
+		if strings.Contains(userAgent, candidate.token) {
+			os = candidate.name
+			break
+		}
+	}
+
+	switch {
+	case browser != "" && os != "":
+		return browser + " on " + os
+	case browser != "":
+		return browser
+	case os != "":
+		return os
+	}
+
+	// Apps and tools such as "okhttp/4.9.0" or "curl/8.0" lead with their name
+	name, _, _ := strings.Cut(userAgent, "/")
+	name, _, _ = strings.Cut(name, " ")
+	return name
+}
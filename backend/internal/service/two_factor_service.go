@@ -0,0 +1,375 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/giakiet05/lkforum/internal/apperror"
+	"github.com/giakiet05/lkforum/internal/auth"
+	"github.com/giakiet05/lkforum/internal/config"
+	"github.com/giakiet05/lkforum/internal/dto"
+	"github.com/giakiet05/lkforum/internal/model"
+	"github.com/giakiet05/lkforum/internal/repo"
+	"github.com/giakiet05/lkforum/internal/util"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const recoveryCodeCount = 10
+
+// TwoFactorService manages TOTP enrollment and the second step of logging in.
+// Wherever a code is asked for, a one-time recovery code works as well.
+type TwoFactorService interface {
+	Enroll(userID string) (*dto.TwoFactorEnrollResponse, error)
+	Confirm(userID string, code string, client dto.ClientInfo) (*dto.TwoFactorConfirmResponse, error)
+	Disable(userID string, password string, code string) error
+	RegenerateRecoveryCodes(userID string, code string) (*dto.RecoveryCodesResponse, error)
+
+	VerifyLogin(mfaToken string, code string, client dto.ClientInfo) (*dto.LoginResponse, error)
+}
+
+type twoFactorService struct {
+	userRepo            repo.UserRepo
+	sessionService      SessionService
+	loginAttemptService LoginAttemptService
+
+	maxChallengeAttempts int64
+}
+
+func NewTwoFactorService(userRepo repo.UserRepo, sessionService SessionService, loginAttemptService LoginAttemptService) TwoFactorService {
+	return &twoFactorService{
+		userRepo:            userRepo,
+		sessionService:      sessionService,
+		loginAttemptService: loginAttemptService,
+
+		maxChallengeAttempts: int64(config.GetEnvIntWithDefault("MFA_MAX_ATTEMPTS", 5)),
+	}
+}
+
+// Enroll starts a new setup. The secret stays pending until Confirm proves the authenticator has it,
+// so starting over simply replaces it.
+func (s *twoFactorService) Enroll(userID string) (*dto.TwoFactorEnrollResponse, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	user, err := s.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TwoFactorEnabled() {
+		return nil, apperror.ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.userRepo.SetTwoFactor(ctx, userID, &model.TwoFactor{PendingSecret: secret}); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, apperror.ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	return &dto.TwoFactorEnrollResponse{
+		Secret:     secret,
+		OTPAuthURI: auth.TOTPURI(secret, user.Username),
+	}, nil
+}
+
+func (s *twoFactorService) Confirm(userID string, code string, client dto.ClientInfo) (*dto.TwoFactorConfirmResponse, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	user, err := s.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TwoFactorEnabled() {
+		return nil, apperror.ErrTwoFactorAlreadyEnabled
+	}
+	if user.TwoFactor == nil || user.TwoFactor.PendingSecret == "" {
+		return nil, apperror.ErrTwoFactorNotPending
+	}
+
+	step, ok := auth.ValidateTOTP(user.TwoFactor.PendingSecret, code, time.Now())
+	if !ok {
+		return nil, apperror.ErrInvalidTwoFactorCode
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	user.TwoFactor = &model.TwoFactor{
+		Enabled:       true,
+		Secret:        user.TwoFactor.PendingSecret,
+		RecoveryCodes: hashes,
+		LastUsedStep:  step,
+		EnabledAt:     &now,
+	}
+	if err := s.userRepo.SetTwoFactor(ctx, userID, user.TwoFactor); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, apperror.ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	// Sessions that never passed the second factor are signed out; this device continues in a new one
+	if err := s.sessionService.RevokeAllSessions(userID); err != nil {
+		log.Printf("Failed to revoke sessions for user %s: %v\n", userID, err)
+	}
+	tokens, err := s.sessionService.StartSession(user, client)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.TwoFactorConfirmResponse{
+		RecoveryCodes: codes,
+		AccessToken:   tokens.AccessToken,
+		RefreshToken:  tokens.RefreshToken,
+	}, nil
+}
+
+func (s *twoFactorService) Disable(userID string, password string, code string) error {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	user, err := s.getUser(userID)
+	if err != nil {
+		return err
+	}
+	if !user.TwoFactorEnabled() {
+		return apperror.ErrTwoFactorNotEnabled
+	}
+	if user.TwoFactorRequired() {
+		return apperror.ErrTwoFactorRequired
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)) != nil {
+		return apperror.ErrInvalidCredentials
+	}
+	if err := s.verifyCode(user, code); err != nil {
+		return err
+	}
+
+	if err := s.userRepo.SetTwoFactor(ctx, userID, nil); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return apperror.ErrUserNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces every recovery code. Only an authenticator code is accepted,
+// a recovery code cannot be used to mint new ones.
+func (s *twoFactorService) RegenerateRecoveryCodes(userID string, code string) (*dto.RecoveryCodesResponse, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	user, err := s.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TwoFactorEnabled() {
+		return nil, apperror.ErrTwoFactorNotEnabled
+	}
+	if err := s.verifyTOTP(user, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	// Re-read so the step claimed by verifyTOTP is kept
+	user, err = s.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	user.TwoFactor.RecoveryCodes = hashes
+	if err := s.userRepo.SetTwoFactor(ctx, userID, user.TwoFactor); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, apperror.ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	return &dto.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// VerifyLogin completes a login that stopped at the MFA challenge. Wrong codes count as failed logins
+// of the account, so guessing codes backs off and locks the account like guessing passwords does.
+// A challenge is burned once it succeeds or after maxChallengeAttempts wrong codes.
+func (s *twoFactorService) VerifyLogin(mfaToken string, code string, client dto.ClientInfo) (*dto.LoginResponse, error) {
+	challenge, err := auth.ParseMFAToken(mfaToken)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.getUser(challenge.UserID)
+	if err != nil {
+		if errors.Is(err, apperror.ErrUserNotFound) {
+			return nil, apperror.ErrInvalidMFAToken
+		}
+		return nil, err
+	}
+	if user.IsSuspended(time.Now()) {
+		return nil, apperror.ErrUserInactive
+	}
+	if !user.TwoFactorEnabled() {
+		return nil, apperror.ErrInvalidMFAToken
+	}
+
+	accountKey := loginAccountKey(user, "")
+	if err := s.loginAttemptService.Check(accountKey, client.IP); err != nil {
+		return nil, err
+	}
+	if err := s.verifyCode(user, code); err != nil {
+		if errors.Is(err, apperror.ErrInvalidTwoFactorCode) {
+			s.loginAttemptService.RecordFailure(accountKey, user, client)
+			s.countChallengeFailure(challenge)
+		}
+		return nil, err
+	}
+
+	if err := s.burnChallenge(challenge); err != nil {
+		return nil, err
+	}
+	s.loginAttemptService.RecordSuccess(accountKey, user, client)
+
+	tokens, err := s.sessionService.StartSession(user, client)
+	if err != nil {
+		return nil, err
+	}
+	return dto.NewLoginResponse(user, tokens.AccessToken, tokens.RefreshToken), nil
+}
+
+// countChallengeFailure burns the challenge once it has seen too many wrong codes, so the password
+// step has to be passed again
+func (s *twoFactorService) countChallengeFailure(challenge *auth.MFAClaims) {
+	if auth.TokenSvc == nil {
+		return
+	}
+
+	ctx, cancel := util.NewDefaultRedisContext()
+	defer cancel()
+
+	count, err := auth.TokenSvc.CountMFAFailure(ctx, challenge.TokenID, challenge.ExpiresAt)
+	if err != nil {
+		log.Printf("Failed to count failed MFA attempt: %v\n", err)
+		return
+	}
+	if count < s.maxChallengeAttempts {
+		return
+	}
+	if err := auth.TokenSvc.DenyAccessToken(ctx, challenge.TokenID, challenge.ExpiresAt); err != nil {
+		log.Printf("Failed to burn MFA challenge: %v\n", err)
+	}
+}
+
+// burnChallenge makes a passed challenge unusable. Of two requests racing with the same challenge
+// only one gets through.
+func (s *twoFactorService) burnChallenge(challenge *auth.MFAClaims) error {
+	if auth.TokenSvc == nil {
+		return nil
+	}
+
+	ctx, cancel := util.NewDefaultRedisContext()
+	defer cancel()
+
+	burned, err := auth.TokenSvc.ConsumeToken(ctx, challenge.TokenID, challenge.ExpiresAt)
+	if err != nil {
+		return err
+	}
+	if !burned {
+		return apperror.ErrInvalidMFAToken
+	}
+	return nil
+}
+
+// verifyCode accepts an authenticator code, or else burns a recovery code
+func (s *twoFactorService) verifyCode(user *model.User, code string) error {
+	code = strings.TrimSpace(code)
+	if len(code) == 6 {
+		return s.verifyTOTP(user, code)
+	}
+
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	if err := s.userRepo.ConsumeRecoveryCode(ctx, user.ID.Hex(), util.HashToken(normalizeRecoveryCode(code))); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return apperror.ErrInvalidTwoFactorCode
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *twoFactorService) verifyTOTP(user *model.User, code string) error {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	step, ok := auth.ValidateTOTP(user.TwoFactor.Secret, code, time.Now())
+	if !ok {
+		return apperror.ErrInvalidTwoFactorCode
+	}
+
+	// A code that was already used, here or in another request racing this one, does not count
+	if err := s.userRepo.ClaimTOTPStep(ctx, user.ID.Hex(), step); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return apperror.ErrInvalidTwoFactorCode
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *twoFactorService) getUser(userID string) (*model.User, error) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, apperror.ErrUserNotFound
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// generateRecoveryCodes returns the codes to show the user once and the hashes to store
+func generateRecoveryCodes() ([]string, []string, error) {
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		raw := strings.ToLower(encoding.EncodeToString(b))[:10]
+
+		codes = append(codes, raw[:5]+"-"+raw[5:])
+		hashes = append(hashes, util.HashToken(raw))
+	}
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode lets users type recovery codes with or without the dash and in any case
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
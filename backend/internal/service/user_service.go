@@ -3,21 +3,30 @@ package service
 import (
 	"errors"
 	"fmt"
+	"log"
+	"net/url"
 	"regexp"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/giakiet05/lkforum/internal/apperror"
 	"github.com/giakiet05/lkforum/internal/auth"
+	"github.com/giakiet05/lkforum/internal/config"
 	"github.com/giakiet05/lkforum/internal/dto"
+	"github.com/giakiet05/lkforum/internal/mail"
 	"github.com/giakiet05/lkforum/internal/model"
 	"github.com/giakiet05/lkforum/internal/repo"
 	"github.com/giakiet05/lkforum/internal/util"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
 	"go.mongodb.org/mongo-driver/mongo"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type UserService interface {
-	RegisterUser(username, email, password string) (*model.User, string, string, error)
-	Login(identifier, password string) (*model.User, string, string, error)
+	RegisterUser(username, email, password string, client dto.ClientInfo) (*model.User, string, string, error)
+	Login(identifier, password string, client dto.ClientInfo) (*dto.LoginResponse, error)
 	UpdateUser(user *model.User) (*model.User, error)
 	DeleteUser(id string) error
 
@@ -27,16 +36,29 @@ type UserService interface {
 	GetUserByEmail(email string) (*model.User, error)
 	ChangePassword(userID, oldPassword, newPassword string) error
 	GetUsers(page, pageSize int) (*dto.PaginatedUsersResponse, error)
-	RefreshToken(refreshToken string) (string, string, error)
+	RefreshToken(refreshToken string, client dto.ClientInfo) (string, string, error)
+	Logout(user auth.AuthUser) error
+
+	VerifyEmail(token string) error
+	ResendVerificationEmail(userID string) error
+
+	ForgotPassword(email string) error
+	ResetPassword(token, newPassword string, client dto.ClientInfo) error
 }
 
 type userService struct {
-	userRepo repo.UserRepo
+	userRepo            repo.UserRepo
+	sessionService      SessionService
+	loginAttemptService LoginAttemptService
+	mailer              mail.Mailer
 }
 
-func NewUserService(userRepo repo.UserRepo) UserService {
+func NewUserService(userRepo repo.UserRepo, sessionService SessionService, loginAttemptService LoginAttemptService, mailer mail.Mailer) UserService {
 	return &userService{
-		userRepo: userRepo,
+		userRepo:            userRepo,
+		sessionService:      sessionService,
+		loginAttemptService: loginAttemptService,
+		mailer:              mailer,
 	}
 }
 func (s *userService) GetAllUsers() ([]*model.User, error) {
@@ -84,7 +106,7 @@ func (s *userService) GetUserByEmail(email string) (*model.User, error) {
 	return user, nil
 }
 
-func (s *userService) RegisterUser(username, email, password string) (*model.User, string, string, error) {
+func (s *userService) RegisterUser(username, email, password string, client dto.ClientInfo) (*model.User, string, string, error) {
 	ctx, cancel := util.NewDefaultDBContext()
 	defer cancel()
 
@@ -111,14 +133,22 @@ func (s *userService) RegisterUser(username, email, password string) (*model.Use
 	if err != nil {
 		return nil, "", "", err
 	}
-	accessToken, refreshToken, err := auth.GenerateToken(createdUser.ID.Hex(), string(createdUser.Role))
+
+	// The account works right away but stays restricted until verified. A failed mail is not fatal,
+	// the user can ask for another one.
+	if err := s.sendVerificationEmail(createdUser); err != nil {
+		log.Printf("Failed to send verification email to user %s: %v\n", createdUser.ID.Hex(), err)
+	}
+	tokens, err := s.sessionService.StartSession(createdUser, client)
 	if err != nil {
 		return nil, "", "", err
 	}
-	return createdUser, accessToken, refreshToken, nil
+	return createdUser, tokens.AccessToken, tokens.RefreshToken, nil
 }
 
-func (s *userService) Login(identifier, password string) (*model.User, string, string, error) {
+// Login checks the password. Accounts with two-factor authentication get an MFA challenge instead of tokens.
+// Failed attempts are throttled, and an unknown identifier takes as long to reject as a wrong password.
+func (s *userService) Login(identifier, password string, client dto.ClientInfo) (*dto.LoginResponse, error) {
 	ctx, cancel := util.NewDefaultDBContext()
 	defer cancel()
 	var user *model.User
@@ -132,19 +162,69 @@ func (s *userService) Login(identifier, password string) (*model.User, string, s
 		user, err = s.userRepo.GetByUsername(ctx, identifier)
 	}
 	if err != nil {
-		if errors.Is(err, mongo.ErrNoDocuments) {
-			return nil, "", "", apperror.ErrInvalidCredentials
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, err
 		}
-		return nil, "", "", err
+		user = nil
+	}
+
+	accountKey := loginAccountKey(user, identifier)
+	if err := s.loginAttemptService.Check(accountKey, client.IP); err != nil {
+		return nil, err
+	}
+
+	if !checkPassword(user, password) {
+		s.loginAttemptService.RecordFailure(accountKey, user, client)
+		return nil, apperror.ErrInvalidCredentials
 	}
-	if user == nil || bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)) != nil {
-		return nil, "", "", apperror.ErrInvalidCredentials
+	// With 2FA the failures are cleared by the second step, or knowing the password would reset the
+	// count of wrong codes
+	if !user.TwoFactorEnabled() {
+		s.loginAttemptService.RecordSuccess(accountKey, user, client)
 	}
-	accessToken, refreshToken, err := auth.GenerateToken(user.ID.Hex(), string(user.Role))
+
+	return completeLogin(s.sessionService, user, client)
+}
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     []byte
+)
+
+// checkPassword compares the password with the user's hash. Without a user or a password it compares
+// with a throwaway hash instead, so every rejected login costs the same bcrypt round.
+func checkPassword(user *model.User, password string) bool {
+	if user == nil || user.Password == "" {
+		dummyHashOnce.Do(func() {
+			dummyHash, _ = bcrypt.GenerateFromPassword([]byte(uuid.New().String()), bcrypt.DefaultCost)
+		})
+		bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)) == nil
+}
+
+// completeLogin finishes a login once the user proved who they are, by password or through an
+// identity provider: suspended users are turned away, 2FA users get an MFA challenge and everyone
+// else starts a new session.
+func completeLogin(sessionService SessionService, user *model.User, client dto.ClientInfo) (*dto.LoginResponse, error) {
+	if user.IsSuspended(time.Now()) {
+		return nil, apperror.ErrUserInactive
+	}
+
+	if user.TwoFactorEnabled() {
+		mfaToken, err := auth.CreateMFAToken(user.ID.Hex())
+		if err != nil {
+			return nil, err
+		}
+		return &dto.LoginResponse{MFARequired: true, MFAToken: mfaToken}, nil
+	}
+
+	tokens, err := sessionService.StartSession(user, client)
 	if err != nil {
-		return nil, "", "", err
+		return nil, err
 	}
-	return user, accessToken, refreshToken, nil
+	return dto.NewLoginResponse(user, tokens.AccessToken, tokens.RefreshToken), nil
 }
 
 func (s *userService) UpdateUser(user *model.User) (*model.User, error) {
@@ -164,12 +244,10 @@ func (s *userService) DeleteUser(id string) error {
 	ctx, cancel := util.NewDefaultDBContext()
 	defer cancel()
 
-	// First invalidate all tokens for this user
-	if auth.TokenSvc != nil {
-		if err := auth.TokenSvc.InvalidateAllUserTokens(ctx, id); err != nil {
-			// Log the error but continue with deletion
-			fmt.Printf("Failed to invalidate tokens for user %s: %v\n", id, err)
-		}
+	// First sign the user out of every session
+	if err := s.sessionService.RevokeAllSessions(id); err != nil {
+		// Log the error but continue with deletion
+		fmt.Printf("Failed to revoke sessions for user %s: %v\n", id, err)
 	}
 
 	// Then delete the user from the database
@@ -239,28 +317,197 @@ func (s *userService) GetUsers(page, pageSize int) (*dto.PaginatedUsersResponse,
 	}, nil
 }
 
-func (s *userService) RefreshToken(refreshToken string) (string, string, error) {
-	userID, err := auth.ParseRefreshToken(refreshToken)
+func (s *userService) RefreshToken(refreshToken string, client dto.ClientInfo) (string, string, error) {
+	tokens, err := s.sessionService.RefreshSession(refreshToken, client)
 	if err != nil {
 		return "", "", err
 	}
+	return tokens.AccessToken, tokens.RefreshToken, nil
+}
+
+// Logout ends the session the access token belongs to and denies the access token itself until it expires
+func (s *userService) Logout(user auth.AuthUser) error {
+	if user.SessionID != "" {
+		if err := s.sessionService.RevokeSession(user.ID, user.SessionID); err != nil && !errors.Is(err, apperror.ErrSessionNotFound) {
+			return err
+		}
+	}
+
+	if auth.TokenSvc == nil || user.TokenID == "" {
+		return nil
+	}
+
+	ctx, cancel := util.NewDefaultRedisContext()
+	defer cancel()
+	return auth.TokenSvc.DenyAccessToken(ctx, user.TokenID, user.ExpiresAt)
+}
+
+func (s *userService) VerifyEmail(token string) error {
+	userID, email, err := auth.ParseEmailVerificationToken(token)
+	if err != nil {
+		return err
+	}
 
 	ctx, cancel := util.NewDefaultDBContext()
 	defer cancel()
+
 	user, err := s.userRepo.GetByID(ctx, userID)
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
-			return "", "", apperror.ErrUserNotFound
+			return apperror.ErrUserNotFound
 		}
-		return "", "", err
+		return err
+	}
+	// Links sent to an address the user has since replaced are void
+	if !strings.EqualFold(user.Email, email) {
+		return apperror.ErrInvalidVerificationToken
+	}
+	if user.IsEmailVerified() {
+		return apperror.ErrEmailAlreadyVerified
 	}
 
-	accessToken, newRefreshToken, err := auth.GenerateToken(user.ID.Hex(), string(user.Role))
+	if err := s.userRepo.MarkEmailVerified(ctx, userID, user.Email, time.Now()); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return apperror.ErrInvalidVerificationToken
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *userService) ResendVerificationEmail(userID string) error {
+	user, err := s.GetUserByID(userID)
 	if err != nil {
-		return "", "", err
+		return err
 	}
+	if user.IsEmailVerified() {
+		return apperror.ErrEmailAlreadyVerified
+	}
+
+	return s.sendVerificationEmail(user)
+}
+
+// ForgotPassword mails a reset link when the email belongs to an account. It returns before looking the
+// email up, and the lookup, token and mail all happen in the background, so neither the response nor its
+// timing tells callers which addresses are registered.
+func (s *userService) ForgotPassword(email string) error {
+	if auth.TokenSvc == nil {
+		return apperror.ErrInternal
+	}
+
+	go s.sendPasswordResetLink(email)
+	return nil
+}
+
+// sendPasswordResetLink does the work behind ForgotPassword; failures are only logged
+func (s *userService) sendPasswordResetLink(email string) {
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			log.Printf("Failed to look up user for password reset: %v\n", err)
+		}
+		return
+	}
+
+	link, expMinutes, err := issuePasswordResetLink(user)
+	if err != nil {
+		log.Printf("Failed to issue password reset token for user %s: %v\n", user.ID.Hex(), err)
+		return
+	}
+
+	body := fmt.Sprintf("Hi %s,\n\nSomeone asked to reset the password of your account. Open the link below to choose a new one:\n\n%s\n\n"+
+		"The link expires in %d minutes and works once. If you did not ask for this, you can ignore this email.\n",
+		user.Username, link, expMinutes)
+
+	if err := s.mailer.Send(user.Email, "Reset your password", body); err != nil {
+		log.Printf("Failed to send password reset email to user %s: %v\n", user.ID.Hex(), err)
+	}
+}
+
+// issuePasswordResetLink stores a single-use reset token for the user, replacing any earlier one,
+// and returns the link that carries it along with how many minutes it stays valid
+func issuePasswordResetLink(user *model.User) (string, int, error) {
+	if auth.TokenSvc == nil {
+		return "", 0, apperror.ErrInternal
+	}
+
+	token, err := util.RandomToken(32)
+	if err != nil {
+		return "", 0, err
+	}
+
+	expMinutes := config.GetEnvIntWithDefault("PASSWORD_RESET_EXP_MIN", 30)
+	redisCtx, redisCancel := util.NewDefaultRedisContext()
+	defer redisCancel()
+	if err := auth.TokenSvc.StorePasswordResetToken(redisCtx, user.ID.Hex(), util.HashToken(token), time.Duration(expMinutes)*time.Minute); err != nil {
+		return "", 0, err
+	}
+
+	link := fmt.Sprintf("%s/reset-password?token=%s", config.GetEnvWithDefault("FRONTEND_URL", "http://localhost:5173"), url.QueryEscape(token))
+	return link, expMinutes, nil
+}
+
+// ResetPassword sets a new password from a reset token, signs the user out everywhere and lifts a login lockout
+func (s *userService) ResetPassword(token, newPassword string, client dto.ClientInfo) error {
+	if auth.TokenSvc == nil {
+		return apperror.ErrInternal
+	}
+
+	redisCtx, redisCancel := util.NewDefaultRedisContext()
+	defer redisCancel()
+	userID, err := auth.TokenSvc.ConsumePasswordResetToken(redisCtx, util.HashToken(token))
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return apperror.ErrInvalidResetToken
+		}
+		return err
+	}
+
+	ctx, cancel := util.NewDefaultDBContext()
+	defer cancel()
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return apperror.ErrInvalidResetToken
+		}
+		return err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	user.Password = string(hashedPassword)
+	if _, err := s.userRepo.Update(ctx, user); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return apperror.ErrUserNotFound
+		}
+		return err
+	}
+
+	if _, err := s.loginAttemptService.Unlock(userID, "password reset", client); err != nil {
+		log.Printf("Failed to unlock user %s after password reset: %v\n", userID, err)
+	}
+	return s.sessionService.RevokeAllSessions(userID)
+}
+
+// sendVerificationEmail mails a signed link pointing at the frontend, which posts the token back to /auth/verify-email
+func (s *userService) sendVerificationEmail(user *model.User) error {
+	token, err := auth.CreateEmailVerificationToken(user.ID.Hex(), user.Email)
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/verify-email?token=%s", config.GetEnvWithDefault("FRONTEND_URL", "http://localhost:5173"), url.QueryEscape(token))
+	body := fmt.Sprintf("Hi %s,\n\nPlease confirm your email address by opening the link below:\n\n%s\n\n"+
+		"The link expires in %d hours. If you did not create an account, you can ignore this email.\n",
+		user.Username, link, config.GetEnvIntWithDefault("EMAIL_VERIFICATION_EXP_HOURS", 24))
 
-	return accessToken, newRefreshToken, nil
+	return s.mailer.Send(user.Email, "Verify your email address", body)
 }
 
 // isEmail checks if the given string is a valid email address format
@@ -0,0 +1,31 @@
+package util
+
+import (
+	"fmt"
+	"time"
+)
+
+// FormatWait renders a wait time for users, rounded up to the largest sensible unit
+func FormatWait(d time.Duration) string {
+	switch {
+	case d >= 24*time.Hour:
+		days := int((d + 24*time.Hour - 1) / (24 * time.Hour))
+		return pluralize(days, "day")
+	case d >= time.Hour:
+		hours := int((d + time.Hour - 1) / time.Hour)
+		return pluralize(hours, "hour")
+	case d >= time.Minute:
+		minutes := int((d + time.Minute - 1) / time.Minute)
+		return pluralize(minutes, "minute")
+	default:
+		seconds := int((d + time.Second - 1) / time.Second)
+		return pluralize(seconds, "second")
+	}
+}
+
+func pluralize(n int, unit string) string {
+	if n == 1 {
+		return "1 " + unit
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
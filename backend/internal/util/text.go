@@ -0,0 +1,89 @@
+package util
+
+import (
+	"strings"
+	"unicode"
+)
+
+// foldTable maps letters with diacritics, look-alike letters from other scripts and leetspeak digits
+// and symbols to the plain ASCII letter they stand for
+var foldTable = buildFoldTable(map[rune]string{
+	'a': "àáảãạăằắẳẵặâầấẩẫậäåāąǎаα@4",
+	'b': "вβ8",
+	'c': "çćčсϲ",
+	'd': "đďԁ",
+	'e': "èéẻẽẹêềếểễệëēęěеε3€",
+	'g': "ğ9",
+	'h': "һн",
+	'i': "ìíỉĩịîïīįıіίι1!|",
+	'j': "ј",
+	'k': "кκ",
+	'l': "łľĺ",
+	'm': "мμ",
+	'n': "ñńňпη",
+	'o': "òóỏõọôồốổỗộơờớởỡợöøōőоοσ0",
+	'p': "рρ",
+	's': "śšşѕ$5",
+	't': "ťţтτ7",
+	'u': "ùúủũụưừứửữựûüūůűυ",
+	'v': "νѵ",
+	'w': "ŵω",
+	'x': "хχ",
+	'y': "ỳýỷỹỵÿŷуγ",
+	'z': "źżž",
+})
+
+func buildFoldTable(groups map[rune]string) map[rune]rune {
+	table := make(map[rune]rune)
+	for base, variants := range groups {
+		for _, r := range variants {
+			table[r] = base
+		}
+	}
+	return table
+}
+
+// NormalizeForMatching folds text so that disguised words compare equal to their plain spelling:
+// lower case, Vietnamese and other diacritics removed, homoglyphs, fullwidth forms and leetspeak
+// mapped to ASCII, zero-width characters dropped and whitespace runs collapsed to one space.
+// It also returns, for every normalized rune, the index of the rune of text it came from,
+// so matches can be mapped back onto the original.
+func NormalizeForMatching(text string) ([]rune, []int) {
+	runes := []rune(text)
+	normalized := make([]rune, 0, len(runes))
+	origins := make([]int, 0, len(runes))
+
+	for i, r := range runes {
+		switch {
+		case r >= 0x0300 && r <= 0x036F: // combining diacritical marks
+			continue
+		case r == 0x200B || r == 0x200C || r == 0x200D || r == 0xFEFF || r == 0x00AD: // zero-width and soft hyphen
+			continue
+		case r >= 0xFF01 && r <= 0xFF5E: // fullwidth ASCII
+			r -= 0xFEE0
+		}
+
+		if unicode.IsSpace(r) {
+			if len(normalized) == 0 || normalized[len(normalized)-1] == ' ' {
+				continue
+			}
+			r = ' '
+		}
+
+		r = unicode.ToLower(r)
+		if folded, ok := foldTable[r]; ok {
+			r = folded
+		}
+
+		normalized = append(normalized, r)
+		origins = append(origins, i)
+	}
+
+	return normalized, origins
+}
+
+// NormalizeString is NormalizeForMatching without the index mapping
+func NormalizeString(text string) string {
+	normalized, _ := NormalizeForMatching(text)
+	return strings.TrimSpace(string(normalized))
+}